@@ -2,11 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/params"
+	"cfd-cli/txbuilder"
 )
 
 // AddSignTransactionCmd append tx input.
@@ -16,6 +20,7 @@ type AddSignTransactionCmd struct {
 	txFilePath   *string
 	tx           *string
 	isElements   *bool
+	nettype      *string
 	txid         *string
 	vout         *uint
 	signature    *string
@@ -48,15 +53,16 @@ func (cmd *AddSignTransactionCmd) Init() {
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.txid = cmd.flagSet.String("txid", "", "append transaction id")
 	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "append transaction output number")
-	cmd.signature = cmd.flagSet.String("signature", "", "signature")
+	cmd.signature = cmd.flagSet.String("signature", "",
+		"signature (multisig: sig1,sig2,... script: sig:<compact signature>,data:<hex>,...)")
 	cmd.pubkey = cmd.flagSet.String("pubkey", "", "pubkey")
 	cmd.redeemScript = cmd.flagSet.String("script", "", "redeem script")
 	cmd.addrType = cmd.flagSet.String("addresstype", "",
 		"txin's utxo addressType (p2wpkh, p2wsh, p2sh-p2wpkh, p2sh-p2wsh, p2pkh, p2sh)")
-	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all",
-		"sighashtype (all,single,none)")
+	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
 	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "sighash anyonecanpay flag")
 }
 
@@ -66,53 +72,45 @@ func (cmd *AddSignTransactionCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *AddSignTransactionCmd) Do(ctx context.Context) {
+func (cmd *AddSignTransactionCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
-	}
-
-	if *cmd.isElements == false {
-		fmt.Println("bitcoin tx sign is not implements.")
-		return
+		return errors.New("tx is required")
 	}
 
 	// other input parameter check
 	if len(*cmd.txid) != 64 {
-		fmt.Println("txid size invalid.")
-		return
+		return errors.New("txid size invalid.")
+	}
+
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sigHashType, *cmd.anyoneCanPay)
+	if err == nil {
+		err = sighashType.ValidateEcdsa(*cmd.isElements)
+	}
+	if err != nil {
+		return err
 	}
 
-	sigHashType := int(cfd.KCfdSigHashAll)
-	switch *cmd.sigHashType {
-	case "all":
-		sigHashType = int(cfd.KCfdSigHashAll)
-	case "none":
-		sigHashType = int(cfd.KCfdSigHashNone)
-	case "single":
-		sigHashType = int(cfd.KCfdSigHashSingle)
-	default:
-		fmt.Printf("sighashtype %s is unknown type.", *cmd.sigHashType)
-		return
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
 	}
 
 	addrType := -1
 	redeemScript := *cmd.redeemScript
 	pubkey := *cmd.pubkey
-	tempPubkey, tempScript, tempAddrType, _, _, err := GetDescriptorInfoFromUtxoList(
-		*cmd.txid, uint32(*cmd.vout), data.Utxos)
+	tempPubkey, tempScript, tempAddrType, _, _, err := txbuilder.GetDescriptorInfoFromUtxoList(
+		*cmd.txid, uint32(*cmd.vout), data.Utxos, networkType)
 	if tempAddrType != -1 {
 		if len(*cmd.addrType) == 0 {
 			addrType = tempAddrType
@@ -140,8 +138,7 @@ func (cmd *AddSignTransactionCmd) Do(ctx context.Context) {
 		case "p2wsh":
 			addrType = int(cfd.KCfdP2wsh)
 		default:
-			fmt.Printf("addresstype %s is unknown type.", *cmd.addrType)
-			return
+			return fmt.Errorf("addresstype %s is unknown type.", *cmd.addrType)
 		}
 	}
 
@@ -163,133 +160,80 @@ func (cmd *AddSignTransactionCmd) Do(ctx context.Context) {
 		signData := cfd.CfdSignParameter{
 			Data:                *cmd.signature,
 			IsDerEncode:         true,
-			SighashType:         sigHashType,
-			SighashAnyoneCanPay: *cmd.anyoneCanPay,
+			SighashType:         sighashType.GetCfdType(),
+			SighashAnyoneCanPay: sighashType.AnyoneCanPay,
+		}
+		if *cmd.isElements {
+			txHex, err = cfd.CfdGoAddConfidentialTxPubkeyHashSign(
+				tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
+				signData)
+		} else {
+			txHex, err = cfd.CfdGoAddTxPubkeyHashSign(networkType,
+				tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
+				signData)
 		}
-		txHex, err = cfd.CfdGoAddConfidentialTxPubkeyHashSign(
-			tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
-			signData)
 	} else if isMulti {
-		sigList := strings.Split(*cmd.signature, ",")
-		pubkeyList := strings.Split(*cmd.pubkey, ",")
-		if len(pubkeyList) > 0 && len(sigList) != len(pubkeyList) {
-			fmt.Println("pubkey count is unmatch signature count.")
-			return
+		var sigList []params.SignatureItem
+		if sigList, err = params.ParseSignatures(*cmd.signature, *cmd.pubkey); err != nil {
+			return err
 		}
 		signList := []cfd.CfdMultisigSignData{}
-		for index, signature := range sigList {
-			if len(signature) > 0 {
-				pubkey := ""
-				if len(pubkeyList) > 0 {
-					pubkey = pubkeyList[index]
-				}
-				data := cfd.CfdMultisigSignData{
-					Signature:           signature,
-					IsDerEncode:         true,
-					SighashType:         sigHashType,
-					SighashAnyoneCanPay: *cmd.anyoneCanPay,
-					RelatedPubkey:       pubkey,
-				}
-				signList = append(signList, data)
+		for _, signature := range sigList {
+			data := cfd.CfdMultisigSignData{
+				Signature:           signature.Signature,
+				IsDerEncode:         true,
+				SighashType:         sighashType.GetCfdType(),
+				SighashAnyoneCanPay: sighashType.AnyoneCanPay,
+				RelatedPubkey:       signature.Pubkey,
 			}
+			signList = append(signList, data)
+		}
+		if *cmd.isElements {
+			txHex, err = cfd.CfdGoAddConfidentialTxMultisigSign(
+				tx, *cmd.txid, uint32(*cmd.vout), addrType,
+				signList, redeemScript)
+		} else {
+			txHex, err = cfd.CfdGoAddTxMultisigSign(networkType,
+				tx, *cmd.txid, uint32(*cmd.vout), addrType,
+				signList, redeemScript)
 		}
-		txHex, err = cfd.CfdGoAddConfidentialTxMultisigSign(
-			tx, *cmd.txid, uint32(*cmd.vout), addrType,
-			signList, redeemScript)
 	} else {
-		sigList := strings.Split(*cmd.signature, ",")
+		var elements []params.ScriptElement
+		if elements, err = params.ParseScriptElements(*cmd.signature); err != nil {
+			return err
+		}
 		signList := []cfd.CfdSignParameter{}
-		for _, signature := range sigList {
-			if len(signature) > 0 {
-				data := cfd.CfdSignParameter{
-					Data:                signature,
-					IsDerEncode:         false,
-					SighashType:         int(cfd.KCfdSigHashAll),
-					SighashAnyoneCanPay: false,
-				}
-				signList = append(signList, data)
+		for _, element := range elements {
+			// the "sig:" compact signature is encoded to der with the sighash type.
+			data := cfd.CfdSignParameter{
+				Data:                element.Data,
+				IsDerEncode:         element.IsSignature,
+				SighashType:         sighashType.GetCfdType(),
+				SighashAnyoneCanPay: sighashType.AnyoneCanPay,
 			}
+			signList = append(signList, data)
+		}
+		if *cmd.isElements {
+			txHex, err = cfd.CfdGoAddConfidentialTxScriptHashSign(
+				tx, *cmd.txid, uint32(*cmd.vout), addrType,
+				signList, redeemScript)
+		} else {
+			txHex, err = cfd.CfdGoAddTxScriptHashSign(networkType,
+				tx, *cmd.txid, uint32(*cmd.vout), addrType,
+				signList, redeemScript)
 		}
-		txHex, err = cfd.CfdGoAddConfidentialTxScriptHashSign(
-			tx, *cmd.txid, uint32(*cmd.vout), addrType,
-			signList, redeemScript)
 	}
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	if *cmd.txFilePath != "" {
 		data.Hex = txHex
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-	}
-}
-
-// GetDescriptorInfoFromUtxoList get descriptor info.
-func GetDescriptorInfoFromUtxoList(txid string, vout uint32, utxoList []UtxoData) (
-	pubkey, redeemScript string, hashType int, amount int64,
-	amountCommitment string, err error) {
-	hashType = -1
-	if utxoList != nil {
-		desc := ""
-		for _, utxo := range utxoList {
-			if utxo.Txid == txid && utxo.Vout == vout {
-				amount = utxo.Amount
-				amountCommitment = utxo.AmountCommitment
-				desc = utxo.Descriptor
-				break
-			}
-		}
-		if len(desc) > 0 {
-			pubkey, redeemScript, hashType, _, err = ParseDescriptor(
-				desc, int(cfd.KCfdNetworkMainnet))
-			if err != nil {
-				fmt.Println(err)
-				return "", "", -1, -1, "", err
-			}
-		}
-	}
-	return pubkey, redeemScript, hashType, amount, amountCommitment, nil
-}
-
-// ParseDescriptor parse descriptor
-func ParseDescriptor(descriptor string, networkType int) (pubkey, redeemScript string, hashType int, address string, err error) {
-	descList, _, err := cfd.CfdGoParseDescriptor(
-		descriptor, networkType, "")
-	if err != nil {
-		fmt.Println(err)
-		return "", "", -1, "", err
-	}
-	hashType = descList[0].HashType
-	address = descList[0].Address
-	redeemScript = descList[len(descList)-1].RedeemScript
-	extkey := ""
-	switch descList[len(descList)-1].KeyType {
-	case int(cfd.KCfdDescriptorKeyPublic):
-		pubkey = descList[len(descList)-1].Pubkey
-	case int(cfd.KCfdDescriptorKeyBip32):
-		extkey = descList[len(descList)-1].ExtPubkey
-	case int(cfd.KCfdDescriptorKeyBip32Priv):
-		extkey = descList[len(descList)-1].ExtPrivkey
-	default:
-		// do nothing
-	}
-	if len(extkey) > 0 {
-		key, err := cfd.CfdGoGetPubkeyFromExtkey(
-			extkey, int(cfd.KCfdNetworkMainnet))
-		if err != nil {
-			key, err = cfd.CfdGoGetPubkeyFromExtkey(
-				extkey, int(cfd.KCfdNetworkTestnet))
-		}
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println(err)
-			return "", "", -1, "", err
+			return err
 		}
-		pubkey = key
 	}
-	return pubkey, redeemScript, hashType, address, nil
+	fmt.Printf("add sign transaction:\n%s\n", txHex)
+	return nil
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"cfd-cli/txbuilder"
+)
+
+// AnalyzeDescriptorCmd analyze descriptor spending paths.
+type AnalyzeDescriptorCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	descriptor *string
+	nettype    *string
+	childNum   *uint
+}
+
+// NewAnalyzeDescriptorCmd returns a new AnalyzeDescriptorCmd struct.
+func NewAnalyzeDescriptorCmd() *AnalyzeDescriptorCmd {
+	return &AnalyzeDescriptorCmd{}
+}
+
+// Command returns the command name.
+func (cmd *AnalyzeDescriptorCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *AnalyzeDescriptorCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *AnalyzeDescriptorCmd) Init() {
+	cmd.cmd = "analyzedescriptor"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.descriptor = cmd.flagSet.String("descriptor", "",
+		"output descriptor or miniscript. ex) wsh(or_d(pk(A),and_v(v:pk(B),older(144))))")
+	cmd.nettype = cmd.flagSet.String("network", "mainnet", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.childNum = cmd.flagSet.Uint("childnum", uint(0), "derive child number (for ranged keys)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *AnalyzeDescriptorCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *AnalyzeDescriptorCmd) Do(ctx context.Context) error {
+	if *cmd.descriptor == "" {
+		return errors.New("descriptor is required")
+	}
+	networkType, err := txbuilder.ParseNetworkType(*cmd.nettype)
+	if err != nil {
+		return err
+	}
+	descriptor, err := txbuilder.ValidateDescriptorChecksum(*cmd.descriptor)
+	if err != nil {
+		return err
+	}
+
+	resolver := txbuilder.NewDescriptorKeyResolver(networkType,
+		strconv.FormatUint(uint64(*cmd.childNum), 10))
+	analysis, err := txbuilder.AnalyzeDescriptor(descriptor, resolver)
+	if err != nil {
+		return err
+	}
+
+	ms := analysis.Miniscript
+	fmt.Printf("script type      : %s\n", analysis.ScriptType)
+	fmt.Printf("miniscript       : %s\n", ms.String())
+	fmt.Printf("type             : %s\n", ms.Type.String())
+	if analysis.IsResolved {
+		fmt.Printf("script           : %s\n", hex.EncodeToString(analysis.Script))
+	}
+	fmt.Printf("script size      : %d\n", len(analysis.Script))
+	fmt.Printf("ops count        : %d\n", analysis.OpsCount)
+
+	isMixingLock := false
+	for _, path := range analysis.Paths {
+		if path.IsMixingLock {
+			isMixingLock = true
+		}
+	}
+	isWithinLimits := len(analysis.Script) <= txbuilder.MiniscriptMaxScriptSize &&
+		analysis.OpsCount <= txbuilder.MiniscriptMaxOpsCount
+	fmt.Println("properties:")
+	fmt.Printf("  - top level B      : %t\n", ms.Type.Base == 'B')
+	fmt.Printf("  - non-malleable    : %t\n", ms.Type.M)
+	fmt.Printf("  - need signature   : %t\n", ms.Type.S)
+	fmt.Printf("  - timelock mixing  : %t\n", isMixingLock)
+	fmt.Printf("  - within limits    : %t\n", isWithinLimits)
+	fmt.Printf("  - sane             : %t\n", ms.Type.Base == 'B' && ms.Type.M &&
+		ms.Type.S && !isMixingLock && isWithinLimits)
+
+	if analysis.IsSegwit {
+		fmt.Printf("max witness size : %d\n", analysis.MaxWitnessSize)
+	}
+	fmt.Printf("max scriptsig size: %d\n", analysis.MaxScriptSig)
+	fmt.Printf("scriptsigTemplate: %s\n", txbuilder.CreateScriptsigTemplate(
+		analysis.MaxPath.StackSizes, analysis.Script))
+
+	fmt.Println("spending paths:")
+	for i, path := range analysis.Paths {
+		size := analysis.GetPathSize(path)
+		mixing := ""
+		if path.IsMixingLock {
+			mixing = " (unsatisfiable: timelock mixing)"
+		}
+		fmt.Printf("  [%d] %s, size: %d%s\n", i, path.PathSummary(), size, mixing)
+	}
+	return nil
+}
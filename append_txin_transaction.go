@@ -2,12 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
-	cfd "github.com/cryptogarageinc/cfd-go"
+	"cfd-cli/cache"
+	"cfd-cli/regtest"
+	"cfd-cli/txbuilder"
 )
 
+// AppendTxInResult the result of appendtxin.
+type AppendTxInResult struct {
+	Tx string `json:"tx"`
+	// ScriptsigTemplate the template created from the descriptor. (empty if not created)
+	ScriptsigTemplate string   `json:"scriptsigTemplate,omitempty"`
+	Warnings          []string `json:"warnings,omitempty"`
+}
+
 // AppendTxInCmd append tx input.
 type AppendTxInCmd struct {
 	cmd               string
@@ -15,9 +26,13 @@ type AppendTxInCmd struct {
 	txFilePath        *string
 	tx                *string
 	isElements        *bool
+	nettype           *string
 	txid              *string
 	vout              *uint
 	sequence          *uint
+	csvBlocks         *uint
+	csvTime           *uint
+	isRbf             *bool
 	amount            *int64
 	asset             *string
 	assetBlinder      *string
@@ -26,6 +41,7 @@ type AppendTxInCmd struct {
 	amountCommitment  *string
 	descriptor        *string
 	scriptsigTemplate *string
+	nodePath          *string
 }
 
 // NewAppendTxInCmd returns a new AppendTxInCmd struct.
@@ -50,9 +66,13 @@ func (cmd *AppendTxInCmd) Init() {
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.txid = cmd.flagSet.String("txid", "", "append transaction id")
 	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "append transaction output number")
 	cmd.sequence = cmd.flagSet.Uint("sequence", uint(0xffffffff), "sequence number")
+	cmd.csvBlocks = cmd.flagSet.Uint("csvblocks", uint(0), "relative timelock in blocks (BIP68)")
+	cmd.csvTime = cmd.flagSet.Uint("csvtime", uint(0), "relative timelock in seconds (BIP68, rounded up to 512 seconds)")
+	cmd.isRbf = cmd.flagSet.Bool("rbf", false, "signal replaceability (BIP125)")
 	cmd.amount = cmd.flagSet.Int64("amount", int64(0), "utxo amount")
 	cmd.asset = cmd.flagSet.String("asset", "", "utxo asset")
 	cmd.assetBlinder = cmd.flagSet.String("assetblinder",
@@ -67,7 +87,8 @@ func (cmd *AppendTxInCmd) Init() {
 		"amount commitment")
 	cmd.descriptor = cmd.flagSet.String("descriptor", "", "output descriptor")
 	cmd.scriptsigTemplate = cmd.flagSet.String("scriptsigTemplate", "",
-		"scriptsig template (for estimate fee)")
+		"scriptsig template (for estimate fee). If empty, created from the descriptor.")
+	cmd.nodePath = cmd.flagSet.String("node", "", "regtest node state file path (the utxo data is read from the node)")
 }
 
 // GetFlagSet returns the flag set for this command.
@@ -76,128 +97,146 @@ func (cmd *AppendTxInCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *AppendTxInCmd) Do(ctx context.Context) {
+func (cmd *AppendTxInCmd) Do(ctx context.Context) error {
+	result, err := cmd.Result(ctx)
+	if err != nil {
+		return err
+	}
+	appendResult := result.(*AppendTxInResult)
+	if appendResult.ScriptsigTemplate != "" {
+		fmt.Printf("scriptsigTemplate(auto): %s\n", appendResult.ScriptsigTemplate)
+	}
+	fmt.Printf("append txin:\n%s\n", appendResult.Tx)
+
+	for _, warning := range appendResult.Warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	return nil
+}
+
+// Result performs the command action, and returns the appended transaction.
+func (cmd *AppendTxInCmd) Result(ctx context.Context) (interface{}, error) {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return nil, err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return nil, errors.New("tx is required")
 	}
+	data.Hex = tx
 
-	// other input parameter check
-	if len(*cmd.txid) != 64 {
-		fmt.Println("txid size invalid.")
-		return
-	}
-	if len(*cmd.asset) > 0 && len(*cmd.asset) != 64 {
-		fmt.Println("asset size invalid.")
-		return
+	sequence, err := cmd.getSequence()
+	if err != nil {
+		return nil, err
 	}
-	if len(*cmd.assetBlinder) > 0 && len(*cmd.assetBlinder) != 64 {
-		fmt.Println("asset blinder size invalid.")
-		return
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return nil, err
 	}
-	if len(*cmd.amountBlinder) > 0 && len(*cmd.amountBlinder) != 64 {
-		fmt.Println("amount blinder size invalid.")
-		return
+
+	utxo := cache.UtxoData{
+		Txid:              *cmd.txid,
+		Vout:              uint32(*cmd.vout),
+		Amount:            *cmd.amount,
+		Asset:             *cmd.asset,
+		AssetBlinder:      *cmd.assetBlinder,
+		AssetCommitment:   *cmd.assetCommitment,
+		AmountBlinder:     *cmd.amountBlinder,
+		AmountCommitment:  *cmd.amountCommitment,
+		Descriptor:        *cmd.descriptor,
+		ScriptsigTemplate: *cmd.scriptsigTemplate,
 	}
-	if len(*cmd.assetCommitment) > 0 && len(*cmd.assetCommitment) != 66 {
-		fmt.Println("asset commitment size invalid.")
-		return
+	if *cmd.nodePath != "" {
+		var nodeNetworkType int
+		if utxo, nodeNetworkType, err = cmd.getRegtestUtxo(); err != nil {
+			return nil, err
+		}
+		if *cmd.nettype == "" {
+			networkType = nodeNetworkType
+		}
 	}
-	if len(*cmd.amountCommitment) > 0 && len(*cmd.amountCommitment) != 66 {
-		fmt.Println("amount commitment size invalid.")
-		return
+
+	response, err := txbuilder.AppendTxIn(data, txbuilder.AppendTxInRequest{
+		IsElements:  *cmd.isElements,
+		NetworkType: networkType,
+		Sequence:    sequence,
+		Utxo:        utxo,
+	})
+	if err != nil {
+		return nil, err
 	}
-	if len(*cmd.descriptor) > 0 {
-		netType := int(cfd.KCfdNetworkMainnet)
-		if *cmd.isElements {
-			netType = int(cfd.KCfdNetworkLiquidv1)
-		}
-		_, _, err = cfd.CfdGoParseDescriptor(*cmd.descriptor, netType, "")
+	if *cmd.txFilePath != "" {
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println("descriptor is invalid.")
-			fmt.Println(err)
-			return
+			return nil, err
 		}
 	}
+	return &AppendTxInResult{
+		Tx:                data.Hex,
+		ScriptsigTemplate: response.ScriptsigTemplate,
+		Warnings:          response.Warnings,
+	}, nil
+}
 
-	var handle uintptr
-	if *cmd.isElements {
-		handle, err = cfd.CfdGoInitializeConfidentialTransactionByHex(tx)
-	} else {
-		handle, err = cfd.CfdGoInitializeTransactionByHex(tx)
+// getSequence returns the sequence from the sequence/csv/rbf flags.
+func (cmd *AppendTxInCmd) getSequence() (uint32, error) {
+	isSetSequence := false
+	cmd.flagSet.Visit(func(f *flag.Flag) {
+		if f.Name == "sequence" {
+			isSetSequence = true
+		}
+	})
+	if *cmd.csvBlocks > 0 && *cmd.csvTime > 0 {
+		return 0, errors.New("csvblocks and csvtime are exclusive")
 	}
-	if err != nil {
-		fmt.Println(err)
-		return
+	if isSetSequence && (*cmd.csvBlocks > 0 || *cmd.csvTime > 0 || *cmd.isRbf) {
+		return 0, errors.New("sequence and csvblocks/csvtime/rbf are exclusive")
+	}
+	if *cmd.sequence > uint(txbuilder.SequenceFinal) {
+		return 0, errors.New("sequence is out of range")
 	}
-	defer cfd.CfdGoFreeTransactionHandle(handle)
+	switch {
+	case *cmd.csvBlocks > uint(txbuilder.SequenceValueMask):
+		return 0, errors.New("csvblocks is out of range")
+	case *cmd.csvBlocks > 0:
+		return txbuilder.EncodeSequenceBlocks(uint32(*cmd.csvBlocks))
+	case *cmd.csvTime > uint(^uint32(0)):
+		return 0, errors.New("csvtime is out of range")
+	case *cmd.csvTime > 0:
+		return txbuilder.EncodeSequenceTime(uint32(*cmd.csvTime))
+	case *cmd.isRbf:
+		return txbuilder.SequenceMaxRbf, nil
+	}
+	return uint32(*cmd.sequence), nil
+}
 
-	err = cfd.CfdGoAddTxInput(handle, *cmd.txid,
-		uint32(*cmd.vout), uint32(*cmd.sequence))
+// getRegtestUtxo returns the utxo data and the network type of the regtest node.
+// The descriptor and scriptsigTemplate flags override the utxo data.
+func (cmd *AppendTxInCmd) getRegtestUtxo() (cache.UtxoData, int, error) {
+	node, err := regtest.LoadNode(*cmd.nodePath)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return cache.UtxoData{}, 0, err
 	}
-	txHex, err := cfd.CfdGoFinalizeTransaction(handle)
+	if node.IsElements != *cmd.isElements {
+		return cache.UtxoData{}, 0, errors.New("elements mode is unmatch the regtest node")
+	}
+	nodeUtxo, err := node.GetUtxo(*cmd.txid, uint32(*cmd.vout))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return cache.UtxoData{}, 0, err
 	}
-
-	isFind := false
-	_, err = cfd.CfdGoGetConfidentialTxInIndex(tx, *cmd.txid, uint32(*cmd.vout))
-	if err == nil {
-		// already exist. tx not update.
-		isFind = true
-		txHex = tx
+	utxo := nodeUtxo.UtxoData
+	if *cmd.descriptor != "" {
+		utxo.Descriptor = *cmd.descriptor
 	}
-
-	if *cmd.txFilePath != "" {
-		utxo := UtxoData{
-			Txid:              *cmd.txid,
-			Vout:              uint32(*cmd.vout),
-			Amount:            *cmd.amount,
-			Asset:             *cmd.asset,
-			AssetBlinder:      *cmd.assetBlinder,
-			AssetCommitment:   *cmd.assetCommitment,
-			AmountBlinder:     *cmd.amountBlinder,
-			AmountCommitment:  *cmd.amountCommitment,
-			Descriptor:        *cmd.descriptor,
-			ScriptsigTemplate: *cmd.scriptsigTemplate,
-		}
-		data.Hex = txHex
-		isUpdate := false
-		if isFind {
-			// update utxo data
-			for index, utxoData := range data.Utxos {
-				if *cmd.txid == utxoData.Txid && uint32(*cmd.vout) == utxoData.Vout {
-					data.Utxos[index] = utxo
-					isUpdate = true
-					break
-				}
-			}
-		}
-		if !isUpdate {
-			data.Utxos = append(data.Utxos, utxo)
-		}
-
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
+	if *cmd.scriptsigTemplate != "" {
+		utxo.ScriptsigTemplate = *cmd.scriptsigTemplate
 	}
-	fmt.Printf("append txin:\n%s\n", txHex)
+	return utxo, node.GetNetworkType(), nil
 }
@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
-	cfd "github.com/cryptogarageinc/cfd-go"
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
+// AppendTxOutResult the result of appendtxout.
+type AppendTxOutResult struct {
+	Tx string `json:"tx"`
+}
+
 // AppendTxOutCmd append tx output.
 type AppendTxOutCmd struct {
 	cmd             string
@@ -59,82 +66,51 @@ func (cmd *AppendTxOutCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *AppendTxOutCmd) Do(ctx context.Context) {
+func (cmd *AppendTxOutCmd) Do(ctx context.Context) error {
+	result, err := cmd.Result(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("append txout:\n%s\n", result.(*AppendTxOutResult).Tx)
+	return nil
+}
+
+// Result performs the command action, and returns the appended transaction.
+func (cmd *AppendTxOutCmd) Result(ctx context.Context) (interface{}, error) {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return nil, err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
-	}
-
-	// other output parameter check
-	if len(*cmd.asset) > 0 && len(*cmd.asset) != 64 {
-		fmt.Println("asset size invalid.")
-		return
+		return nil, errors.New("tx is required")
 	}
+	data.Hex = tx
 
-	var handle uintptr
-	if *cmd.isElements {
-		handle, err = cfd.CfdGoInitializeConfidentialTransactionByHex(
-			data.Hex)
-	} else {
-		handle, err = cfd.CfdGoInitializeTransactionByHex(data.Hex)
-	}
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer cfd.CfdGoFreeTransactionHandle(handle)
-
-	if *cmd.isElements {
-		if *cmd.isFee {
-			err = cfd.CfdGoAddConfidentialTxOutputFee(handle,
-				*cmd.asset, *cmd.amount)
-		} else if *cmd.isDestroyAmount {
-			err = cfd.CfdGoAddConfidentialTxOutputDestroyAmount(handle,
-				*cmd.asset, *cmd.amount)
-		} else if len(*cmd.lockingScript) > 0 {
-			err = cfd.CfdGoAddConfidentialTxOutputByScript(handle,
-				*cmd.asset, *cmd.amount, *cmd.lockingScript)
-		} else {
-			err = cfd.CfdGoAddConfidentialTxOutput(handle,
-				*cmd.asset, *cmd.amount, *cmd.address)
-		}
-	} else {
-		if len(*cmd.lockingScript) > 0 {
-			err = cfd.CfdGoAddTxOutputByScript(handle,
-				*cmd.amount, *cmd.lockingScript)
-		} else {
-			err = cfd.CfdGoAddTxOutput(handle, *cmd.amount, *cmd.address)
-		}
-	}
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	txHex, err := cfd.CfdGoFinalizeTransaction(handle)
+	err = txbuilder.AppendTxOut(data, txbuilder.AppendTxOutRequest{
+		IsElements:      *cmd.isElements,
+		Amount:          *cmd.amount,
+		Asset:           *cmd.asset,
+		Address:         *cmd.address,
+		LockingScript:   *cmd.lockingScript,
+		IsDestroyAmount: *cmd.isDestroyAmount,
+		IsFee:           *cmd.isFee,
+	})
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
 
 	if *cmd.txFilePath != "" {
-		data.Hex = txHex
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return nil, err
 		}
 	}
-	fmt.Printf("append txout:\n%s\n", txHex)
+	return &AppendTxOutResult{Tx: data.Hex}, nil
 }
@@ -2,12 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
-	"fmt"
-	"strconv"
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/params"
 )
 
 // BlindRawTransactionCmd append tx input.
@@ -23,13 +25,6 @@ type BlindRawTransactionCmd struct {
 	minimumBits       *int64
 }
 
-// BlindInput blinding key input
-type BlindInput struct {
-	txid        string
-	vout        uint32
-	blindingKey string
-}
-
 // NewBlindRawTransactionCmd returns a new BlindRawTransactionCmd struct.
 func NewBlindRawTransactionCmd() *BlindRawTransactionCmd {
 	return &BlindRawTransactionCmd{}
@@ -67,22 +62,20 @@ func (cmd *BlindRawTransactionCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *BlindRawTransactionCmd) Do(ctx context.Context) {
+func (cmd *BlindRawTransactionCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	option := cfd.NewCfdBlindTxOption()
@@ -90,46 +83,33 @@ func (cmd *BlindRawTransactionCmd) Do(ctx context.Context) {
 	option.Exponent = *cmd.exponent
 	option.MinimumBits = *cmd.minimumBits
 
-	inputs := []BlindInput{}
-	keys := strings.Split(*cmd.blindingkeys, "|")
-	for _, keyData := range keys {
-		inputList := strings.Split(keyData, ",")
-		if len(inputList) >= 3 {
-			vout, err := strconv.Atoi(inputList[1])
-			if err != nil {
-				fmt.Println(err)
-				return
-			}
-			input := BlindInput{
-				txid:        inputList[0],
-				vout:        uint32(vout),
-				blindingKey: inputList[2],
-			}
-			inputs = append(inputs, input)
-		}
+	inputs, err := params.ParseBlindingKeys(*cmd.blindingkeys)
+	if err != nil {
+		return err
 	}
 
 	txinList := []cfd.CfdBlindInputData{}
 	txoutList := []cfd.CfdBlindOutputData{}
 
-	addrList := strings.Split(*cmd.addresses, ",")
-	for _, addr := range addrList {
-		if len(addr) > 0 {
-			outData := cfd.CfdBlindOutputData{
-				Index:               -1,
-				ConfidentialAddress: addr,
-				ConfidentialKey:     "",
-			}
-			txoutList = append(txoutList, outData)
+	for _, addr := range params.SplitList(*cmd.addresses, params.ItemSeparator) {
+		if addr == "" {
+			continue
+		}
+		outData := cfd.CfdBlindOutputData{
+			Index:               -1,
+			ConfidentialAddress: addr,
+			ConfidentialKey:     "",
 		}
+		txoutList = append(txoutList, outData)
 	}
 
 	if data.Utxos != nil {
 		for _, utxo := range data.Utxos {
 			blindingKey := ""
 			for _, input := range inputs {
-				if utxo.Txid == input.txid && utxo.Vout == input.vout {
-					blindingKey = input.blindingKey
+				// the txid hex is case insensitive.
+				if strings.EqualFold(utxo.Txid, input.Txid) && utxo.Vout == input.Vout {
+					blindingKey = input.BlindingKey
 					break
 				}
 			}
@@ -148,20 +128,18 @@ func (cmd *BlindRawTransactionCmd) Do(ctx context.Context) {
 	}
 	txHex, err := cfd.CfdGoBlindRawTransaction(tx, txinList, txoutList, &option)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	if txHex == tx {
-		fmt.Println("blinding fail.")
-		return
+		return errors.New("blinding fail.")
 	}
 
 	if *cmd.txFilePath != "" {
 		data.Hex = txHex
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
+	return nil
 }
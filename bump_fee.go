@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+// BumpFeeCmd bump fee of the replaceable transaction. (BIP125)
+type BumpFeeCmd struct {
+	cmd            string
+	flagSet        *flag.FlagSet
+	txFilePath     *string
+	tx             *string
+	isElements     *bool
+	nettype        *string
+	feeRate        *float64
+	incrementalFee *float64
+	changeIndex    *int
+	exponent       *int64
+	minimumBits    *int64
+	utxoFilePath   *string
+	outputFilePath *string
+}
+
+// NewBumpFeeCmd returns a new BumpFeeCmd struct.
+func NewBumpFeeCmd() *BumpFeeCmd {
+	return &BumpFeeCmd{}
+}
+
+// Command returns the command name.
+func (cmd *BumpFeeCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *BumpFeeCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *BumpFeeCmd) Init() {
+	cmd.cmd = "bumpfee"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.feeRate = cmd.flagSet.Float64("feerate", 20.0, "target fee rate (sat/vB)")
+	cmd.incrementalFee = cmd.flagSet.Float64("incrementalfee", 1.0, "incremental relay fee rate (sat/vB)")
+	cmd.changeIndex = cmd.flagSet.Int("change", -1, "change output index")
+	cmd.exponent = cmd.flagSet.Int64("exponent", 0, "blind exponent")
+	cmd.minimumBits = cmd.flagSet.Int64("minimumbits", 52, "blind minimum bits")
+	cmd.utxoFilePath = cmd.flagSet.String("utxofile", "", "additional utxo data file path (utxos of transaction data file)")
+	cmd.outputFilePath = cmd.flagSet.String("output", "", "output transaction data file path")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *BumpFeeCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *BumpFeeCmd) Do(ctx context.Context) error {
+	var err error
+	data := cache.NewTransactionCacheData()
+
+	tx := *cmd.tx
+	if *cmd.tx == "" && *cmd.txFilePath != "" {
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
+		if err != nil {
+			return err
+		}
+		tx = data.Hex
+	}
+	if tx == "" {
+		return errors.New("tx is required")
+	}
+	if *cmd.outputFilePath == "" {
+		return errors.New("output is required")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+
+	addUtxos := []cache.UtxoData{}
+	if *cmd.utxoFilePath != "" {
+		utxoData, err := cache.ReadTransactionCache(*cmd.utxoFilePath)
+		if err != nil {
+			return err
+		}
+		addUtxos = utxoData.Utxos
+	}
+
+	txData, err := txbuilder.DecodeTransaction(tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	result, err := txbuilder.BumpFee(txData, data.Utxos, addUtxos, txbuilder.BumpFeeOption{
+		FeeRate:        *cmd.feeRate,
+		IncrementalFee: *cmd.incrementalFee,
+		ChangeIndex:    *cmd.changeIndex,
+		NetworkType:    networkType,
+		Exponent:       *cmd.exponent,
+		MinimumBits:    *cmd.minimumBits,
+	})
+	if err != nil {
+		return err
+	}
+
+	data.Hex = result.Tx.Hex()
+	data.Utxos = append(data.Utxos, result.AddedUtxos...)
+	_, err = cache.WriteTransactionCache(*cmd.outputFilePath, data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("fee   : %d -> %d (%.3f sat/vB -> %.3f sat/vB)\n", result.OldFee, result.NewFee,
+		float64(result.OldFee)/float64(result.OldVsize), float64(result.NewFee)/float64(result.NewVsize))
+	fmt.Printf("vsize : %d -> %d\n", result.OldVsize, result.NewVsize)
+	for _, utxo := range result.AddedUtxos {
+		fmt.Printf("added input: %s:%d (amount: %d)\n", utxo.Txid, utxo.Vout, utxo.Amount)
+	}
+	for _, index := range result.StrippedInputs {
+		txin := txData.TxIn[index]
+		fmt.Printf("stripped signature: [%d] %s:%d (need re-sign)\n", index, txin.Txid, txin.Vout)
+	}
+	if *cmd.isElements {
+		fmt.Println("need re-blind before signing.")
+	}
+	fmt.Printf("bumpfee tx:\n%s\n", data.Hex)
+	return nil
+}
@@ -0,0 +1,106 @@
+// Package cache reads and writes the transaction data file. (the transaction hex and the utxo data)
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// UtxoData utxo data mapping.
+type UtxoData struct {
+	Txid              string `json:"txid"`
+	Vout              uint32 `json:"vout"`
+	Amount            int64  `json:"amount"`
+	Asset             string `json:"asset"`
+	AssetBlinder      string `json:"assetblinder"`
+	AssetCommitment   string `json:"assetcommitment"`
+	AmountBlinder     string `json:"blinder"`
+	AmountCommitment  string `json:"amountcommitment"`
+	Descriptor        string `json:"descriptor"`
+	ScriptsigTemplate string `json:"scriptsigTemplate"`
+	// PartialSigs the multisig partial signatures. (pubkey: der signature)
+	PartialSigs map[string]string `json:"partialsigs,omitempty"`
+}
+
+// TransactionCacheData transaction cache data mapping.
+type TransactionCacheData struct {
+	Hex   string     `json:"hex"`
+	Utxos []UtxoData `json:"utxos"`
+}
+
+// NewTransactionCacheData returns a new TransactionCacheData struct.
+func NewTransactionCacheData() *TransactionCacheData {
+	return &TransactionCacheData{}
+}
+
+// WriteTransactionCache write jsondata to file. (or memory if the path has MemoryPathPrefix)
+func WriteTransactionCache(path string, cache *TransactionCacheData) (jsonString string, err error) {
+	if cache == nil {
+		return "", errors.New("cahce is null")
+	}
+	jsonData, err := json.Marshal(*cache)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, jsonData, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	indentJSON := buf.String()
+
+	if IsMemoryPath(path) {
+		return indentJSON, writeMemoryCache(path, cache)
+	}
+	err = ioutil.WriteFile(path, []byte(indentJSON), 0600)
+	return indentJSON, err
+}
+
+// ReadTransactionCache read jsondata from file. (or memory if the path has MemoryPathPrefix)
+func ReadTransactionCache(path string) (cache *TransactionCacheData, err error) {
+	if IsMemoryPath(path) {
+		return readMemoryCache(path)
+	}
+	_, err = os.Stat(path)
+	if err != nil {
+		return nil, errors.New("tx data file not found")
+	}
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTransactionCache(bytes)
+}
+
+// ParseTransactionCache parses and validates the transaction data file json.
+func ParseTransactionCache(jsonData []byte) (*TransactionCacheData, error) {
+	var data TransactionCacheData
+	jsonString := strings.TrimSpace(string(jsonData))
+	if err := json.Unmarshal([]byte(jsonString), &data); err != nil {
+		return nil, err
+	}
+	if data.Hex != "" && !isHexString(data.Hex) {
+		return nil, errors.New("tx data hex is invalid")
+	}
+	for index, utxo := range data.Utxos {
+		// the empty partialsigs is omitted on write.
+		if len(utxo.PartialSigs) == 0 {
+			data.Utxos[index].PartialSigs = nil
+		}
+		if utxo.Txid != "" && (len(utxo.Txid) != 64 || !isHexString(utxo.Txid)) {
+			return nil, fmt.Errorf("tx data utxos[%d] txid is invalid", index)
+		}
+		for pubkey, signature := range utxo.PartialSigs {
+			if !isHexString(pubkey) || !isHexString(signature) {
+				return nil, fmt.Errorf("tx data utxos[%d] partialsigs is invalid", index)
+			}
+		}
+	}
+	return &data, nil
+}
@@ -0,0 +1,68 @@
+//go:build go1.18
+// +build go1.18
+
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func FuzzParseTransactionCache(f *testing.F) {
+	f.Add([]byte(`{"hex":"0200000000000000000000","utxos":null}`))
+	f.Add([]byte(`{"hex":"","utxos":[{"txid":"57a15002d066ce52573d674df925c9bc0f1164849420705f2cfad8a68111230f","vout":1,"amount":10000}]}`))
+	f.Add([]byte(`{"hex":"zz"}`))
+	f.Add([]byte(`{"utxos":[{"txid":"00","partialsigs":{"02":"30"}}]}`))
+	f.Add([]byte(`{"utxos":[{"partialsigs":{}}]}`))
+	f.Add([]byte(``))
+	f.Fuzz(func(t *testing.T, jsonData []byte) {
+		data, err := ParseTransactionCache(jsonData)
+		if err != nil {
+			return
+		}
+		// the parsed data is written and parsed again with the same result.
+		written, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := ParseTransactionCache(written)
+		if err != nil {
+			t.Fatalf("round trip failed: %v", err)
+		}
+		if !reflect.DeepEqual(data, again) {
+			t.Fatalf("round trip unmatch: %+v, %+v", data, again)
+		}
+	})
+}
+
+func FuzzParseTransactionInput(f *testing.F) {
+	f.Add("")
+	f.Add("0200000000000000000000")
+	f.Add(`{"hex":"0200000000000000000000","utxos":[]}`)
+	f.Add("cHNidP8BAAoCAAAAAAAAAAAAAA==")
+	f.Add("cHNldP8BAAoCAAAAAAAAAAAAAA==")
+	f.Add("cHNidP8B/w==")
+	f.Add("cHNidP8BAD0CAAAAAQ8jEYGm2PosX3AglIRkEQ+8ySX5TWc9V1LOZtACUKFXAQAAAAD9////ASgjAAAAAAAAAVEAAAAAAAEBChAnAAAAAAAAAVEiAgP5QnFoZbubYmeNmao03kYyJJ0GbZneK1ouVC5UkIRQ1gkwBgIBAQIBAQEAAA==")
+	f.Add("cHNidP8BAD0CAAAAAQ8jEYGm2PosX3AglIRkEQ+8ySX5TWc9V1LOZtACUKFXAQAAAAD9////ASgjAAAAAAAAAVEAAAAAAAEHAQAAAA==")
+	f.Fuzz(func(t *testing.T, input string) {
+		data, format, err := ParseTransactionInput(input)
+		if err != nil {
+			return
+		}
+		if data == nil {
+			t.Fatalf("%s: data is nil", format)
+		}
+		if data.Hex != "" && !isHexString(data.Hex) {
+			t.Fatalf("%s: hex is invalid: %s", format, data.Hex)
+		}
+		// the loaded data is written as the tx data file.
+		written, err := json.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = ParseTransactionCache(written); err != nil {
+			t.Fatalf("%s: written data is invalid: %v", format, err)
+		}
+	})
+}
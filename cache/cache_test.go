@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteTransactionCacheMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfd-cli-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tx.json")
+
+	if _, err = WriteTransactionCache(path, NewTransactionCacheData()); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("file mode %s", info.Mode())
+	}
+	if _, err = ReadTransactionCache(path); err != nil {
+		t.Errorf("read: %v", err)
+	}
+}
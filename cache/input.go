@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"strings"
+)
+
+// InputFormat the format of the transaction input.
+type InputFormat int
+
+const (
+	// InputFormatEmpty empty input.
+	InputFormatEmpty InputFormat = iota
+	// InputFormatHex raw transaction hex.
+	InputFormatHex
+	// InputFormatJSON transaction data file json.
+	InputFormatJSON
+	// InputFormatPsbt PSBT base64. (the unsigned transaction, the utxo amounts and the partial signatures are loaded)
+	InputFormatPsbt
+)
+
+var (
+	psbtMagic = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+	psetMagic = []byte{0x70, 0x73, 0x65, 0x74, 0xff}
+)
+
+// String returns the format name.
+func (format InputFormat) String() string {
+	switch format {
+	case InputFormatHex:
+		return "hex"
+	case InputFormatJSON:
+		return "json"
+	case InputFormatPsbt:
+		return "psbt"
+	default:
+		return "empty"
+	}
+}
+
+// ParseTransactionInput parses the transaction input with auto detection.
+// (raw transaction hex, transaction data file json or PSBT base64)
+func ParseTransactionInput(input string) (*TransactionCacheData, InputFormat, error) {
+	text := strings.TrimSpace(input)
+	data := NewTransactionCacheData()
+	switch {
+	case text == "":
+		return data, InputFormatEmpty, nil
+	case strings.HasPrefix(text, "{"):
+		data, err := ParseTransactionCache([]byte(text))
+		if err != nil {
+			return nil, InputFormatJSON, errors.New("tx data json is invalid. " + err.Error())
+		}
+		return data, InputFormatJSON, nil
+	case isHexString(text):
+		data.Hex = strings.ToLower(text)
+		return data, InputFormatHex, nil
+	}
+
+	psbt, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, InputFormatEmpty, errors.New("input is not tx hex, tx data json or PSBT base64")
+	}
+	if bytes.HasPrefix(psbt, psetMagic) {
+		return nil, InputFormatPsbt, errors.New("PSET is not supported")
+	}
+	if !bytes.HasPrefix(psbt, psbtMagic) {
+		return nil, InputFormatEmpty, errors.New("input is not tx hex, tx data json or PSBT base64")
+	}
+	if data, err = parsePsbt(psbt[len(psbtMagic):]); err != nil {
+		return nil, InputFormatPsbt, err
+	}
+	return data, InputFormatPsbt, nil
+}
+
+// ReadTransactionInput reads the transaction input file with auto detection.
+// The binary PSBT file is also accepted.
+func ReadTransactionInput(path string) (*TransactionCacheData, InputFormat, error) {
+	input, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, InputFormatEmpty, err
+	}
+	if bytes.HasPrefix(input, psbtMagic) {
+		data, err := parsePsbt(input[len(psbtMagic):])
+		return data, InputFormatPsbt, err
+	}
+	return ParseTransactionInput(string(input))
+}
+
+// isHexString returns true if the text is the even length hex.
+func isHexString(text string) bool {
+	if len(text)%2 != 0 {
+		return false
+	}
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MemoryPathPrefix the prefix of the path of the transaction data kept in memory.
+// ReadTransactionCache and WriteTransactionCache read and write the data without the file.
+const MemoryPathPrefix = "mem:"
+
+// memoryCaches the transaction data kept in memory by the path.
+var memoryCaches = struct {
+	sync.Mutex
+	count int
+	data  map[string]*TransactionCacheData
+}{data: map[string]*TransactionCacheData{}}
+
+// NewMemoryCache keeps the data in memory, and returns the path to read and write it.
+// The data is updated in place by WriteTransactionCache until ReleaseMemoryCache is called.
+func NewMemoryCache(data *TransactionCacheData) string {
+	memoryCaches.Lock()
+	defer memoryCaches.Unlock()
+	memoryCaches.count++
+	path := fmt.Sprintf("%s%d", MemoryPathPrefix, memoryCaches.count)
+	memoryCaches.data[path] = data
+	return path
+}
+
+// ReleaseMemoryCache removes the data of the path from memory.
+func ReleaseMemoryCache(path string) {
+	memoryCaches.Lock()
+	defer memoryCaches.Unlock()
+	delete(memoryCaches.data, path)
+}
+
+// IsMemoryPath returns true if the path is the data kept in memory.
+func IsMemoryPath(path string) bool {
+	return strings.HasPrefix(path, MemoryPathPrefix)
+}
+
+// readMemoryCache returns the copy of the data of the path.
+func readMemoryCache(path string) (*TransactionCacheData, error) {
+	memoryCaches.Lock()
+	defer memoryCaches.Unlock()
+	data, ok := memoryCaches.data[path]
+	if !ok {
+		return nil, fmt.Errorf("tx data %s not found", path)
+	}
+	return copyTransactionCache(data)
+}
+
+// writeMemoryCache updates the data of the path by the copy of the data.
+func writeMemoryCache(path string, data *TransactionCacheData) error {
+	memoryCaches.Lock()
+	defer memoryCaches.Unlock()
+	current, ok := memoryCaches.data[path]
+	if !ok {
+		return fmt.Errorf("tx data %s not found", path)
+	}
+	newData, err := copyTransactionCache(data)
+	if err != nil {
+		return err
+	}
+	*current = *newData
+	return nil
+}
+
+// copyTransactionCache returns the deep copy of the data.
+func copyTransactionCache(data *TransactionCacheData) (*TransactionCacheData, error) {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTransactionCache(jsonData)
+}
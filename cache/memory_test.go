@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMemoryCache(t *testing.T) {
+	data := NewTransactionCacheData()
+	path := NewMemoryCache(data)
+	if !IsMemoryPath(path) {
+		t.Fatalf("%s is not memory path", path)
+	}
+
+	readData, err := ReadTransactionCache(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readData.Hex = "0200"
+	readData.Utxos = []UtxoData{{Vout: 1, Amount: 1000}}
+	if data.Hex != "" {
+		t.Errorf("the read data is not copied")
+	}
+	if _, err = WriteTransactionCache(path, readData); err != nil {
+		t.Fatal(err)
+	}
+	readData.Utxos[0].Amount = 0
+	if data.Hex != "0200" || len(data.Utxos) != 1 || data.Utxos[0].Amount != 1000 {
+		t.Errorf("the data is not updated in place: %+v", data)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s is written to the file", path)
+	}
+
+	ReleaseMemoryCache(path)
+	if _, err = ReadTransactionCache(path); err == nil {
+		t.Errorf("the released data is read")
+	}
+	if _, err = WriteTransactionCache(path, readData); err == nil {
+		t.Errorf("the released data is written")
+	}
+}
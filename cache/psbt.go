@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// the PSBT key types. (BIP174)
+const (
+	psbtGlobalUnsignedTx     = 0x00
+	psbtInNonWitnessUtxo     = 0x00
+	psbtInWitnessUtxo        = 0x01
+	psbtInPartialSig         = 0x02
+	psbtInFinalScriptsig     = 0x07
+	psbtInFinalScriptWitness = 0x08
+)
+
+var errPsbtInvalid = errors.New("PSBT is invalid")
+
+// psbtReader reads the PSBT key-value maps.
+type psbtReader struct {
+	data   []byte
+	offset int
+}
+
+// readBytes reads the compact size prefixed bytes.
+func (reader *psbtReader) readBytes() ([]byte, error) {
+	size, length := readCompactSize(reader.data[reader.offset:])
+	if length == 0 || uint64(len(reader.data)-reader.offset-length) < size {
+		return nil, errPsbtInvalid
+	}
+	reader.offset += length
+	value := reader.data[reader.offset : reader.offset+int(size)]
+	reader.offset += int(size)
+	return value, nil
+}
+
+// readMap reads the map until the separator, and calls the handler with each key-value pair.
+func (reader *psbtReader) readMap(handler func(key, value []byte) error) error {
+	for {
+		if reader.offset >= len(reader.data) {
+			return errPsbtInvalid
+		}
+		key, err := reader.readBytes()
+		if err != nil {
+			return err
+		}
+		if len(key) == 0 {
+			// the separator.
+			return nil
+		}
+		value, err := reader.readBytes()
+		if err != nil {
+			return err
+		}
+		if err = handler(key, value); err != nil {
+			return err
+		}
+	}
+}
+
+// parsePsbt parses the PSBT data after the magic bytes.
+// The unsigned transaction, the utxo amounts and the partial signatures are loaded.
+// The finalized input is not supported. (the scriptsig and the witness can not be set to the unsigned tx)
+func parsePsbt(data []byte) (*TransactionCacheData, error) {
+	reader := &psbtReader{data: data}
+	var unsignedTx []byte
+	err := reader.readMap(func(key, value []byte) error {
+		if len(key) == 1 && key[0] == psbtGlobalUnsignedTx {
+			unsignedTx = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unsignedTx == nil {
+		return nil, errors.New("PSBT unsigned tx not found")
+	}
+	tx, err := parseTxOutpoints(unsignedTx, false)
+	if err != nil {
+		return nil, fmt.Errorf("PSBT unsigned tx is invalid. %s", err.Error())
+	}
+
+	result := NewTransactionCacheData()
+	result.Hex = hex.EncodeToString(unsignedTx)
+	for index, outpoint := range tx.inputs {
+		utxo := UtxoData{Txid: outpoint.txid, Vout: outpoint.vout}
+		err = reader.readMap(func(key, value []byte) error {
+			switch key[0] {
+			case psbtInNonWitnessUtxo:
+				prevTx, err := parseTxOutpoints(value, true)
+				if err != nil || int(outpoint.vout) >= len(prevTx.amounts) {
+					return fmt.Errorf("PSBT txin[%d] utxo is invalid", index)
+				}
+				utxo.Amount = prevTx.amounts[outpoint.vout]
+			case psbtInWitnessUtxo:
+				if len(value) < 8 {
+					return fmt.Errorf("PSBT txin[%d] witness utxo is invalid", index)
+				}
+				utxo.Amount = int64(binary.LittleEndian.Uint64(value))
+			case psbtInPartialSig:
+				if len(key) != 34 && len(key) != 66 || len(value) == 0 {
+					return fmt.Errorf("PSBT txin[%d] partial signature is invalid", index)
+				}
+				if utxo.PartialSigs == nil {
+					utxo.PartialSigs = map[string]string{}
+				}
+				utxo.PartialSigs[hex.EncodeToString(key[1:])] = hex.EncodeToString(value)
+			case psbtInFinalScriptsig, psbtInFinalScriptWitness:
+				return fmt.Errorf("PSBT txin[%d] is finalized. finalized input is not supported", index)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if utxo.Amount != 0 || utxo.PartialSigs != nil {
+			result.Utxos = append(result.Utxos, utxo)
+		}
+	}
+	// the output maps are not used.
+	return result, nil
+}
+
+// txOutpoint the outpoint of the input.
+type txOutpoint struct {
+	txid string
+	vout uint32
+}
+
+// txOutpoints the outpoints of the inputs and the amounts of the outputs.
+type txOutpoints struct {
+	inputs  []txOutpoint
+	amounts []int64
+}
+
+// parseTxOutpoints parses the bitcoin transaction, and returns the input outpoints and the output amounts.
+// The witness serialization is allowed for the utxo transaction only. (the unsigned tx has no witness)
+func parseTxOutpoints(data []byte, allowWitness bool) (*txOutpoints, error) {
+	errInvalid := errors.New("tx is invalid")
+	reader := &psbtReader{data: data}
+	skip := func(size int) bool {
+		if len(data)-reader.offset < size {
+			return false
+		}
+		reader.offset += size
+		return true
+	}
+	readCount := func() (int, bool) {
+		count, length := readCompactSize(data[reader.offset:])
+		if length == 0 || count > uint64(len(data)) {
+			return 0, false
+		}
+		reader.offset += length
+		return int(count), true
+	}
+
+	result := &txOutpoints{}
+	if !skip(4) {
+		return nil, errInvalid
+	}
+	isWitness := allowWitness && len(data) > 6 && data[4] == 0 && data[5] == 1
+	if isWitness {
+		reader.offset += 2
+	}
+	inputCount, ok := readCount()
+	if !ok {
+		return nil, errInvalid
+	}
+	for index := 0; index < inputCount; index++ {
+		if len(data)-reader.offset < 36 {
+			return nil, errInvalid
+		}
+		txid := make([]byte, 32)
+		for byteIndex := range txid {
+			txid[byteIndex] = data[reader.offset+31-byteIndex]
+		}
+		vout := binary.LittleEndian.Uint32(data[reader.offset+32:])
+		reader.offset += 36
+		if _, err := reader.readBytes(); err != nil || !skip(4) {
+			return nil, errInvalid
+		}
+		result.inputs = append(result.inputs, txOutpoint{txid: hex.EncodeToString(txid), vout: vout})
+	}
+	outputCount, ok := readCount()
+	if !ok {
+		return nil, errInvalid
+	}
+	for index := 0; index < outputCount; index++ {
+		if len(data)-reader.offset < 8 {
+			return nil, errInvalid
+		}
+		result.amounts = append(result.amounts, int64(binary.LittleEndian.Uint64(data[reader.offset:])))
+		reader.offset += 8
+		if _, err := reader.readBytes(); err != nil {
+			return nil, errInvalid
+		}
+	}
+	if isWitness {
+		for index := 0; index < inputCount; index++ {
+			itemCount, ok := readCount()
+			if !ok {
+				return nil, errInvalid
+			}
+			for itemIndex := 0; itemIndex < itemCount; itemIndex++ {
+				if _, err := reader.readBytes(); err != nil {
+					return nil, errInvalid
+				}
+			}
+		}
+	}
+	if !skip(4) || reader.offset != len(data) {
+		return nil, errInvalid
+	}
+	return result, nil
+}
+
+// readCompactSize returns the value and the byte length of the compact size. (length is 0 if invalid)
+func readCompactSize(data []byte) (uint64, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	length := 1
+	switch data[0] {
+	case 0xfd:
+		length = 3
+	case 0xfe:
+		length = 5
+	case 0xff:
+		length = 9
+	default:
+		return uint64(data[0]), 1
+	}
+	if len(data) < length {
+		return 0, 0
+	}
+	var value uint64
+	for index := length - 1; index >= 1; index-- {
+		value = (value << 8) | uint64(data[index])
+	}
+	return value, length
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"cfd-cli/cache"
+	"cfd-cli/params"
+	"cfd-cli/txbuilder"
+)
+
+// CombineTransactionCmd combine the signatures of transaction data files.
+type CombineTransactionCmd struct {
+	cmd            string
+	flagSet        *flag.FlagSet
+	txFilePaths    *string
+	isElements     *bool
+	nettype        *string
+	outputFilePath *string
+}
+
+// NewCombineTransactionCmd returns a new CombineTransactionCmd struct.
+func NewCombineTransactionCmd() *CombineTransactionCmd {
+	return &CombineTransactionCmd{}
+}
+
+// Command returns the command name.
+func (cmd *CombineTransactionCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *CombineTransactionCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *CombineTransactionCmd) Init() {
+	cmd.cmd = "combinetransaction"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePaths = cmd.flagSet.String("files", "", "transaction data file or PSBT file paths (file1|file2|...)")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.outputFilePath = cmd.flagSet.String("output", "", "output transaction data file path")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *CombineTransactionCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *CombineTransactionCmd) Do(ctx context.Context) error {
+	paths, err := params.ParseNonEmptyList(*cmd.txFilePaths, params.ListSeparator)
+	if err != nil {
+		return fmt.Errorf("files is invalid. %s", err.Error())
+	} else if len(paths) < 2 {
+		return errors.New("files are required at least 2")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	dataList := make([]*cache.TransactionCacheData, len(paths))
+	for index, path := range paths {
+		// the partial signatures of PSBT are merged as the transaction data file.
+		var format cache.InputFormat
+		dataList[index], format, err = cache.ReadTransactionInput(path)
+		if err != nil {
+			return fmt.Errorf("%s is not transaction data file or PSBT. %s", path, err.Error())
+		} else if format == cache.InputFormatEmpty {
+			return fmt.Errorf("%s is empty", path)
+		}
+	}
+
+	combined, results, err := txbuilder.CombineTransactionData(dataList, *cmd.isElements, networkType)
+	if err != nil {
+		return err
+	}
+	if *cmd.outputFilePath != "" {
+		_, err = cache.WriteTransactionCache(*cmd.outputFilePath, combined)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%-5s %-70s %-14s %s\n", "index", "outpoint", "status", "detail")
+	for index, result := range results {
+		fmt.Printf("%-5d %-70s %-14s %s\n", index,
+			fmt.Sprintf("%s,%d", result.Txid, result.Vout), result.Status, result.Detail)
+	}
+	fmt.Printf("tx: %s\n", combined.Hex)
+	return nil
+}
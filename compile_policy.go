@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/txbuilder"
+)
+
+// CompilePolicyCmd compile spending policy to miniscript.
+type CompilePolicyCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	policy     *string
+	nettype    *string
+	scriptType *string
+	childNum   *uint
+}
+
+// NewCompilePolicyCmd returns a new CompilePolicyCmd struct.
+func NewCompilePolicyCmd() *CompilePolicyCmd {
+	return &CompilePolicyCmd{}
+}
+
+// Command returns the command name.
+func (cmd *CompilePolicyCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *CompilePolicyCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *CompilePolicyCmd) Init() {
+	cmd.cmd = "compilepolicy"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.policy = cmd.flagSet.String("policy", "",
+		"spending policy. ex) or(99@pk(A),and(pk(B),older(144)))")
+	cmd.nettype = cmd.flagSet.String("network", "mainnet", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.scriptType = cmd.flagSet.String("type", "wsh", "output script type (wsh, sh-wsh)")
+	cmd.childNum = cmd.flagSet.Uint("childnum", uint(0), "derive child number (for ranged keys)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *CompilePolicyCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *CompilePolicyCmd) Do(ctx context.Context) error {
+	if *cmd.policy == "" {
+		return errors.New("policy is required")
+	}
+	networkType, err := txbuilder.ParseNetworkType(*cmd.nettype)
+	if err != nil {
+		return err
+	}
+
+	miniscript, err := txbuilder.CompilePolicy(*cmd.policy)
+	if err != nil {
+		return err
+	}
+
+	var descriptor string
+	hashType := int(cfd.KCfdP2wsh)
+	switch *cmd.scriptType {
+	case "wsh":
+		descriptor = "wsh(" + miniscript.String() + ")"
+	case "sh-wsh":
+		descriptor = "sh(wsh(" + miniscript.String() + "))"
+		hashType = int(cfd.KCfdP2shP2wsh)
+	default:
+		return fmt.Errorf("type %s is unknown type.", *cmd.scriptType)
+	}
+	checksum, err := txbuilder.GetDescriptorChecksum(descriptor)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("miniscript   : %s\n", miniscript.String())
+	fmt.Printf("type         : %s\n", miniscript.Type.String())
+	fmt.Printf("descriptor   : %s#%s\n", descriptor, checksum)
+
+	resolver := txbuilder.NewDescriptorKeyResolver(networkType,
+		strconv.FormatUint(uint64(*cmd.childNum), 10))
+	script, err := miniscript.CompileScript(resolver)
+	if err != nil {
+		// named keys (ex. A, B) are not resolved.
+		fmt.Printf("witnessScript: (unresolved key) %s\n", err.Error())
+		return nil
+	}
+	address, _, _, err := cfd.CfdGoCreateAddress(hashType, "",
+		hex.EncodeToString(script), networkType)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("witnessScript: %s\n", hex.EncodeToString(script))
+	fmt.Printf("address      : %s\n", address)
+	return nil
+}
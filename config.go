@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// configEnvPrefix the prefix of the environment variables.
+	// CFDCLI_<FLAG> for all commands, CFDCLI_<COMMAND>_<FLAG> for the command.
+	configEnvPrefix = "CFDCLI_"
+	// projectConfigFileName the project config file name. (searched from the current directory to the root)
+	projectConfigFileName = ".cfd-cli.yaml"
+	// userConfigFileName the user config file name in the user config directory.
+	userConfigFileName = "cfd-cli/config.yaml"
+)
+
+// configFile the config file mapping.
+type configFile struct {
+	// Defaults the flag defaults for all commands that have the flag.
+	Defaults map[string]interface{} `yaml:"defaults"`
+	// Commands the flag defaults for the command. (overrides Defaults)
+	Commands map[string]map[string]interface{} `yaml:"commands"`
+}
+
+// configSource the flag defaults loaded from the config file.
+type configSource struct {
+	name     string
+	path     string
+	defaults map[string]string
+	commands map[string]map[string]string
+}
+
+// cliConfig the config sources in priority order. (environment variables are looked up first)
+type cliConfig struct {
+	sources []*configSource
+}
+
+// loadedConfig the config loaded by getConfig.
+var loadedConfig *cliConfig
+
+// ConfigCmd show the config.
+type ConfigCmd struct {
+	cmd     string
+	flagSet *flag.FlagSet
+	command *string
+	showAll *bool
+}
+
+// NewConfigCmd returns a new ConfigCmd struct.
+func NewConfigCmd() *ConfigCmd {
+	return &ConfigCmd{}
+}
+
+// Command returns the command name.
+func (cmd *ConfigCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *ConfigCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *ConfigCmd) Init() {
+	cmd.cmd = "config"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.command = cmd.flagSet.String("command", "", "show the flags of the command only")
+	cmd.showAll = cmd.flagSet.Bool("all", false, "show the flags without the config value")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *ConfigCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *ConfigCmd) Do(ctx context.Context) error {
+	if cmd.flagSet.Arg(0) != "show" {
+		return errors.New("usage: config show [-command <command>] [-all]")
+	}
+	// the flags after "show".
+	if err := cmd.flagSet.Parse(cmd.flagSet.Args()[1:]); err != nil {
+		return err
+	}
+	config, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("config files:")
+	for _, source := range config.sources {
+		status := "not found"
+		if source.defaults != nil {
+			status = "loaded"
+		}
+		fmt.Printf("  %s: %s (%s)\n", source.name, source.path, status)
+	}
+
+	names := getSortedCommandNames()
+	if *cmd.command != "" {
+		if _, ok := commandMap[*cmd.command]; !ok {
+			return newUnknownCommandError(*cmd.command)
+		}
+		names = []string{*cmd.command}
+	}
+	for _, name := range names {
+		lines := []string{}
+		commandMap[name].GetFlagSet().VisitAll(func(f *flag.Flag) {
+			value, source, ok := config.lookup(name, f.Name)
+			if !ok {
+				if !*cmd.showAll {
+					return
+				}
+				value, source = f.DefValue, "flag default"
+			}
+			lines = append(lines, fmt.Sprintf("  -%s = %s (%s)", f.Name, value, source))
+		})
+		if len(lines) > 0 {
+			fmt.Printf("%s:\n%s\n", name, strings.Join(lines, "\n"))
+		}
+	}
+	return nil
+}
+
+// getConfig returns the config. The config files are loaded at the first call.
+func getConfig() (*cliConfig, error) {
+	if loadedConfig != nil {
+		return loadedConfig, nil
+	}
+	config := &cliConfig{}
+	// the higher priority is first.
+	if path := findProjectConfigPath(); path != "" {
+		source, err := loadConfigSource("project config", path)
+		if err != nil {
+			return nil, err
+		}
+		config.sources = append(config.sources, source)
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		source, err := loadConfigSource("user config", filepath.Join(dir, userConfigFileName))
+		if err != nil {
+			return nil, err
+		}
+		config.sources = append(config.sources, source)
+	}
+	loadedConfig = config
+	return config, nil
+}
+
+// findProjectConfigPath returns the project config path, or empty if not found.
+func findProjectConfigPath() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, projectConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// loadConfigSource loads the config file. The defaults is nil if the file does not exist.
+func loadConfigSource(name, path string) (*configSource, error) {
+	source := &configSource{name: name, path: path}
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return source, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var file configFile
+	if err = yaml.UnmarshalStrict(bytes, &file); err != nil {
+		return nil, fmt.Errorf("%s %s is invalid. %s", name, path, err.Error())
+	}
+
+	for flagName := range file.Defaults {
+		if !hasCommandFlag(flagName) {
+			return nil, fmt.Errorf("%s %s is invalid. unknown flag %s of defaults", name, path, flagName)
+		}
+	}
+	source.defaults, err = formatConfigValues(file.Defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s is invalid. %s", name, path, err.Error())
+	}
+	source.commands = map[string]map[string]string{}
+	for command, values := range file.Commands {
+		cmd, ok := commandMap[command]
+		if !ok {
+			return nil, fmt.Errorf("%s %s is invalid. unknown command %s", name, path, command)
+		}
+		for flagName := range values {
+			if cmd.GetFlagSet().Lookup(flagName) == nil {
+				return nil, fmt.Errorf("%s %s is invalid. unknown flag %s of %s", name, path, flagName, command)
+			}
+		}
+		if source.commands[command], err = formatConfigValues(values); err != nil {
+			return nil, fmt.Errorf("%s %s is invalid. %s", name, path, err.Error())
+		}
+	}
+	return source, nil
+}
+
+// hasCommandFlag returns true if any command has the flag.
+func hasCommandFlag(flagName string) bool {
+	for _, cmd := range commandMap {
+		if cmd.GetFlagSet().Lookup(flagName) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// formatConfigValues converts the yaml values to the flag values.
+func formatConfigValues(values map[string]interface{}) (map[string]string, error) {
+	result := map[string]string{}
+	for name, value := range values {
+		switch value.(type) {
+		case string, bool, int, int64, uint64, float64:
+			result[name] = fmt.Sprint(value)
+		case nil:
+			result[name] = ""
+		default:
+			return nil, fmt.Errorf("%s must be scalar value", name)
+		}
+	}
+	return result, nil
+}
+
+// getConfigEnvName returns the environment variable name of the flag.
+func getConfigEnvName(command, flagName string) string {
+	if command == "" {
+		return configEnvPrefix + strings.ToUpper(flagName)
+	}
+	return configEnvPrefix + strings.ToUpper(command) + "_" + strings.ToUpper(flagName)
+}
+
+// lookup returns the config value and the source name of the flag.
+// The priority: CFDCLI_<COMMAND>_<FLAG>, CFDCLI_<FLAG>, project config, user config.
+// (the command section of the file overrides the defaults section)
+func (config *cliConfig) lookup(command, flagName string) (value, source string, ok bool) {
+	for _, envName := range []string{getConfigEnvName(command, flagName), getConfigEnvName("", flagName)} {
+		if value, ok = os.LookupEnv(envName); ok {
+			return value, "env " + envName, true
+		}
+	}
+	for _, configSource := range config.sources {
+		if value, ok = configSource.commands[command][flagName]; ok {
+			return value, fmt.Sprintf("%s commands.%s", configSource.name, command), true
+		}
+		if value, ok = configSource.defaults[flagName]; ok {
+			return value, configSource.name + " defaults", true
+		}
+	}
+	return "", "", false
+}
+
+// applyConfigDefaults sets the config values to the flags not set yet.
+func applyConfigDefaults(command string, flagSet *flag.FlagSet) error {
+	config, err := getConfig()
+	if err != nil {
+		return err
+	}
+	isSet := map[string]bool{}
+	flagSet.Visit(func(f *flag.Flag) {
+		isSet[f.Name] = true
+	})
+	var setErr error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if isSet[f.Name] || setErr != nil {
+			return
+		}
+		value, source, ok := config.lookup(command, f.Name)
+		if !ok {
+			return
+		}
+		if err := flagSet.Set(f.Name, value); err != nil {
+			setErr = fmt.Errorf("%s: -%s %s is invalid. %s", source, f.Name, value, err.Error())
+		}
+	})
+	return setErr
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+// CpfpCmd create child transaction for CPFP (child pays for parent).
+type CpfpCmd struct {
+	cmd            string
+	flagSet        *flag.FlagSet
+	txFilePath     *string
+	tx             *string
+	isElements     *bool
+	nettype        *string
+	vout           *uint
+	descriptor     *string
+	amount         *int64
+	asset          *string
+	address        *string
+	feeRate        *float64
+	parentFee      *int64
+	outputFilePath *string
+}
+
+// NewCpfpCmd returns a new CpfpCmd struct.
+func NewCpfpCmd() *CpfpCmd {
+	return &CpfpCmd{}
+}
+
+// Command returns the command name.
+func (cmd *CpfpCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *CpfpCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *CpfpCmd) Init() {
+	cmd.cmd = "cpfp"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "parent transaction data file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "parent transaction in hex format")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "spending parent output number")
+	cmd.descriptor = cmd.flagSet.String("descriptor", "", "spending parent output descriptor")
+	cmd.amount = cmd.flagSet.Int64("amount", int64(-1), "spending parent output amount (default: explicit amount of the output)")
+	cmd.asset = cmd.flagSet.String("asset", "", "spending parent output asset (default: explicit asset of the output)")
+	cmd.address = cmd.flagSet.String("address", "", "child output address")
+	cmd.feeRate = cmd.flagSet.Float64("feerate", 20.0, "target package fee rate (sat/vB)")
+	cmd.parentFee = cmd.flagSet.Int64("parentfee", int64(-1), "parent transaction fee (default: calculated from the utxo data)")
+	cmd.outputFilePath = cmd.flagSet.String("output", "", "output child transaction data file path")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *CpfpCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *CpfpCmd) Do(ctx context.Context) error {
+	var err error
+	data := cache.NewTransactionCacheData()
+
+	tx := *cmd.tx
+	if *cmd.tx == "" && *cmd.txFilePath != "" {
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
+		if err != nil {
+			return err
+		}
+		tx = data.Hex
+	}
+	if tx == "" {
+		return errors.New("tx is required")
+	}
+	if *cmd.descriptor == "" {
+		return errors.New("descriptor is required")
+	}
+	if *cmd.address == "" {
+		return errors.New("address is required")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+
+	parentTx, err := txbuilder.DecodeTransaction(tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	result, err := txbuilder.CreateCpfpTransaction(parentTx, data.Utxos, txbuilder.CpfpOption{
+		Vout:        uint32(*cmd.vout),
+		Descriptor:  *cmd.descriptor,
+		Amount:      *cmd.amount,
+		Asset:       *cmd.asset,
+		Address:     *cmd.address,
+		FeeRate:     *cmd.feeRate,
+		ParentFee:   *cmd.parentFee,
+		NetworkType: networkType,
+	})
+	if err != nil {
+		return err
+	}
+
+	childHex := result.Tx.Hex()
+	if *cmd.outputFilePath != "" {
+		childData := &cache.TransactionCacheData{Hex: childHex, Utxos: []cache.UtxoData{result.Utxo}}
+		_, err = cache.WriteTransactionCache(*cmd.outputFilePath, childData)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("parent : fee %d, vsize %d (%.3f sat/vB)\n", result.ParentFee,
+		result.ParentVsize, float64(result.ParentFee)/float64(result.ParentVsize))
+	fmt.Printf("child  : fee %d, vsize %d (%.3f sat/vB)\n", result.ChildFee,
+		result.ChildVsize, float64(result.ChildFee)/float64(result.ChildVsize))
+	fmt.Printf("package: fee %d, vsize %d (%.3f sat/vB)\n", result.ParentFee+result.ChildFee,
+		result.PackageVsize, float64(result.ParentFee+result.ChildFee)/float64(result.PackageVsize))
+	fmt.Printf("cpfp tx:\n%s\n", childHex)
+	return nil
+}
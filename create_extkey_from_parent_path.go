@@ -31,16 +31,16 @@ func (cmd *CreatePubkeyFromParentPathCmd) Parse(args []string) {
 func (cmd *CreatePubkeyFromParentPathCmd) Init() {
 	cmd.cmd = "createpubkeyfromparentpath"
 	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
-	cmd.xkey = cmd.flagSet.String("k", "", "")
-	cmd.path = cmd.flagSet.String("p", "", "")
-	cmd.networkType = cmd.flagSet.String("n", "", "")
+	cmd.xkey = cmd.flagSet.String("k", "", "parent extended key (xpub/xprv)")
+	cmd.path = cmd.flagSet.String("p", "", "bip32 path from the parent key. ex) 0/1")
+	cmd.networkType = cmd.flagSet.String("n", "", "network type (mainnet/testnet/regtest/liquid/elementsregtest)")
 }
 
 func (cmd *CreatePubkeyFromParentPathCmd) GetFlagSet() *flag.FlagSet {
 	return cmd.flagSet
 }
 
-func (cmd *CreatePubkeyFromParentPathCmd) Do(ctx context.Context) {
+func (cmd *CreatePubkeyFromParentPathCmd) Do(ctx context.Context) error {
 
 	networkType := cfd.KCfdNetworkMainnet
 	if len(*cmd.networkType) > 0 {
@@ -60,13 +60,14 @@ func (cmd *CreatePubkeyFromParentPathCmd) Do(ctx context.Context) {
 	}
 	childKey, err := cfd.CfdGoCreateExtkeyFromParentPath(*cmd.xkey, *cmd.path, int(networkType), 1)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	pubkey, err := cfd.CfdGoGetPubkeyFromExtkey(childKey, int(networkType))
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	fmt.Printf("xpub: %s\npubkey: %s\n", childKey, pubkey)
+	return nil
 }
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
 // CreateSignatureHashCmd create sighash.
@@ -15,6 +19,7 @@ type CreateSignatureHashCmd struct {
 	txFilePath       *string
 	tx               *string
 	isElements       *bool
+	nettype          *string
 	txid             *string
 	vout             *uint
 	pubkey           *string
@@ -49,6 +54,7 @@ func (cmd *CreateSignatureHashCmd) Init() {
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.txid = cmd.flagSet.String("txid", "", "append transaction id")
 	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "append transaction output number")
 	cmd.pubkey = cmd.flagSet.String("pubkey", "", "pubkey (for pubkey hash)")
@@ -58,8 +64,7 @@ func (cmd *CreateSignatureHashCmd) Init() {
 	cmd.amount = cmd.flagSet.Int64("amount", int64(0), "utxo amount")
 	cmd.amountCommitment = cmd.flagSet.String("amountcommitment", "",
 		"amount commitment (for blind transaction)")
-	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all",
-		"sighashtype (all,single,none)")
+	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
 	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "sighash anyonecanpay flag")
 	cmd.disablecache = cmd.flagSet.Bool("disablecache", false, "unuse cache flag")
 }
@@ -70,45 +75,45 @@ func (cmd *CreateSignatureHashCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *CreateSignatureHashCmd) Do(ctx context.Context) {
+func (cmd *CreateSignatureHashCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	// parameter check
 	if len(*cmd.txid) != 64 {
-		fmt.Println("txid size invalid.")
-		return
+		return errors.New("txid size invalid.")
 	}
 	pubkey := *cmd.pubkey
 	if len(pubkey) > 0 && len(pubkey) != 66 {
-		fmt.Println("asset size invalid.")
-		return
+		return errors.New("asset size invalid.")
 	}
 	amountCommitment := *cmd.amountCommitment
 	if len(amountCommitment) > 0 && len(amountCommitment) != 66 {
-		fmt.Println("amount commitment size invalid.")
-		return
+		return errors.New("amount commitment size invalid.")
+	}
+
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
 	}
 
 	amount := *cmd.amount
 	redeemScript := *cmd.redeemScript
 	addrType := -1
-	tempPubkey, tempScript, tempAddrType, tempAmount, tempCommitment, err := GetDescriptorInfoFromUtxoList(
-		*cmd.txid, uint32(*cmd.vout), data.Utxos)
+	tempPubkey, tempScript, tempAddrType, tempAmount, tempCommitment, err := txbuilder.GetDescriptorInfoFromUtxoList(
+		*cmd.txid, uint32(*cmd.vout), data.Utxos, networkType)
 	if *cmd.disablecache == false && tempAddrType != -1 {
 		if len(*cmd.addrType) == 0 {
 			addrType = tempAddrType
@@ -142,22 +147,16 @@ func (cmd *CreateSignatureHashCmd) Do(ctx context.Context) {
 		case "p2wsh":
 			addrType = int(cfd.KCfdP2wsh)
 		default:
-			fmt.Printf("addresstype [%s] is unknown type.", *cmd.addrType)
-			return
+			return fmt.Errorf("addresstype [%s] is unknown type.", *cmd.addrType)
 		}
 	}
 
-	sigHashType := int(cfd.KCfdSigHashAll)
-	switch *cmd.sigHashType {
-	case "all":
-		sigHashType = int(cfd.KCfdSigHashAll)
-	case "none":
-		sigHashType = int(cfd.KCfdSigHashNone)
-	case "single":
-		sigHashType = int(cfd.KCfdSigHashSingle)
-	default:
-		fmt.Printf("sighashtype %s is unknown type.", *cmd.sigHashType)
-		return
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sigHashType, *cmd.anyoneCanPay)
+	if err == nil {
+		err = sighashType.ValidateEcdsa(*cmd.isElements)
+	}
+	if err != nil {
+		return err
 	}
 
 	var sighash string
@@ -165,16 +164,16 @@ func (cmd *CreateSignatureHashCmd) Do(ctx context.Context) {
 		sighash, err = cfd.CfdGoCreateConfidentialSighash(
 			tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
 			redeemScript, amount, amountCommitment,
-			sigHashType, *cmd.anyoneCanPay)
+			sighashType.GetCfdType(), sighashType.AnyoneCanPay)
 	} else {
-		sighash, err = cfd.CfdGoCreateSighash(int(cfd.KCfdNetworkMainnet),
+		sighash, err = cfd.CfdGoCreateSighash(networkType,
 			tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
-			redeemScript, amount, sigHashType, *cmd.anyoneCanPay)
+			redeemScript, amount, sighashType.GetCfdType(), sighashType.AnyoneCanPay)
 	}
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	fmt.Printf("signature hash: %s\n", sighash)
+	return nil
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+// DebugScriptCmd execute the input script step by step.
+type DebugScriptCmd struct {
+	cmd           string
+	flagSet       *flag.FlagSet
+	txFilePath    *string
+	tx            *string
+	isElements    *bool
+	nettype       *string
+	txid          *string
+	vout          *uint
+	descriptor    *string
+	lockingScript *string
+	amount        *int64
+	commitment    *string
+}
+
+// NewDebugScriptCmd returns a new DebugScriptCmd struct.
+func NewDebugScriptCmd() *DebugScriptCmd {
+	return &DebugScriptCmd{}
+}
+
+// Command returns the command name.
+func (cmd *DebugScriptCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *DebugScriptCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *DebugScriptCmd) Init() {
+	cmd.cmd = "debugscript"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.txid = cmd.flagSet.String("txid", "", "txin's txid")
+	cmd.vout = cmd.flagSet.Uint("vout", 0, "txin's vout")
+	cmd.descriptor = cmd.flagSet.String("descriptor", "", "txin's utxo output descriptor (default: descriptor of the utxo data)")
+	cmd.lockingScript = cmd.flagSet.String("lockingscript", "", "txin's utxo locking script (not exist descriptor)")
+	cmd.amount = cmd.flagSet.Int64("amount", int64(-1), "txin's utxo amount (default: amount of the utxo data)")
+	cmd.commitment = cmd.flagSet.String("commitment", "", "txin's utxo amount commitment (elements mode only)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *DebugScriptCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *DebugScriptCmd) Do(ctx context.Context) error {
+	var err error
+	data := cache.NewTransactionCacheData()
+
+	tx := *cmd.tx
+	if *cmd.tx == "" && *cmd.txFilePath != "" {
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
+		if err != nil {
+			return err
+		}
+		tx = data.Hex
+	}
+	if tx == "" {
+		return errors.New("tx is required")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	txData, err := txbuilder.DecodeTransaction(tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	index, err := txData.GetTxInIndex(*cmd.txid, uint32(*cmd.vout))
+	if err != nil {
+		return err
+	}
+
+	amount := *cmd.amount
+	commitment := *cmd.commitment
+	descriptor := *cmd.descriptor
+	for _, utxo := range data.Utxos {
+		if utxo.Txid == *cmd.txid && utxo.Vout == uint32(*cmd.vout) {
+			if descriptor == "" {
+				descriptor = utxo.Descriptor
+			}
+			if amount < 0 {
+				amount = utxo.Amount
+			}
+			if commitment == "" {
+				commitment = utxo.AmountCommitment
+			}
+		}
+	}
+	if amount < 0 {
+		amount = 0
+	}
+	checker := txbuilder.NewTxScriptChecker(txData, index, amount, commitment, networkType)
+
+	lockingScriptHex := *cmd.lockingScript
+	if lockingScriptHex == "" {
+		if descriptor == "" {
+			return errors.New("descriptor or lockingscript is required")
+		}
+		descList, _, err := cfd.CfdGoParseDescriptor(descriptor, networkType, "")
+		if err != nil {
+			return err
+		}
+		lockingScriptHex = descList[0].LockingScript
+	}
+	lockingScript, err := hex.DecodeString(lockingScriptHex)
+	if err != nil {
+		return errors.New("lockingscript is invalid hex.")
+	}
+
+	txin := txData.TxIn[index]
+	fmt.Printf("outpoint: %s,%d\n", txin.Txid, txin.Vout)
+	interp := txbuilder.NewScriptInterpreter(checker)
+	phase := ""
+	interp.Trace = func(step txbuilder.ScriptStep) {
+		if step.Phase != phase {
+			phase = step.Phase
+			fmt.Printf("== %s ==\n", phase)
+		}
+		status := ""
+		if !step.Executed {
+			status = " (skip)"
+		}
+		fmt.Printf("[%3d] %s%s\n", step.Index, shortenAsm(step.Element.ToAsm()), status)
+		for _, note := range step.Notes {
+			fmt.Printf("      %s\n", note)
+		}
+		fmt.Printf("      stack: %s\n", formatStack(step.Stack))
+		if len(step.AltStack) > 0 {
+			fmt.Printf("      altstack: %s\n", formatStack(step.AltStack))
+		}
+	}
+	if err = interp.VerifyInputScript(txin.ScriptSig, txin.Witness, lockingScript); err != nil {
+		return fmt.Errorf("verify: fail. reason: %s", err.Error())
+	}
+	fmt.Println("verify: success.")
+	return nil
+}
+
+// formatStack returns the stack items in hex. (the last item is the top)
+func formatStack(stack [][]byte) string {
+	items := make([]string, len(stack))
+	for index, item := range stack {
+		if len(item) == 0 {
+			items[index] = "<>"
+		} else {
+			items[index] = shortenAsm(hex.EncodeToString(item))
+		}
+	}
+	return "[" + strings.Join(items, " ") + "]"
+}
+
+// shortenAsm shortens the long push data.
+func shortenAsm(text string) string {
+	if len(text) <= 72 {
+		return text
+	}
+	return fmt.Sprintf("%s...%s(%d bytes)", text[:32], text[len(text)-8:], len(text)/2)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -11,6 +12,8 @@ import (
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
 )
 
 // DecodeRawTransactionCmd decode transaction hex.
@@ -54,45 +57,52 @@ func (cmd *DecodeRawTransactionCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *DecodeRawTransactionCmd) Do(ctx context.Context) {
+func (cmd *DecodeRawTransactionCmd) Do(ctx context.Context) error {
+	result, err := cmd.Result(ctx)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	err = json.Indent(&buf, result.(json.RawMessage), "", "  ")
+	if err != nil {
+		return err
+	}
+	indentJSON := buf.String()
+
+	fmt.Printf("decode transaction:\n%s\n", indentJSON)
+	return nil
+}
+
+// Result performs the command action, and returns the decoded transaction json.
+func (cmd *DecodeRawTransactionCmd) Result(ctx context.Context) (interface{}, error) {
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		_, err := os.Stat(*cmd.txFilePath)
-		if err != nil {
-			fmt.Println("tx data file not found.")
-			return
+		if _, err := os.Stat(*cmd.txFilePath); err != nil && !cache.IsMemoryPath(*cmd.txFilePath) {
+			return nil, errors.New("tx data file not found.")
 		}
-		txcache, err := ReadTransactionCache(*cmd.txFilePath)
+		txcache, err := cache.ReadTransactionCache(*cmd.txFilePath)
 		if err == nil {
 			tx = txcache.Hex
 		} else {
 			bytes, err := ioutil.ReadFile(*cmd.txFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return
+				return nil, err
 			}
 			tx = strings.TrimSpace(string(bytes))
 		}
 	}
 
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return nil, errors.New("tx is required")
 	}
 
 	jsonData, err := cfd.CfdGoDecodeRawTransactionJson(tx, *cmd.nettype, *cmd.isElements)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
-
-	var buf bytes.Buffer
-	err = json.Indent(&buf, []byte(jsonData), "", "  ")
-	if err != nil {
-		fmt.Println(err)
-		return
+	if !json.Valid([]byte(jsonData)) {
+		return nil, errors.New("decoded transaction is invalid json")
 	}
-	indentJSON := buf.String()
-
-	fmt.Printf("decode transaction:\n%s\n", indentJSON)
+	return json.RawMessage(jsonData), nil
 }
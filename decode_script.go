@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/txbuilder"
+)
+
+// DecodeScriptCmd decode script hex.
+type DecodeScriptCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	script     *string
+	tx         *string
+	isElements *bool
+	vin        *int
+	vout       *int
+	nettype    *string
+}
+
+// NewDecodeScriptCmd returns a new DecodeScriptCmd struct.
+func NewDecodeScriptCmd() *DecodeScriptCmd {
+	return &DecodeScriptCmd{}
+}
+
+// Command returns the command name.
+func (cmd *DecodeScriptCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *DecodeScriptCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *DecodeScriptCmd) Init() {
+	cmd.cmd = "decodescript"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.script = cmd.flagSet.String("script", "", "script hex")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format (decode the script of -vin or -vout)")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.vin = cmd.flagSet.Int("vin", -1, "transaction input index (scriptsig and witness)")
+	cmd.vout = cmd.flagSet.Int("vout", -1, "transaction output index (locking script)")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *DecodeScriptCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *DecodeScriptCmd) Do(ctx context.Context) error {
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	if *cmd.tx == "" {
+		if *cmd.script == "" {
+			return errors.New("script is required")
+		}
+		script, err := hex.DecodeString(*cmd.script)
+		if err != nil {
+			return errors.New("script is invalid hex.")
+		}
+		printDecodedScript("", script, networkType)
+		return nil
+	}
+
+	tx, err := txbuilder.DecodeTransaction(*cmd.tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *cmd.vin >= 0:
+		if *cmd.vin >= len(tx.TxIn) {
+			return errors.New("vin is out of range.")
+		}
+		txin := tx.TxIn[*cmd.vin]
+		fmt.Println("scriptSig:")
+		printDecodedScript("  ", txin.ScriptSig, networkType)
+		elements, err := txbuilder.ParseScriptBytes(txin.ScriptSig)
+		if err == nil && len(elements) > 0 && len(txin.Witness) == 0 {
+			if last := elements[len(elements)-1]; last.IsPush && len(last.Data) > 0 {
+				fmt.Println("redeemScript:")
+				printDecodedScript("  ", last.Data, networkType)
+			}
+		}
+		if len(txin.Witness) > 0 {
+			fmt.Println("witness:")
+			for index, item := range txin.Witness {
+				fmt.Printf("  [%d] %s\n", index, hex.EncodeToString(item))
+			}
+			fmt.Println("witnessScript:")
+			printDecodedScript("  ", txin.Witness[len(txin.Witness)-1], networkType)
+		}
+	case *cmd.vout >= 0:
+		if *cmd.vout >= len(tx.TxOut) {
+			return errors.New("vout is out of range.")
+		}
+		fmt.Println("lockingScript:")
+		printDecodedScript("  ", tx.TxOut[*cmd.vout].LockingScript, networkType)
+	default:
+		fmt.Println("vin or vout is required")
+	}
+	return nil
+}
+
+// printDecodedScript prints the script asm, template and addresses.
+func printDecodedScript(indent string, script []byte, networkType int) {
+	elements, err := txbuilder.ParseScriptBytes(script)
+	if err != nil {
+		fmt.Printf("%shex : %s\n", indent, hex.EncodeToString(script))
+		fmt.Printf("%serror: %s\n", indent, err.Error())
+		return
+	}
+	template := txbuilder.ClassifyScript(script)
+	fmt.Printf("%shex : %s\n", indent, hex.EncodeToString(script))
+	fmt.Printf("%sasm : %s\n", indent, txbuilder.ScriptToAsm(elements))
+	fmt.Printf("%stype: %s\n", indent, template.Type)
+	if template.WitnessVersion >= 0 {
+		fmt.Printf("%switness version: %d\n", indent, template.WitnessVersion)
+	}
+	if len(template.Hash) > 0 {
+		fmt.Printf("%shash: %s\n", indent, hex.EncodeToString(template.Hash))
+	}
+	if template.RequiredSigs > 0 {
+		fmt.Printf("%srequired sigs: %d\n", indent, template.RequiredSigs)
+		for index, pubkey := range template.Pubkeys {
+			fmt.Printf("%s  pubkey[%d]: %s\n", indent, index, hex.EncodeToString(pubkey))
+		}
+	}
+	if len(script) == 0 || template.Type == "nulldata" {
+		return
+	}
+	for _, hashType := range []int{int(cfd.KCfdP2sh), int(cfd.KCfdP2wsh), int(cfd.KCfdP2shP2wsh)} {
+		address, _, _, err := cfd.CfdGoCreateAddress(hashType, "", hex.EncodeToString(script), networkType)
+		if err != nil {
+			continue
+		}
+		fmt.Printf("%s%-11s: %s\n", indent, txbuilder.GetHashTypeString(hashType), address)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// dispatchCommand runs the command for the shell, the recipe steps, the server and the pipe.
+// The stdout output of the command is returned with the error of the command.
+func dispatchCommand(ctx context.Context, name string, setFlags func(flagSet *flag.FlagSet) error) (string, error) {
+	cmd, ok := commandMap[name]
+	if !ok {
+		return "", fmt.Errorf("command %s is unknown", name)
+	}
+	if err := prepareCommand(cmd, setFlags); err != nil {
+		return "", err
+	}
+	var doErr error
+	output, err := captureOutput(func() { doErr = cmd.Do(ctx) })
+	if err != nil {
+		return output, err
+	}
+	return output, doErr
+}
+
+// prepareCommand makes the command ready to run.
+// The flags are reset, set by setFlags, and the config defaults are applied to the flags not set.
+func prepareCommand(cmd Command, setFlags func(flagSet *flag.FlagSet) error) error {
+	// reset the flags of the previous call.
+	cmd.Init()
+	if setFlags != nil {
+		if err := setFlags(cmd.GetFlagSet()); err != nil {
+			return err
+		}
+	}
+	return applyConfigDefaults(cmd.Command(), cmd.GetFlagSet())
+}
+
+// captureOutput returns the stdout output of the function.
+// The panic of the function is returned as error.
+func captureOutput(fn func()) (output string, err error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	outputChan := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, reader)
+		reader.Close()
+		outputChan <- buf.String()
+	}()
+
+	stdout := os.Stdout
+	os.Stdout = writer
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+			os.Stdout = stdout
+			writer.Close()
+		}()
+		fn()
+	}()
+	return <-outputChan, err
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/txbuilder"
 )
 
 // EncodeDerFromSignatureCmd encode der format from signature.
@@ -37,7 +40,7 @@ func (cmd *EncodeDerFromSignatureCmd) Init() {
 	cmd.cmd = "encodedersignature"
 	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
 	cmd.sig = cmd.flagSet.String("signature", "", "signature")
-	cmd.sighashType = cmd.flagSet.String("sighashtype", "all", "signature hash type (all, none, single)")
+	cmd.sighashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
 	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "enable signature hash type anyone can pay.")
 }
 
@@ -47,30 +50,24 @@ func (cmd *EncodeDerFromSignatureCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *EncodeDerFromSignatureCmd) Do(ctx context.Context) {
+func (cmd *EncodeDerFromSignatureCmd) Do(ctx context.Context) error {
 	if *cmd.sig == "" {
-		fmt.Println("signture is required")
-		return
+		return errors.New("signture is required")
 	}
 
-	sighashType := int(cfd.KCfdSigHashAll)
-	switch *cmd.sighashType {
-	case "all":
-		sighashType = int(cfd.KCfdSigHashAll)
-	case "none":
-		sighashType = int(cfd.KCfdSigHashNone)
-	case "single":
-		sighashType = int(cfd.KCfdSigHashSingle)
-	default:
-		fmt.Printf("sighashtype %s is unknown type.", *cmd.sighashType)
-		return
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sighashType, *cmd.anyoneCanPay)
+	if err == nil {
+		err = sighashType.ValidateEcdsa(true)
+	}
+	if err != nil {
+		return err
 	}
 
-	derSig, err := cfd.CfdGoEncodeSignatureByDer(*cmd.sig, sighashType, *cmd.anyoneCanPay)
+	derSig, err := cfd.CfdGoEncodeSignatureByDer(*cmd.sig, sighashType.GetCfdType(), sighashType.AnyoneCanPay)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	fmt.Printf("der encoded signature: '%s'\n", derSig)
+	return nil
 }
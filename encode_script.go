@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	"cfd-cli/txbuilder"
+)
+
+// EncodeScriptCmd encode script asm.
+type EncodeScriptCmd struct {
+	cmd     string
+	flagSet *flag.FlagSet
+	asm     *string
+}
+
+// NewEncodeScriptCmd returns a new EncodeScriptCmd struct.
+func NewEncodeScriptCmd() *EncodeScriptCmd {
+	return &EncodeScriptCmd{}
+}
+
+// Command returns the command name.
+func (cmd *EncodeScriptCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *EncodeScriptCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *EncodeScriptCmd) Init() {
+	cmd.cmd = "encodescript"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.asm = cmd.flagSet.String("asm", "", "script asm (ex. \"OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG\")")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *EncodeScriptCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *EncodeScriptCmd) Do(ctx context.Context) error {
+	if *cmd.asm == "" {
+		return errors.New("asm is required")
+	}
+	script, err := txbuilder.ParseScriptAsm(*cmd.asm)
+	if err != nil {
+		return err
+	}
+	elements, err := txbuilder.ParseScriptBytes(script)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("hex : %s\n", hex.EncodeToString(script))
+	fmt.Printf("asm : %s\n", txbuilder.ScriptToAsm(elements))
+	fmt.Printf("type: %s\n", txbuilder.ClassifyScript(script).Type)
+	return nil
+}
@@ -2,10 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
 // EstimateFeeCmd fee estimation.
@@ -15,6 +19,7 @@ type EstimateFeeCmd struct {
 	txFilePath  *string
 	tx          *string
 	isElements  *bool
+	nettype     *string
 	feeRate     *float64
 	asset       *string
 	exponent    *int64
@@ -43,6 +48,7 @@ func (cmd *EstimateFeeCmd) Init() {
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.feeRate = cmd.flagSet.Float64("feerate", 20.0, "fee rate. (default: 20.0)")
 	cmd.asset = cmd.flagSet.String("asset", "", "fee asset")
 	cmd.exponent = cmd.flagSet.Int64("exponent", 0, "blind exponent")
@@ -55,22 +61,20 @@ func (cmd *EstimateFeeCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *EstimateFeeCmd) Do(ctx context.Context) {
+func (cmd *EstimateFeeCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	option := cfd.NewCfdEstimateFeeOption()
@@ -82,9 +86,24 @@ func (cmd *EstimateFeeCmd) Do(ctx context.Context) {
 		option.MinimumBits = *cmd.minimumBits
 	}
 
-	txinList := []cfd.CfdEstimateFeeInput{}
+	netType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
 
+	txinList := []cfd.CfdEstimateFeeInput{}
 	for _, utxo := range data.Utxos {
+		scriptsigTemplate := utxo.ScriptsigTemplate
+		if scriptsigTemplate == "" && utxo.Descriptor != "" {
+			template, _, err := txbuilder.CreateScriptsigTemplateFromDescriptor(utxo.Descriptor, netType)
+			if err != nil {
+				// the fee is estimated from the descriptor only.
+				fmt.Printf("warning: input %s,%d scriptsig template is not created. %s\n", utxo.Txid, utxo.Vout, err.Error())
+			} else {
+				scriptsigTemplate = template
+			}
+		}
+
 		feeInput := cfd.CfdEstimateFeeInput{
 			Utxo: cfd.CfdUtxo{
 				Txid:              utxo.Txid,
@@ -96,7 +115,7 @@ func (cmd *EstimateFeeCmd) Do(ctx context.Context) {
 				IsBlindIssuance:   false,
 				IsPegin:           false,
 				PeginBtcTxSize:    0,
-				ScriptSigTemplate: utxo.ScriptsigTemplate,
+				ScriptSigTemplate: scriptsigTemplate,
 			},
 			IsIssuance:      false,
 			IsBlindIssuance: false,
@@ -107,8 +126,71 @@ func (cmd *EstimateFeeCmd) Do(ctx context.Context) {
 	}
 	total, txFee, inputFee, err := cfd.CfdGoEstimateFee(tx, txinList, option)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	fmt.Printf("fee = %d (tx: %d, input: %d)\n", total, txFee, inputFee)
+
+	txData, err := txbuilder.DecodeTransaction(tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	report, err := txbuilder.CreateFeeReport(txData, data.Utxos, txbuilder.FeeReportOption{
+		Exponent:    *cmd.exponent,
+		MinimumBits: *cmd.minimumBits,
+		NetworkType: netType,
+	})
+	if err != nil {
+		return err
+	}
+	printFeeReport(report, *cmd.isElements, *cmd.feeRate, total)
+	return nil
+}
+
+// printFeeReport prints the fee report.
+func printFeeReport(report *txbuilder.FeeReport, isElements bool, feeRate float64, fee int64) {
+	fmt.Println("size:")
+	fmt.Printf("  base size     : %d\n", report.BaseSize)
+	fmt.Printf("  witness size  : %d\n", report.WitnessSize)
+	fmt.Printf("  weight        : %d\n", report.Weight)
+	fmt.Printf("  vsize         : %d\n", report.Vsize)
+	fmt.Printf("  signed weight : %d (vsize: %d)\n", report.SignedWeight, report.SignedVsize)
+	if isElements {
+		fmt.Printf("  blinded weight: %d (vsize: %d)\n", report.BlindedWeight, report.BlindedVsize)
+		if !report.HasFeeOutput {
+			fmt.Println("  (fee output is not set. signed/blinded size includes the fee output.)")
+		}
+	}
+
+	fmt.Println("inputs:")
+	for index, input := range report.Inputs {
+		status := "unsigned"
+		if input.IsSigned {
+			status = "signed"
+		} else if input.IsEstimated {
+			status = "estimated"
+		}
+		fmt.Printf("  [%d] %s:%d weight: %d (%s)\n", index,
+			input.Txid, input.Vout, input.Weight, status)
+	}
+	fmt.Println("outputs:")
+	for index, output := range report.Outputs {
+		label := ""
+		if output.IsFee {
+			label = " (fee)"
+		}
+		if isElements {
+			fmt.Printf("  [%d] amount: %d, weight: %d, blinded weight: %d%s\n", index,
+				output.Amount, output.Weight, output.BlindedWeight, label)
+		} else {
+			fmt.Printf("  [%d] amount: %d, weight: %d%s\n", index, output.Amount, output.Weight, label)
+		}
+	}
+
+	fmt.Println("feerate:")
+	fmt.Printf("  target        : %.3f sat/vB (fee: %d)\n", feeRate, report.GetRequiredFee(feeRate))
+	fmt.Printf("  effective     : %.3f sat/vB (fee: %d)\n", report.GetFeeRate(fee), fee)
+	if report.HasCurrentFee {
+		fmt.Printf("  current       : %.3f sat/vB (fee: %d, diff: %+d)\n",
+			report.GetFeeRate(report.CurrentFee), report.CurrentFee, report.CurrentFee-fee)
+	}
 }
@@ -37,7 +37,7 @@ func (cmd *GenPrivkeyFromStringsCmd) GetFlagSet() *flag.FlagSet {
 	return cmd.flagSet
 }
 
-func (cmd *GenPrivkeyFromStringsCmd) Do(ctx context.Context) {
+func (cmd *GenPrivkeyFromStringsCmd) Do(ctx context.Context) error {
 	texts := strings.Split(*cmd.text, "|")
 	seed := ""
 	for i, w := range texts {
@@ -48,10 +48,10 @@ func (cmd *GenPrivkeyFromStringsCmd) Do(ctx context.Context) {
 	h := sha256.New()
 	_, err := h.Write([]byte(seed))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	privkey := hex.EncodeToString(h.Sum(nil))
 
 	fmt.Printf("privkey: '%s'\n", privkey)
+	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
@@ -49,32 +50,28 @@ func (cmd *GetCommitmentCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *GetCommitmentCmd) Do(ctx context.Context) {
+func (cmd *GetCommitmentCmd) Do(ctx context.Context) error {
 	if len(*cmd.asset) != 64 {
-		fmt.Println("asset length is invalid")
-		return
+		return errors.New("asset length is invalid")
 	}
 	if len(*cmd.assetBlinder) != 64 {
-		fmt.Println("asset blinder is invalid")
-		return
+		return errors.New("asset blinder is invalid")
 	}
 	if len(*cmd.blinder) != 64 {
-		fmt.Println("blinder is invalid")
-		return
+		return errors.New("blinder is invalid")
 	}
 
 	assetCommitment, err := cfd.CfdGoGetAssetCommitment(
 		*cmd.asset, *cmd.assetBlinder)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	amountCommitment, err := cfd.CfdGoGetAmountCommitment(
 		*cmd.amount, assetCommitment, *cmd.blinder)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	fmt.Printf("assetCommitment : %s\n", assetCommitment)
 	fmt.Printf("amountCommitment: %s\n", amountCommitment)
+	return nil
 }
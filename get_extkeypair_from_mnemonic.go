@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/params"
 )
 
 // GetExtkeypairFromMnemonicCmd deriveds a extended private key from seed.
@@ -52,11 +55,10 @@ func (cmd *GetExtkeypairFromMnemonicCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *GetExtkeypairFromMnemonicCmd) Do(ctx context.Context) {
+func (cmd *GetExtkeypairFromMnemonicCmd) Do(ctx context.Context) error {
 
 	if *cmd.mnemonic == "" {
-		fmt.Println("mnemonic is required")
-		return
+		return errors.New("mnemonic is required")
 	}
 
 	networkType := cfd.KCfdNetworkMainnet
@@ -75,31 +77,30 @@ func (cmd *GetExtkeypairFromMnemonicCmd) Do(ctx context.Context) {
 
 	seed, _, err := cfd.CfdGoConvertMnemonicWordsToSeed(mnemonicList, *cmd.passphrase, *cmd.language)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	baseXpriv, err := cfd.CfdGoCreateExtkeyFromSeed(seed, int(networkType), int(cfd.KCfdExtPrivkey))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
-	paths := strings.Split(*cmd.path, ",")
+	paths, err := params.ParseKeyPaths(*cmd.path)
+	if err != nil {
+		return err
+	}
 	for _, path := range paths {
 		xpriv := baseXpriv
 		if path != "" {
 			xpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(xpriv, path, int(networkType), int(cfd.KCfdExtPrivkey))
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
 
 		xpub, err := cfd.CfdGoCreateExtPubkey(xpriv, int(networkType))
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 
 		if len(path) == 0 {
@@ -107,4 +108,5 @@ func (cmd *GetExtkeypairFromMnemonicCmd) Do(ctx context.Context) {
 		}
 		fmt.Printf("xpriv(%s): '%s',\nxpub (%s): '%s',\n", path, xpriv, path, xpub)
 	}
+	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
@@ -44,11 +45,10 @@ func (cmd *GetExtkeypairFromSeedCmd) GetFlagSet() *flag.FlagSet {
 	return cmd.flagSet
 }
 
-func (cmd *GetExtkeypairFromSeedCmd) Do(ctx context.Context) {
+func (cmd *GetExtkeypairFromSeedCmd) Do(ctx context.Context) error {
 
 	if *cmd.seed == "" {
-		fmt.Println("seed is required")
-		return
+		return errors.New("seed is required")
 	}
 
 	networkType := cfd.KCfdNetworkMainnet
@@ -63,23 +63,21 @@ func (cmd *GetExtkeypairFromSeedCmd) Do(ctx context.Context) {
 
 	xpriv, err := cfd.CfdGoCreateExtkeyFromSeed(*cmd.seed, int(networkType), int(cfd.KCfdExtPrivkey))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	if *cmd.path != "" {
 		xpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(xpriv, *cmd.path, int(networkType), int(cfd.KCfdExtPrivkey))
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
 
 	xpub, err := cfd.CfdGoCreateExtPubkey(xpriv, int(networkType))
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	fmt.Printf("xpriv: '%s'\nxpub: '%s'\n", xpriv, xpub)
+	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
@@ -47,11 +48,10 @@ func (cmd *GetPubkeyFromPrivkeyCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *GetPubkeyFromPrivkeyCmd) Do(ctx context.Context) {
+func (cmd *GetPubkeyFromPrivkeyCmd) Do(ctx context.Context) error {
 
 	if *cmd.privkey == "" && *cmd.wif == "" {
-		fmt.Println("privkey or wif is required")
-		return
+		return errors.New("privkey or wif is required")
 	}
 
 	pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey(*cmd.privkey, *cmd.wif, *cmd.isCompress)
@@ -60,4 +60,5 @@ func (cmd *GetPubkeyFromPrivkeyCmd) Do(ctx context.Context) {
 	}
 
 	fmt.Printf("public key: '%s'\n", pubkey)
+	return nil
 }
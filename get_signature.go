@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"strings"
@@ -52,10 +53,9 @@ func (cmd *GetSignatureCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *GetSignatureCmd) Do(ctx context.Context) {
+func (cmd *GetSignatureCmd) Do(ctx context.Context) error {
 	if *cmd.sighash == "" {
-		fmt.Println("sighash is required")
-		return
+		return errors.New("sighash is required")
 	}
 	sighash := *cmd.sighash
 	if sigList := strings.Split(sighash, ":"); len(sigList) > 1 {
@@ -74,15 +74,13 @@ func (cmd *GetSignatureCmd) Do(ctx context.Context) {
 					*cmd.privkey, int(cfd.KCfdNetworkTestnet))
 			}
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
 	} else {
 		info, err := cfd.CfdGoGetExtkeyInformation(*cmd.extpriv)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		nettype := int(cfd.KCfdNetworkTestnet)
 		if info.Version == "0488ade4" {
@@ -94,23 +92,21 @@ func (cmd *GetSignatureCmd) Do(ctx context.Context) {
 			extpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(
 				extpriv, *cmd.bip32path, nettype, int(cfd.KCfdExtPrivkey))
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
 
 		privkey, _, err = cfd.CfdGoGetPrivkeyFromExtkey(extpriv, nettype)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
 
 	signature, err := cfd.CfdGoCalculateEcSignature(sighash, privkey, "",
 		int(cfd.KCfdNetworkMainnet), *cmd.grindR)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 	fmt.Printf("signature: %s\n", signature)
+	return nil
 }
@@ -2,4 +2,7 @@ module cfd-cli
 
 go 1.13
 
-require github.com/cryptogarageinc/cfd-go v0.3.15
+require (
+	github.com/cryptogarageinc/cfd-go v0.3.15
+	gopkg.in/yaml.v2 v2.2.2
+)
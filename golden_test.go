@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"cfd-cli/cache"
+)
+
+// updateGolden rewrites the golden files by the current output.
+var updateGolden = flag.Bool("update", false, "update the golden files")
+
+// goldenDir the directory of the golden cases. (<name>.yaml and <name>.golden)
+const goldenDir = "testdata/golden"
+
+// goldenCase the golden test case. The steps are executed as the recipe of the run command.
+type goldenCase struct {
+	Description string `yaml:"description"`
+	// Data the initial transaction data file json. (default: empty)
+	Data string `yaml:"data"`
+	// Masks the regexps of the random values replaced with "<masked>". (ex: blinding)
+	Masks  []string `yaml:"masks"`
+	Recipe `yaml:",inline"`
+}
+
+func TestGolden(t *testing.T) {
+	casePaths, err := filepath.Glob(filepath.Join(goldenDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(casePaths) == 0 {
+		t.Fatal("golden cases not found")
+	}
+	// the config files and CFDCLI_* of the environment are not used.
+	loadedConfig = &cliConfig{}
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, configEnvPrefix) {
+			os.Unsetenv(strings.SplitN(env, "=", 2)[0])
+		}
+	}
+
+	for _, casePath := range casePaths {
+		casePath := casePath
+		name := strings.TrimSuffix(filepath.Base(casePath), ".yaml")
+		t.Run(name, func(t *testing.T) {
+			testCase := readGoldenCase(t, casePath)
+			actual := runGoldenCase(t, testCase)
+
+			goldenPath := filepath.Join(goldenDir, name+".golden")
+			if *updateGolden {
+				if err := ioutil.WriteFile(goldenPath, []byte(actual), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := ioutil.ReadFile(goldenPath)
+			if os.IsNotExist(err) {
+				t.Fatalf("%s not found. run \"go test -run TestGolden -update\" with libcfd to create", goldenPath)
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if actual != string(expected) {
+				t.Errorf("%s: output mismatch. (%s)\n--- expected\n%s\n--- actual\n%s",
+					name, testCase.Description, string(expected), actual)
+			}
+		})
+	}
+}
+
+// readGoldenCase reads the golden case file.
+func readGoldenCase(t *testing.T, path string) *goldenCase {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var testCase goldenCase
+	if err = yaml.UnmarshalStrict(bytes, &testCase); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if len(testCase.Steps) == 0 {
+		t.Fatalf("%s: steps is empty", path)
+	}
+	return &testCase
+}
+
+// runGoldenCase runs the steps, and returns the outputs and the transaction data.
+func runGoldenCase(t *testing.T, testCase *goldenCase) string {
+	dir, err := ioutil.TempDir("", "cfd-cli-golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	runner, err := newRecipeRunner(&testCase.Recipe, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if testCase.Data != "" {
+		if runner.data, err = cache.ParseTransactionCache([]byte(testCase.Data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// ${vars.dir} is the temporary directory of the case. (ex. the regtest node file)
+	runner.vars["dir"] = dir
+	output, err := captureOutput(func() {
+		if err := runner.Run(context.Background()); err != nil {
+			fmt.Printf("run: fail. reason: %s\n", err.Error())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := output + "== data\n"
+	if runner.data.Hex != "" || len(runner.data.Utxos) > 0 {
+		jsonData, err := json.MarshalIndent(runner.data, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		result += string(jsonData) + "\n"
+	}
+	result = strings.Replace(result, runner.cachePath, "${file}", -1)
+	result = strings.Replace(result, dir, "${dir}", -1)
+	for _, mask := range testCase.Masks {
+		maskRegexp, err := regexp.Compile(mask)
+		if err != nil {
+			t.Fatalf("mask %s is invalid. %v", mask, err)
+		}
+		result = maskRegexp.ReplaceAllString(result, "<masked>")
+	}
+	return result
+}
@@ -0,0 +1,126 @@
+// Package hashes provides the hash functions used by the bitcoin script.
+package hashes
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+)
+
+// ripemd160 message schedule and rotation tables.
+var (
+	ripemdLeftIndex = [80]uint{
+		0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+		7, 4, 13, 1, 10, 6, 15, 3, 12, 0, 9, 5, 2, 14, 11, 8,
+		3, 10, 14, 4, 9, 15, 8, 1, 2, 7, 0, 6, 13, 11, 5, 12,
+		1, 9, 11, 10, 0, 8, 12, 4, 13, 3, 7, 15, 14, 5, 6, 2,
+		4, 0, 5, 9, 7, 12, 2, 10, 14, 1, 3, 8, 11, 6, 15, 13,
+	}
+	ripemdRightIndex = [80]uint{
+		5, 14, 7, 0, 9, 2, 11, 4, 13, 6, 15, 8, 1, 10, 3, 12,
+		6, 11, 3, 7, 0, 13, 5, 10, 14, 15, 8, 12, 4, 9, 1, 2,
+		15, 5, 1, 3, 7, 14, 6, 9, 11, 8, 12, 2, 10, 0, 4, 13,
+		8, 6, 4, 1, 3, 11, 15, 0, 5, 12, 2, 13, 9, 7, 10, 14,
+		12, 15, 10, 4, 1, 5, 8, 7, 6, 2, 13, 14, 0, 3, 9, 11,
+	}
+	ripemdLeftShift = [80]int{
+		11, 14, 15, 12, 5, 8, 7, 9, 11, 13, 14, 15, 6, 7, 9, 8,
+		7, 6, 8, 13, 11, 9, 7, 15, 7, 12, 15, 9, 11, 7, 13, 12,
+		11, 13, 6, 7, 14, 9, 13, 15, 14, 8, 13, 6, 5, 12, 7, 5,
+		11, 12, 14, 15, 14, 15, 9, 8, 9, 14, 5, 6, 8, 6, 5, 12,
+		9, 15, 5, 11, 6, 8, 13, 12, 5, 12, 13, 14, 11, 8, 5, 6,
+	}
+	ripemdRightShift = [80]int{
+		8, 9, 9, 11, 13, 15, 15, 5, 7, 7, 8, 11, 14, 14, 12, 6,
+		9, 13, 15, 7, 12, 8, 9, 11, 7, 7, 12, 7, 6, 15, 13, 11,
+		9, 7, 15, 11, 8, 6, 6, 14, 12, 13, 5, 14, 13, 13, 7, 5,
+		15, 5, 8, 11, 14, 14, 6, 14, 6, 9, 12, 9, 12, 5, 15, 8,
+		8, 5, 12, 9, 12, 5, 14, 6, 8, 13, 6, 5, 15, 13, 11, 11,
+	}
+	ripemdLeftConst  = [5]uint32{0x00000000, 0x5a827999, 0x6ed9eba1, 0x8f1bbcdc, 0xa953fd4e}
+	ripemdRightConst = [5]uint32{0x50a28be6, 0x5c4dd124, 0x6d703ef3, 0x7a6d76e9, 0x00000000}
+)
+
+// ripemdFunc is the nonlinear function of the ripemd160 round.
+func ripemdFunc(round int, x, y, z uint32) uint32 {
+	switch round {
+	case 0:
+		return x ^ y ^ z
+	case 1:
+		return (x & y) | (^x & z)
+	case 2:
+		return (x | ^y) ^ z
+	case 3:
+		return (x & z) | (y & ^z)
+	default:
+		return x ^ (y | ^z)
+	}
+}
+
+// Ripemd160 returns the RIPEMD-160 hash of the data.
+func Ripemd160(data []byte) []byte {
+	state := [5]uint32{0x67452301, 0xefcdab89, 0x98badcfe, 0x10325476, 0xc3d2e1f0}
+
+	message := make([]byte, len(data), len(data)+72)
+	copy(message, data)
+	message = append(message, 0x80)
+	for len(message)%64 != 56 {
+		message = append(message, 0)
+	}
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data))*8)
+	message = append(message, length[:]...)
+
+	var words [16]uint32
+	for offset := 0; offset < len(message); offset += 64 {
+		for index := range words {
+			words[index] = binary.LittleEndian.Uint32(message[offset+index*4:])
+		}
+		al, bl, cl, dl, el := state[0], state[1], state[2], state[3], state[4]
+		ar, br, cr, dr, er := al, bl, cl, dl, el
+		for step := 0; step < 80; step++ {
+			round := step / 16
+			temp := bits.RotateLeft32(al+ripemdFunc(round, bl, cl, dl)+
+				words[ripemdLeftIndex[step]]+ripemdLeftConst[round], ripemdLeftShift[step]) + el
+			al, el, dl, cl, bl = el, dl, bits.RotateLeft32(cl, 10), bl, temp
+			temp = bits.RotateLeft32(ar+ripemdFunc(4-round, br, cr, dr)+
+				words[ripemdRightIndex[step]]+ripemdRightConst[round], ripemdRightShift[step]) + er
+			ar, er, dr, cr, br = er, dr, bits.RotateLeft32(cr, 10), br, temp
+		}
+		temp := state[1] + cl + dr
+		state[1] = state[2] + dl + er
+		state[2] = state[3] + el + ar
+		state[3] = state[4] + al + br
+		state[4] = state[0] + bl + cr
+		state[0] = temp
+	}
+
+	result := make([]byte, 20)
+	for index, value := range state {
+		binary.LittleEndian.PutUint32(result[index*4:], value)
+	}
+	return result
+}
+
+// Sha1 returns the SHA-1 hash of the data.
+func Sha1(data []byte) []byte {
+	hash := sha1.Sum(data)
+	return hash[:]
+}
+
+// Sha256 returns the SHA-256 hash of the data.
+func Sha256(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+// Hash160 returns the RIPEMD-160 hash of the SHA-256 hash. (OP_HASH160)
+func Hash160(data []byte) []byte {
+	return Ripemd160(Sha256(data))
+}
+
+// Hash256 returns the double SHA-256 hash. (OP_HASH256)
+func Hash256(data []byte) []byte {
+	return Sha256(Sha256(data))
+}
@@ -0,0 +1,720 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// commandCategories the categories of the commands in display order.
+var commandCategories = []string{
+	"key",
+	"transaction",
+	"elements",
+	"sign",
+	"verify",
+	"script",
+	"tool",
+}
+
+// commandHelp the help document of the command.
+type commandHelp struct {
+	Category    string
+	Description string
+	Examples    []string
+}
+
+// commandHelps the help documents of the commands.
+// The flags are documented by the flag set of the command.
+var commandHelps = map[string]commandHelp{
+	"getpubkeyfromprivkey": {
+		Category:    "key",
+		Description: "get the pubkey from the privkey hex or wif.",
+		Examples: []string{
+			"getpubkeyfromprivkey -privkey <privkey> -comp",
+			"getpubkeyfromprivkey -wif <wif>",
+		},
+	},
+	"genprivkeyfromstrings": {
+		Category:    "key",
+		Description: "generate the privkey from the sha256 hash of the strings.",
+		Examples: []string{
+			"genprivkeyfromstrings -text \"<message1|message2|message3>\"",
+		},
+	},
+	"getextkeypairfromseed": {
+		Category:    "key",
+		Description: "get the extended key pair from the seed.",
+		Examples: []string{
+			"getextkeypairfromseed -seed <seed> -network <network> -path <bip32path>",
+		},
+	},
+	"getextkeypairfrommnemonic": {
+		Category:    "key",
+		Description: "get the extended key pair from the bip39 mnemonic.",
+		Examples: []string{
+			"getextkeypairfrommnemonic -mnemonic <mnemonic> -network <network> -path <bip32paths>",
+		},
+	},
+	"createpubkeyfromparentpath": {
+		Category:    "key",
+		Description: "derive the child extended pubkey and the pubkey from the parent extended key.",
+		Examples: []string{
+			"createpubkeyfromparentpath -k <xpub> -p <bip32path> -n testnet",
+		},
+	},
+	"initializetransaction": {
+		Category:    "transaction",
+		Description: "create the empty transaction.",
+		Examples: []string{
+			"initializetransaction -file <filename> -version <version> -locktime <locktime>",
+			"initializetransaction -file <filename> -locktimeheight <blockHeight>",
+		},
+	},
+	"appendtxin": {
+		Category:    "transaction",
+		Description: "add the input to the transaction, and save the utxo data.",
+		Examples: []string{
+			"appendtxin -file <filename> -txid <txid> -vout <vout> -amount <amount> -descriptor <descriptor>",
+			"appendtxin -file <filename> -txid <txid> -vout <vout> -csvblocks <blocks> -descriptor <descriptor>",
+			"appendtxin -file <filename> -txid <txid> -vout <vout> -node <nodefile>",
+		},
+	},
+	"appendtxout": {
+		Category:    "transaction",
+		Description: "add the output to the transaction.",
+		Examples: []string{
+			"appendtxout -file <filename> -address <address> -amount <amount>",
+			"appendtxout -file <filename> -elements -fee -asset <asset> -amount <amount>",
+		},
+	},
+	"decoderawtransaction": {
+		Category:    "transaction",
+		Description: "decode the transaction.",
+		Examples: []string{
+			"decoderawtransaction -tx <tx> -network <network>",
+			"decoderawtransaction -file <filename> -network <network> -elements",
+		},
+	},
+	"estimatefee": {
+		Category:    "transaction",
+		Description: "estimate the fee of the transaction with the utxo data.",
+		Examples: []string{
+			"estimatefee -file <filename> -feerate <feerate>",
+		},
+	},
+	"bumpfee": {
+		Category:    "transaction",
+		Description: "raise the fee of the transaction by reducing the change output. (RBF)",
+		Examples: []string{
+			"bumpfee -file <filename> -feerate <feerate> -change <index> -output <filename>",
+		},
+	},
+	"cpfp": {
+		Category:    "transaction",
+		Description: "create the child transaction that pays for the parent transaction.",
+		Examples: []string{
+			"cpfp -file <filename> -vout <vout> -descriptor <descriptor> -address <address> -feerate <feerate> -output <filename>",
+		},
+	},
+	"validatetimelock": {
+		Category:    "transaction",
+		Description: "check the locktime and the sequences against the utxo descriptors.",
+		Examples: []string{
+			"validatetimelock -file <filename>",
+		},
+	},
+	"combinetransaction": {
+		Category:    "transaction",
+		Description: "merge the signatures of the co-signer's transaction data files.",
+		Examples: []string{
+			"combinetransaction -files \"<filename1>|<filename2>\" -output <filename>",
+		},
+	},
+	"setrawreissueasset": {
+		Category:    "elements",
+		Description: "set the asset reissuance to the input.",
+		Examples: []string{
+			"setrawreissueasset -file <filename> -txid <txid> -vout <vout> -amount <amount> -entropy <entropy> -address <address>",
+		},
+	},
+	"blindrawtransaction": {
+		Category:    "elements",
+		Description: "blind the transaction with the utxo data.",
+		Examples: []string{
+			"blindrawtransaction -file <filename> -blindingkeys <blindingkeys>",
+		},
+	},
+	"getcommitment": {
+		Category:    "elements",
+		Description: "get the asset and amount commitment.",
+		Examples: []string{
+			"getcommitment -asset <asset> -amount <amount> -assetblinder <assetblinder> -blinder <blinder>",
+		},
+	},
+	"createsignaturehash": {
+		Category:    "sign",
+		Description: "create the signature hash of the input.",
+		Examples: []string{
+			"createsignaturehash -file <filename> -txid <txid> -vout <vout> -pubkey <pubkey> -addresstype p2wpkh -amount <amount>",
+		},
+	},
+	"getsignature": {
+		Category:    "sign",
+		Description: "sign the signature hash with the privkey.",
+		Examples: []string{
+			"getsignature -sighash <sighash> -privkey <privkey>",
+		},
+	},
+	"encodedersignature": {
+		Category:    "sign",
+		Description: "encode the signature to the DER format with the sighash type.",
+		Examples: []string{
+			"encodedersignature -signature <signature> -sighashtype \"all|anyonecanpay\"",
+		},
+	},
+	"addsigntransaction": {
+		Category:    "sign",
+		Description: "add the signature to the input.",
+		Examples: []string{
+			"addsigntransaction -file <filename> -txid <txid> -vout <vout> -signature <signature> -pubkey <pubkey> -addresstype p2wpkh",
+			"addsigntransaction -file <filename> -elements -txid <txid> -vout <vout> -signature \"sig:<compactSignature>,data:<preimage>\" -script <script> -addresstype p2wsh",
+		},
+	},
+	"signwithprivkey": {
+		Category:    "sign",
+		Description: "sign the input with the privkey.",
+		Examples: []string{
+			"signwithprivkey -file <filename> -txid <txid> -vout <vout> -privkey <privkey> -addresstype p2wpkh -amount <amount>",
+		},
+	},
+	"signtransaction": {
+		Category:    "sign",
+		Description: "sign all inputs matched with the utxo descriptors.",
+		Examples: []string{
+			"signtransaction -file <filename> -keys \"<privkey1>|<privkey2>\"",
+			"signtransaction -file <filename> -keys \"keystore:<id>\" -keystore <keystore.yaml>",
+		},
+	},
+	"verifysigntransaction": {
+		Category:    "verify",
+		Description: "verify the signed inputs of the transaction.",
+		Examples: []string{
+			"verifysigntransaction -file <filename> -all",
+			"verifysigntransaction -file <filename> -txid <txid> -vout <vout> -descriptor <descriptor> -amount <amount>",
+		},
+	},
+	"verifysignature": {
+		Category:    "verify",
+		Description: "verify the signature of the input.",
+		Examples: []string{
+			"verifysignature -file <filename> -txid <txid> -vout <vout> -signature <signature> -descriptor <descriptor> -amount <amount>",
+		},
+	},
+	"parsedescriptor": {
+		Category:    "script",
+		Description: "parse the output descriptor.",
+		Examples: []string{
+			"parsedescriptor -descriptor <descriptor> -network <network>",
+		},
+	},
+	"analyzedescriptor": {
+		Category:    "script",
+		Description: "analyze the spending paths of the output descriptor.",
+		Examples: []string{
+			"analyzedescriptor -descriptor <descriptor>",
+		},
+	},
+	"compilepolicy": {
+		Category:    "script",
+		Description: "compile the policy to the miniscript and the descriptor.",
+		Examples: []string{
+			"compilepolicy -policy \"or(pk(<pubkey1>),and(pk(<pubkey2>),older(144)))\"",
+		},
+	},
+	"decodescript": {
+		Category:    "script",
+		Description: "decode the script, or the script of the transaction input/output.",
+		Examples: []string{
+			"decodescript -script <script>",
+			"decodescript -tx <tx> -vin <index>",
+		},
+	},
+	"encodescript": {
+		Category:    "script",
+		Description: "encode the script asm to hex.",
+		Examples: []string{
+			"encodescript -asm \"OP_DUP OP_HASH160 <pubkeyhash> OP_EQUALVERIFY OP_CHECKSIG\"",
+		},
+	},
+	"debugscript": {
+		Category:    "script",
+		Description: "execute the scriptSig and the witness against the utxo locking script step by step.",
+		Examples: []string{
+			"debugscript -file <filename> -txid <txid> -vout <vout>",
+		},
+	},
+	"createhtlc": {
+		Category:    "script",
+		Description: "create the HTLC script and address.",
+		Examples: []string{
+			"createhtlc -hash <hash> -receiverpubkey <pubkey> -refundpubkey <pubkey> -timeout 144",
+		},
+	},
+	"spendhtlc": {
+		Category:    "script",
+		Description: "create the HTLC claim or refund transaction.",
+		Examples: []string{
+			"spendhtlc -script <script> -txid <txid> -vout <vout> -amount <amount> -address <address> -privkey <privkey> -preimage <preimage>",
+			"spendhtlc -script <script> -txid <txid> -vout <vout> -amount <amount> -address <address> -privkey <privkey> -refund",
+		},
+	},
+	"run": {
+		Category:    "tool",
+		Description: "execute the recipe steps in one process.",
+		Examples: []string{
+			"run -recipe <recipe.yaml> -vars \"txid=<txid>|privkey=<privkey>\"",
+		},
+	},
+	"serve": {
+		Category:    "tool",
+		Description: "serve the commands as JSON-RPC 2.0 methods.",
+		Examples: []string{
+			"serve -socket <path>",
+			"serve -listen 127.0.0.1:8080 -token <token>",
+		},
+	},
+	"shell": {
+		Category:    "tool",
+		Description: "interactive shell with the current transaction data.",
+		Examples: []string{
+			"shell -file <filename> -elements -network liquidregtest",
+		},
+	},
+	"help": {
+		Category:    "tool",
+		Description: "show the commands, or the help of the command.",
+		Examples: []string{
+			"help",
+			"help <command>",
+		},
+	},
+	"config": {
+		Category:    "tool",
+		Description: "show the flag defaults of the config files and the CFDCLI_* environment variables.",
+		Examples: []string{
+			"config show",
+			"config show -command blindrawtransaction -all",
+		},
+	},
+	"regtestinit": {
+		Category:    "tool",
+		Description: "initialize the offline regtest node simulator. (utxo set and mempool)",
+		Examples: []string{
+			"regtestinit -node <nodefile>",
+			"regtestinit -node <nodefile> -elements -force",
+		},
+	},
+	"regtestfund": {
+		Category:    "tool",
+		Description: "create the utxo of the regtest node, and mine the block.",
+		Examples: []string{
+			"regtestfund -node <nodefile> -descriptor <descriptor> -amount <amount>",
+			"regtestfund -node <nodefile> -address <address> -amount <amount> -asset <asset>",
+		},
+	},
+	"regtestsend": {
+		Category:    "tool",
+		Description: "validate the transaction (utxos, timelocks, signatures and elements commitments), and add it to the mempool of the regtest node.",
+		Examples: []string{
+			"regtestsend -node <nodefile> -file <filename>",
+			"regtestsend -node <nodefile> -file <filename> -blindingkeys \"<vout>,<blindingKey>|<vout>,<blindingKey>\"",
+		},
+	},
+	"regtestmine": {
+		Category:    "tool",
+		Description: "mine the blocks of the regtest node. the mempool transactions are confirmed.",
+		Examples: []string{
+			"regtestmine -node <nodefile> -blocks 10",
+		},
+	},
+	"regtestlistunspent": {
+		Category:    "tool",
+		Description: "list the utxos of the regtest node.",
+		Examples: []string{
+			"regtestlistunspent -node <nodefile>",
+			"regtestlistunspent -node <nodefile> -descriptor <descriptor>",
+		},
+	},
+	"completion": {
+		Category:    "tool",
+		Description: "print the shell completion script. (bash/zsh/fish)",
+		Examples: []string{
+			"completion -shell bash > /etc/bash_completion.d/cfd-cli",
+			"completion -shell zsh > \"${fpath[1]}/_cfd-cli\"",
+			"completion -shell fish > ~/.config/fish/completions/cfd-cli.fish",
+		},
+	},
+}
+
+// HelpCmd show the help of the commands.
+type HelpCmd struct {
+	cmd     string
+	flagSet *flag.FlagSet
+}
+
+// NewHelpCmd returns a new HelpCmd struct.
+func NewHelpCmd() *HelpCmd {
+	return &HelpCmd{}
+}
+
+// Command returns the command name.
+func (cmd *HelpCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *HelpCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *HelpCmd) Init() {
+	cmd.cmd = "help"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *HelpCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *HelpCmd) Do(ctx context.Context) error {
+	if cmd.flagSet.NArg() == 0 {
+		printCommandList(os.Stdout)
+		return nil
+	}
+	name := cmd.flagSet.Arg(0)
+	target, ok := commandMap[name]
+	if !ok {
+		return newUnknownCommandError(name)
+	}
+	printCommandHelp(os.Stdout, target)
+	return nil
+}
+
+// CompletionCmd print the shell completion script.
+type CompletionCmd struct {
+	cmd     string
+	flagSet *flag.FlagSet
+	shell   *string
+	name    *string
+}
+
+// NewCompletionCmd returns a new CompletionCmd struct.
+func NewCompletionCmd() *CompletionCmd {
+	return &CompletionCmd{}
+}
+
+// Command returns the command name.
+func (cmd *CompletionCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *CompletionCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *CompletionCmd) Init() {
+	cmd.cmd = "completion"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.shell = cmd.flagSet.String("shell", "bash", "shell type (bash/zsh/fish)")
+	cmd.name = cmd.flagSet.String("name", "cfd-cli", "program name to complete")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *CompletionCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *CompletionCmd) Do(ctx context.Context) error {
+	switch *cmd.shell {
+	case "bash":
+		printBashCompletion(os.Stdout, *cmd.name)
+	case "zsh":
+		printZshCompletion(os.Stdout, *cmd.name)
+	case "fish":
+		printFishCompletion(os.Stdout, *cmd.name)
+	default:
+		return errors.New("shell must be bash, zsh or fish")
+	}
+	return nil
+}
+
+// getCommandHelp returns the help document of the command.
+func getCommandHelp(name string) commandHelp {
+	help, ok := commandHelps[name]
+	if !ok {
+		help.Category = "other"
+	}
+	return help
+}
+
+// getSortedCommandNames returns the sorted names of commandMap.
+func getSortedCommandNames() []string {
+	names := make([]string, 0, len(commandMap))
+	for name := range commandMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// printCommandList prints the commands grouped by the category.
+func printCommandList(w io.Writer) {
+	fmt.Fprintln(w, "Usage: cfd-cli <command> [flags]")
+	categories := map[string][]string{}
+	for _, name := range getSortedCommandNames() {
+		category := getCommandHelp(name).Category
+		categories[category] = append(categories[category], name)
+	}
+	for _, category := range append(commandCategories, "other") {
+		names := categories[category]
+		if len(names) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s commands:\n", category)
+		for _, name := range names {
+			fmt.Fprintf(w, "  %-28s %s\n", name, getCommandHelp(name).Description)
+		}
+	}
+	fmt.Fprintln(w, "\nRun \"cfd-cli help <command>\" for the flags and the examples of the command.")
+}
+
+// printCommandHelp prints the description, the flags and the examples of the command.
+func printCommandHelp(w io.Writer, cmd Command) {
+	help := getCommandHelp(cmd.Command())
+	fmt.Fprintf(w, "Usage: cfd-cli %s [flags]\n", cmd.Command())
+	if help.Description != "" {
+		fmt.Fprintf(w, "\n%s\n", help.Description)
+	}
+	hasFlag := false
+	cmd.GetFlagSet().VisitAll(func(f *flag.Flag) {
+		if !hasFlag {
+			fmt.Fprintln(w, "\nFlags:")
+			hasFlag = true
+		}
+		name, usage := flag.UnquoteUsage(f)
+		text := "  -" + f.Name
+		if name != "" {
+			text += " " + name
+		}
+		text += "\n      " + strings.Replace(usage, "\n", "\n      ", -1)
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			text += fmt.Sprintf(" (default: %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, text)
+	})
+	if len(help.Examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, example := range help.Examples {
+			fmt.Fprintf(w, "  cfd-cli %s\n", example)
+		}
+	}
+}
+
+// newUnknownCommandError returns the unknown command error with the similar commands.
+func newUnknownCommandError(name string) error {
+	var buf strings.Builder
+	printUnknownCommand(&buf, name)
+	return errors.New(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+// printUnknownCommand prints the unknown command error with the similar commands.
+func printUnknownCommand(w io.Writer, name string) {
+	fmt.Fprintf(w, "Unknown command %s\n", name)
+	if suggestions := suggestCommands(name); len(suggestions) > 0 {
+		fmt.Fprintf(w, "Did you mean this?\n")
+		for _, suggestion := range suggestions {
+			fmt.Fprintf(w, "  %s\n", suggestion)
+		}
+	}
+	fmt.Fprintln(w, "Run \"cfd-cli help\" for the commands.")
+}
+
+// suggestCommands returns the commands similar to the name.
+// (prefix match, or edit distance within a third of the name length)
+func suggestCommands(name string) []string {
+	name = strings.ToLower(name)
+	maxDistance := len(name) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	suggestions := []string{}
+	for _, cmdName := range getSortedCommandNames() {
+		if (len(name) >= 3 && strings.HasPrefix(cmdName, name)) ||
+			getEditDistance(name, cmdName) <= maxDistance {
+			suggestions = append(suggestions, cmdName)
+		}
+	}
+	return suggestions
+}
+
+// getEditDistance returns the levenshtein distance of the strings.
+func getEditDistance(source, target string) int {
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(minInt(previous[j]+1, current[j-1]+1), previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+// minInt returns the smaller value.
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// getCommandFlagNames returns the flag names of the command. (with "-")
+func getCommandFlagNames(cmd Command) []string {
+	names := []string{}
+	cmd.GetFlagSet().VisitAll(func(f *flag.Flag) {
+		names = append(names, "-"+f.Name)
+	})
+	return names
+}
+
+// getCompletionFuncName returns the function name of the completion script.
+func getCompletionFuncName(programName string) string {
+	return "_" + strings.Map(func(char rune) rune {
+		if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') {
+			return char
+		}
+		return '_'
+	}, programName)
+}
+
+// quoteShellSingle quotes the text with the single quote.
+func quoteShellSingle(text string) string {
+	return "'" + strings.Replace(text, "'", "'\\''", -1) + "'"
+}
+
+// printBashCompletion prints the bash completion script.
+func printBashCompletion(w io.Writer, programName string) {
+	funcName := getCompletionFuncName(programName)
+	names := getSortedCommandNames()
+	fmt.Fprintf(w, "# bash completion for %s\n", programName)
+	fmt.Fprintf(w, "%s() {\n", funcName)
+	fmt.Fprintln(w, "  local cur=\"${COMP_WORDS[COMP_CWORD]}\"")
+	fmt.Fprintln(w, "  if [ \"$COMP_CWORD\" -eq 1 ]; then")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", quoteShellSingle(strings.Join(names, " ")))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  if [ \"${COMP_WORDS[1]}\" = \"help\" ] && [ \"$COMP_CWORD\" -eq 2 ]; then")
+	fmt.Fprintf(w, "    COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", quoteShellSingle(strings.Join(names, " ")))
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  case \"$cur\" in")
+	fmt.Fprintln(w, "  -*) ;;")
+	fmt.Fprintln(w, "  *) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;")
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "  case \"${COMP_WORDS[1]}\" in")
+	for _, name := range names {
+		flagNames := getCommandFlagNames(commandMap[name])
+		if len(flagNames) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n",
+			name, quoteShellSingle(strings.Join(flagNames, " ")))
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "complete -F %s %s\n", funcName, programName)
+}
+
+// printZshCompletion prints the zsh completion script.
+func printZshCompletion(w io.Writer, programName string) {
+	funcName := getCompletionFuncName(programName)
+	names := getSortedCommandNames()
+	// the description of _describe is after the colon.
+	escapeDescribe := func(name, description string) string {
+		return quoteShellSingle(name + ":" + strings.Replace(description, ":", "\\:", -1))
+	}
+	fmt.Fprintf(w, "#compdef %s\n", programName)
+	fmt.Fprintf(w, "%s() {\n", funcName)
+	fmt.Fprintln(w, "  local -a commands flags")
+	fmt.Fprintln(w, "  commands=(")
+	for _, name := range names {
+		fmt.Fprintf(w, "    %s\n", escapeDescribe(name, getCommandHelp(name).Description))
+	}
+	fmt.Fprintln(w, "  )")
+	fmt.Fprintln(w, "  if (( CURRENT == 2 )) || { (( CURRENT == 3 )) && [[ $words[2] == help ]] }; then")
+	fmt.Fprintln(w, "    _describe 'command' commands")
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  if [[ $words[CURRENT] != -* ]]; then")
+	fmt.Fprintln(w, "    _files")
+	fmt.Fprintln(w, "    return")
+	fmt.Fprintln(w, "  fi")
+	fmt.Fprintln(w, "  case $words[2] in")
+	for _, name := range names {
+		cmd := commandMap[name]
+		if len(getCommandFlagNames(cmd)) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "  %s)\n", name)
+		fmt.Fprintln(w, "    flags=(")
+		cmd.GetFlagSet().VisitAll(func(f *flag.Flag) {
+			usage := strings.SplitN(f.Usage, "\n", 2)[0]
+			fmt.Fprintf(w, "      %s\n", escapeDescribe("-"+f.Name, usage))
+		})
+		fmt.Fprintln(w, "    )")
+		fmt.Fprintln(w, "    _describe 'flag' flags ;;")
+	}
+	fmt.Fprintln(w, "  esac")
+	fmt.Fprintln(w, "}")
+	fmt.Fprintf(w, "compdef %s %s\n", funcName, programName)
+}
+
+// printFishCompletion prints the fish completion script.
+func printFishCompletion(w io.Writer, programName string) {
+	names := getSortedCommandNames()
+	fmt.Fprintf(w, "# fish completion for %s\n", programName)
+	fmt.Fprintf(w, "complete -c %s -f\n", programName)
+	for _, name := range names {
+		fmt.Fprintf(w, "complete -c %s -n '__fish_use_subcommand' -a %s -d %s\n",
+			programName, name, quoteShellSingle(getCommandHelp(name).Description))
+	}
+	fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from help' -a %s\n",
+		programName, quoteShellSingle(strings.Join(names, " ")))
+	for _, name := range names {
+		commandMap[name].GetFlagSet().VisitAll(func(f *flag.Flag) {
+			usage := strings.SplitN(f.Usage, "\n", 2)[0]
+			fmt.Fprintf(w, "complete -c %s -n '__fish_seen_subcommand_from %s' -o %s -d %s\n",
+				programName, name, f.Name, quoteShellSingle(usage))
+		})
+	}
+}
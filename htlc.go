@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/keys"
+	"cfd-cli/txbuilder"
+)
+
+// CreateHtlcCmd create HTLC script and descriptor.
+type CreateHtlcCmd struct {
+	cmd            string
+	flagSet        *flag.FlagSet
+	hash           *string
+	preimage       *string
+	receiverPubkey *string
+	refundPubkey   *string
+	timeout        *uint
+	isAbsolute     *bool
+	scriptType     *string
+	nettype        *string
+}
+
+// SpendHtlcCmd create and sign HTLC claim/refund transaction.
+type SpendHtlcCmd struct {
+	cmd              string
+	flagSet          *flag.FlagSet
+	script           *string
+	scriptType       *string
+	isElements       *bool
+	nettype          *string
+	txid             *string
+	vout             *uint
+	amount           *int64
+	amountCommitment *string
+	asset            *string
+	address          *string
+	fee              *int64
+	privkey          *string
+	preimage         *string
+	isRefund         *bool
+	txFilePath       *string
+}
+
+// NewCreateHtlcCmd returns a new CreateHtlcCmd struct.
+func NewCreateHtlcCmd() *CreateHtlcCmd {
+	return &CreateHtlcCmd{}
+}
+
+// Command returns the command name.
+func (cmd *CreateHtlcCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *CreateHtlcCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *CreateHtlcCmd) Init() {
+	cmd.cmd = "createhtlc"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.hash = cmd.flagSet.String("hash", "", "sha256 payment hash")
+	cmd.preimage = cmd.flagSet.String("preimage", "", "payment preimage (32 bytes. calculate hash)")
+	cmd.receiverPubkey = cmd.flagSet.String("receiverpubkey", "", "receiver (claim) pubkey")
+	cmd.refundPubkey = cmd.flagSet.String("refundpubkey", "", "sender (refund) pubkey")
+	cmd.timeout = cmd.flagSet.Uint("timeout", uint(144), "refund timeout (relative blocks, or absolute locktime with -absolute)")
+	cmd.isAbsolute = cmd.flagSet.Bool("absolute", false, "use absolute timeout (CLTV)")
+	cmd.scriptType = cmd.flagSet.String("type", "wsh", "output script type (wsh, sh-wsh)")
+	cmd.nettype = cmd.flagSet.String("network", "mainnet", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *CreateHtlcCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *CreateHtlcCmd) Do(ctx context.Context) error {
+	networkType, err := txbuilder.ParseNetworkType(*cmd.nettype)
+	if err != nil {
+		return err
+	}
+	hashType, err := txbuilder.GetHtlcHashType(*cmd.scriptType)
+	if err != nil {
+		return err
+	}
+
+	hashHex := *cmd.hash
+	if *cmd.preimage != "" {
+		preimage, err := hex.DecodeString(*cmd.preimage)
+		if err != nil || len(preimage) != 32 {
+			return errors.New("preimage must be 32 bytes hex.")
+		}
+		hash := sha256.Sum256(preimage)
+		if hashHex != "" && hashHex != hex.EncodeToString(hash[:]) {
+			return errors.New("hash does not match the preimage.")
+		}
+		hashHex = hex.EncodeToString(hash[:])
+	}
+	hash, err := hex.DecodeString(hashHex)
+	if err != nil || len(hash) != 32 {
+		return errors.New("hash must be 32 bytes hex.")
+	}
+	receiverPubkey, err := hex.DecodeString(*cmd.receiverPubkey)
+	if err != nil || len(receiverPubkey) != 33 {
+		return errors.New("receiverpubkey must be compressed pubkey.")
+	}
+	refundPubkey, err := hex.DecodeString(*cmd.refundPubkey)
+	if err != nil || len(refundPubkey) != 33 {
+		return errors.New("refundpubkey must be compressed pubkey.")
+	}
+	if *cmd.timeout == 0 || *cmd.timeout > uint(^uint32(0)) ||
+		(!*cmd.isAbsolute && *cmd.timeout > uint(txbuilder.SequenceValueMask)) {
+		return errors.New("timeout is out of range.")
+	}
+
+	htlc := &txbuilder.HtlcScript{
+		Hash:           hash,
+		ReceiverPubkey: receiverPubkey,
+		RefundPubkey:   refundPubkey,
+		Timeout:        uint32(*cmd.timeout),
+		IsAbsolute:     *cmd.isAbsolute,
+	}
+	script, err := htlc.Script()
+	if err != nil {
+		return err
+	}
+	descriptor := "wsh(" + htlc.Miniscript() + ")"
+	if hashType == int(cfd.KCfdP2shP2wsh) {
+		descriptor = "sh(" + descriptor + ")"
+	}
+	checksum, err := txbuilder.GetDescriptorChecksum(descriptor)
+	if err != nil {
+		return err
+	}
+	address, lockingScript, _, err := cfd.CfdGoCreateAddress(hashType, "",
+		hex.EncodeToString(script), networkType)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("hash         : %s\n", hashHex)
+	fmt.Printf("witnessScript: %s\n", hex.EncodeToString(script))
+	fmt.Printf("descriptor   : %s#%s\n", descriptor, checksum)
+	fmt.Printf("address      : %s\n", address)
+	fmt.Printf("lockingScript: %s\n", lockingScript)
+	return nil
+}
+
+// NewSpendHtlcCmd returns a new SpendHtlcCmd struct.
+func NewSpendHtlcCmd() *SpendHtlcCmd {
+	return &SpendHtlcCmd{}
+}
+
+// Command returns the command name.
+func (cmd *SpendHtlcCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *SpendHtlcCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *SpendHtlcCmd) Init() {
+	cmd.cmd = "spendhtlc"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.script = cmd.flagSet.String("script", "", "HTLC witness script (createhtlc output)")
+	cmd.scriptType = cmd.flagSet.String("type", "wsh", "HTLC script type (wsh, sh-wsh)")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.txid = cmd.flagSet.String("txid", "", "HTLC utxo txid")
+	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "HTLC utxo vout")
+	cmd.amount = cmd.flagSet.Int64("amount", int64(0), "HTLC utxo amount")
+	cmd.amountCommitment = cmd.flagSet.String("amountcommitment", "", "HTLC utxo amount commitment (elements)")
+	cmd.asset = cmd.flagSet.String("asset", "", "HTLC utxo asset (elements)")
+	cmd.address = cmd.flagSet.String("address", "", "destination address (unconfidential address for elements)")
+	cmd.fee = cmd.flagSet.Int64("fee", int64(1000), "fee amount")
+	cmd.privkey = cmd.flagSet.String("privkey", "", "receiver (claim) or sender (refund) privkey (hex or wif)")
+	cmd.preimage = cmd.flagSet.String("preimage", "", "payment preimage (claim)")
+	cmd.isRefund = cmd.flagSet.Bool("refund", false, "create refund transaction")
+	cmd.txFilePath = cmd.flagSet.String("file", "", "output transaction data file path")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *SpendHtlcCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *SpendHtlcCmd) Do(ctx context.Context) error {
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	hashType, err := txbuilder.GetHtlcHashType(*cmd.scriptType)
+	if err != nil {
+		return err
+	}
+	script, err := hex.DecodeString(*cmd.script)
+	if err != nil {
+		return errors.New("script is invalid.")
+	}
+	htlc, err := txbuilder.ParseHtlcScript(script)
+	if err != nil {
+		return err
+	}
+	if len(*cmd.txid) != 64 {
+		return errors.New("txid size invalid.")
+	}
+	if *cmd.address == "" {
+		return errors.New("address is required")
+	}
+	if *cmd.isElements && *cmd.asset == "" {
+		return errors.New("asset is required")
+	}
+	if *cmd.amount-*cmd.fee <= 0 {
+		return errors.New("amount is less than fee.")
+	}
+	var preimage []byte
+	if !*cmd.isRefund {
+		preimage, err = hex.DecodeString(*cmd.preimage)
+		hash := sha256.Sum256(preimage)
+		if err != nil || len(preimage) != 32 || !bytes.Equal(hash[:], htlc.Hash) {
+			return errors.New("preimage does not match the HTLC hash.")
+		}
+	}
+	privkey, err := keys.GetPrivkeyHex(*cmd.privkey)
+	if err != nil {
+		return err
+	}
+	pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey(privkey, "", true)
+	if err != nil {
+		return err
+	}
+	expectPubkey := htlc.ReceiverPubkey
+	if *cmd.isRefund {
+		expectPubkey = htlc.RefundPubkey
+	}
+	if pubkey != hex.EncodeToString(expectPubkey) {
+		return errors.New("privkey does not match the HTLC pubkey.")
+	}
+
+	// create transaction
+	locktime := uint32(0)
+	sequence := txbuilder.SequenceFinal
+	if *cmd.isRefund {
+		if htlc.IsAbsolute {
+			locktime = htlc.Timeout
+			sequence = txbuilder.SequenceFinal - 1
+		} else {
+			sequence = htlc.Timeout
+		}
+	}
+	txHex, err := txbuilder.CreateHtlcSpendTransaction(*cmd.isElements, locktime, *cmd.txid,
+		uint32(*cmd.vout), sequence, *cmd.address, *cmd.asset, *cmd.amount-*cmd.fee, *cmd.fee)
+	if err != nil {
+		return err
+	}
+
+	// sign
+	var sighash string
+	if *cmd.isElements {
+		sighash, err = cfd.CfdGoCreateConfidentialSighash(txHex, *cmd.txid, uint32(*cmd.vout),
+			hashType, "", *cmd.script, *cmd.amount, *cmd.amountCommitment,
+			int(cfd.KCfdSigHashAll), false)
+	} else {
+		sighash, err = cfd.CfdGoCreateSighash(networkType, txHex, *cmd.txid, uint32(*cmd.vout),
+			hashType, "", *cmd.script, *cmd.amount, int(cfd.KCfdSigHashAll), false)
+	}
+	if err != nil {
+		return err
+	}
+	signature, err := cfd.CfdGoCalculateEcSignature(sighash, privkey, "", networkType, true)
+	if err != nil {
+		return err
+	}
+	derSignature, err := cfd.CfdGoEncodeSignatureByDer(signature, int(cfd.KCfdSigHashAll), false)
+	if err != nil {
+		return err
+	}
+	sigBytes, err := hex.DecodeString(derSignature)
+	if err != nil {
+		return err
+	}
+
+	tx, err := txbuilder.DecodeTransaction(txHex, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	txin := tx.TxIn[0]
+	if *cmd.isRefund {
+		// sat(and_v(v:pk(refund),older)) + dsat(pk(receiver))
+		txin.Witness = [][]byte{sigBytes, {}, script}
+	} else {
+		// sat(sha256) + sat(pk(receiver))
+		txin.Witness = [][]byte{preimage, sigBytes, script}
+	}
+	if hashType == int(cfd.KCfdP2shP2wsh) {
+		scriptHash := sha256.Sum256(script)
+		txin.ScriptSig = txbuilder.NewScriptBuilder().AddData(
+			append([]byte{txbuilder.OpFalse, 0x20}, scriptHash[:]...)).Bytes()
+	}
+	txHex = tx.Hex()
+
+	if *cmd.txFilePath != "" {
+		data := &cache.TransactionCacheData{Hex: txHex}
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
+		if err != nil {
+			return err
+		}
+	}
+	if *cmd.isRefund {
+		fmt.Printf("refund tx:\n%s\n", txHex)
+	} else {
+		fmt.Printf("claim tx:\n%s\n", txHex)
+	}
+	return nil
+}
@@ -1,52 +1,26 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"strings"
 
-	cfd "github.com/cryptogarageinc/cfd-go"
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
-// UtxoData utxo data mapping.
-type UtxoData struct {
-	Txid              string `json:"txid"`
-	Vout              uint32 `json:"vout"`
-	Amount            int64  `json:"amount"`
-	Asset             string `json:"asset"`
-	AssetBlinder      string `json:"assetblinder"`
-	AssetCommitment   string `json:"assetcommitment"`
-	AmountBlinder     string `json:"blinder"`
-	AmountCommitment  string `json:"amountcommitment"`
-	Descriptor        string `json:"descriptor"`
-	ScriptsigTemplate string `json:"scriptsigTemplate"`
-}
-
-// TransactionCacheData transaction cache data mapping.
-type TransactionCacheData struct {
-	Hex   string     `json:"hex"`
-	Utxos []UtxoData `json:"utxos"`
-}
-
-// NewTransactionCacheData returns a new TransactionCacheData struct.
-func NewTransactionCacheData() *TransactionCacheData {
-	return &TransactionCacheData{}
-}
-
 // InitializeTransactionCmd initialize transaction hex.
 type InitializeTransactionCmd struct {
-	cmd        string
-	flagSet    *flag.FlagSet
-	version    *uint
-	locktime   *uint
-	txFilePath *string
-	isElements *bool
+	cmd            string
+	flagSet        *flag.FlagSet
+	version        *uint
+	locktime       *uint
+	locktimeHeight *uint
+	locktimeDate   *string
+	txFilePath     *string
+	isElements     *bool
 }
 
 // NewInitializeTransactionCmd returns a new InitializeTransactionCmd struct.
@@ -70,6 +44,9 @@ func (cmd *InitializeTransactionCmd) Init() {
 	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
 	cmd.version = cmd.flagSet.Uint("version", uint(2), "tx version")
 	cmd.locktime = cmd.flagSet.Uint("locktime", uint(0), "locktime")
+	cmd.locktimeHeight = cmd.flagSet.Uint("locktimeheight", uint(0), "absolute locktime in block height (BIP65)")
+	cmd.locktimeDate = cmd.flagSet.String("locktimedate", "",
+		"absolute locktime in date (UTC). ex) 2020-01-02T15:04:05Z, 2020-01-02")
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
 }
@@ -80,103 +57,52 @@ func (cmd *InitializeTransactionCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *InitializeTransactionCmd) Do(ctx context.Context) {
-	var tx string
-	var err error
-	var handle uintptr
-	if *cmd.isElements {
-		handle, err = cfd.CfdGoInitializeConfidentialTransaction(
-			uint32(*cmd.version),
-			uint32(*cmd.locktime))
-		if err == nil {
-			defer cfd.CfdGoFreeTransactionHandle(handle)
-			tx, err = cfd.CfdGoFinalizeTransaction(handle)
-		}
-	} else {
-		handle, err = cfd.CfdGoInitializeTransaction(
-			uint32(*cmd.version),
-			uint32(*cmd.locktime))
-		if err == nil {
-			defer cfd.CfdGoFreeTransactionHandle(handle)
-			tx, err = cfd.CfdGoFinalizeTransaction(handle)
-		}
-	}
+func (cmd *InitializeTransactionCmd) Do(ctx context.Context) error {
+	result, err := cmd.Result(ctx)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
-
+	data := result.(*cache.TransactionCacheData)
 	if *cmd.txFilePath == "" {
-		fmt.Printf("initialize transaction: %s\n", tx)
-	} else {
-		data := NewTransactionCacheData()
-		data.Hex = tx
-
-		_, err = os.Stat(*cmd.txFilePath)
-		if err == nil {
-			if err = os.Remove(*cmd.txFilePath); err != nil {
-				fmt.Println(err)
-				return
-			}
-		}
-
-		jsonData, err := json.Marshal(*data)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-
-		var buf bytes.Buffer
-		err = json.Indent(&buf, jsonData, "", "  ")
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		indentJSON := buf.String()
-
-		err = ioutil.WriteFile(*cmd.txFilePath, []byte(indentJSON), 666)
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		fmt.Printf("initialize transaction:\n%s\n", indentJSON)
-	}
-
-}
-
-// WriteTransactionCache write jsondata to file.
-func WriteTransactionCache(path string, cache *TransactionCacheData) (jsonString string, err error) {
-	if cache == nil {
-		return "", errors.New("cahce is null")
-	}
-	jsonData, err := json.Marshal(*cache)
-	if err != nil {
-		return "", err
+		fmt.Printf("initialize transaction: %s\n", data.Hex)
+		return nil
 	}
-
-	var buf bytes.Buffer
-	err = json.Indent(&buf, jsonData, "", "  ")
+	indentJSON, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
-		return "", err
+		return err
 	}
-	indentJSON := buf.String()
-
-	err = ioutil.WriteFile(path, []byte(indentJSON), 666)
-	return indentJSON, err
+	fmt.Printf("initialize transaction:\n%s\n", indentJSON)
+	return nil
 }
 
-// ReadTransactionCache read jsondata from file.
-func ReadTransactionCache(path string) (cache *TransactionCacheData, err error) {
-	_, err = os.Stat(path)
-	if err != nil {
-		return nil, errors.New("tx data file not found")
+// Result performs the command action, and returns the transaction data.
+func (cmd *InitializeTransactionCmd) Result(ctx context.Context) (interface{}, error) {
+	var err error
+	locktime := uint32(*cmd.locktime)
+	switch {
+	case *cmd.locktimeHeight > 0 && *cmd.locktimeDate != "":
+		return nil, errors.New("locktimeheight and locktimedate are exclusive")
+	case *cmd.locktimeHeight >= uint(txbuilder.LocktimeThreshold):
+		return nil, errors.New("locktimeheight is out of range")
+	case *cmd.locktimeHeight > 0:
+		locktime = uint32(*cmd.locktimeHeight)
+	case *cmd.locktimeDate != "":
+		if locktime, err = txbuilder.ParseLocktimeDate(*cmd.locktimeDate); err != nil {
+			return nil, err
+		}
 	}
-	bytes, err := ioutil.ReadFile(path)
+	data, err := txbuilder.InitializeTransaction(txbuilder.InitializeTransactionRequest{
+		Version:    uint32(*cmd.version),
+		Locktime:   locktime,
+		IsElements: *cmd.isElements,
+	})
 	if err != nil {
 		return nil, err
 	}
-	var data TransactionCacheData
-	jsonString := strings.TrimSpace(string(bytes))
-	err = json.Unmarshal([]byte(jsonString), &data)
-	return &data, err
+	if *cmd.txFilePath != "" {
+		if _, err = cache.WriteTransactionCache(*cmd.txFilePath, data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
 }
@@ -0,0 +1,18 @@
+// Package keys provides the signing keys from the privkey, wif, extended privkey and keystore.
+package keys
+
+import (
+	cfd "github.com/cryptogarageinc/cfd-go"
+)
+
+// GetPrivkeyHex returns the privkey hex from hex or wif.
+func GetPrivkeyHex(privkey string) (string, error) {
+	if len(privkey) == 64 {
+		return privkey, nil
+	}
+	privkeyHex, err := cfd.CfdGoGetPrivkeyFromWif(privkey, int(cfd.KCfdNetworkMainnet))
+	if err != nil {
+		privkeyHex, err = cfd.CfdGoGetPrivkeyFromWif(privkey, int(cfd.KCfdNetworkTestnet))
+	}
+	return privkeyHex, err
+}
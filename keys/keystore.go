@@ -0,0 +1,49 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v2"
+)
+
+// KeystoreIDPrefix the prefix of the keystore id in the keys. (keystore:<id>)
+const KeystoreIDPrefix = "keystore:"
+
+// Keystore the signing keys by the id.
+type Keystore map[string]string
+
+// keystoreFile the keystore file mapping.
+type keystoreFile struct {
+	// Keys the key by the id. (privkey hex, wif, extended privkey or extended privkey with bip32 path)
+	Keys map[string]string `yaml:"keys"`
+}
+
+// LoadKeystore loads the keystore file.
+func LoadKeystore(path string) (Keystore, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file keystoreFile
+	if err = yaml.UnmarshalStrict(bytes, &file); err != nil {
+		return nil, fmt.Errorf("keystore %s: %s", path, err.Error())
+	}
+	if file.Keys == nil {
+		return Keystore{}, nil
+	}
+	return Keystore(file.Keys), nil
+}
+
+// GetKey returns the key of the id.
+func (keystore Keystore) GetKey(id string) (string, error) {
+	if keystore == nil {
+		return "", errors.New("keystore is required for the keystore id")
+	}
+	key, ok := keystore[id]
+	if !ok {
+		return "", fmt.Errorf("keystore id %s is not found", id)
+	}
+	return key, nil
+}
@@ -0,0 +1,47 @@
+package keys
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeystore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfd-cli-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keystore.yaml")
+	if err = ioutil.WriteFile(path, []byte("keys:\n  alice: xprv.../0/1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keystore, err := LoadKeystore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key, err := keystore.GetKey("alice"); err != nil || key != "xprv.../0/1" {
+		t.Errorf("alice: key %s, error %v", key, err)
+	}
+	if _, err = keystore.GetKey("bob"); err == nil {
+		t.Errorf("bob: the unknown id is found")
+	}
+
+	if err = ioutil.WriteFile(path, []byte("key:\n  alice: xprv\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadKeystore(path); err == nil {
+		t.Errorf("the unknown field is loaded")
+	}
+}
+
+func TestNewSigningKeySetKeystoreID(t *testing.T) {
+	if _, err := NewSigningKeySet([]string{"keystore:alice"}, nil); err == nil {
+		t.Errorf("the keystore id is resolved without the keystore")
+	}
+	if _, err := NewSigningKeySet([]string{"keystore:bob"}, Keystore{"alice": "xprv"}); err == nil {
+		t.Errorf("the unknown keystore id is resolved")
+	}
+}
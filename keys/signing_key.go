@@ -0,0 +1,178 @@
+package keys
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/hashes"
+)
+
+// descriptorExtkeyRegexp matches the extended key expression of the descriptor.
+// ex. [d34db33f/48'/0'/0'/2']xpub.../0/1
+var descriptorExtkeyRegexp = regexp.MustCompile(
+	`(?:\[([0-9a-fA-F]{8})((?:/[0-9]+['hH]?)*)\])?([xt](?:pub|prv)[1-9A-HJ-NP-Za-km-z]+)((?:/[0-9]+['hH]?)*)`)
+
+// SigningKey signing key pair.
+type SigningKey struct {
+	Privkey string
+	Pubkey  string
+}
+
+// signingExtkey extended privkey for signing.
+type signingExtkey struct {
+	// master the extended privkey of the key origin.
+	master string
+	// extpriv the extended privkey derived from master by the path.
+	extpriv string
+	extpub  string
+	// fingerprint the fingerprint of master.
+	fingerprint string
+	networkType int
+}
+
+// SigningKeySet signing keys. (privkeys and extended privkeys)
+type SigningKeySet struct {
+	keys    []SigningKey
+	extkeys []signingExtkey
+}
+
+// NewSigningKeySet returns a new SigningKeySet from the keys.
+// The key is privkey hex, wif, extended privkey, extended privkey with bip32 path (xprv.../0/1)
+// or keystore id (keystore:<id>). The keystore is required for the keystore id.
+func NewSigningKeySet(keys []string, keystore Keystore) (*SigningKeySet, error) {
+	keySet := &SigningKeySet{}
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if strings.HasPrefix(key, KeystoreIDPrefix) {
+			var err error
+			if key, err = keystore.GetKey(strings.TrimPrefix(key, KeystoreIDPrefix)); err != nil {
+				return nil, err
+			}
+		}
+		switch {
+		case key == "":
+			continue
+		case strings.HasPrefix(key, "xprv") || strings.HasPrefix(key, "tprv"):
+			path := ""
+			if index := strings.Index(key, "/"); index >= 0 {
+				key, path = key[:index], strings.TrimPrefix(key[index:], "/")
+			}
+			extkey, err := newSigningExtkey(key, path)
+			if err != nil {
+				return nil, err
+			}
+			keySet.extkeys = append(keySet.extkeys, *extkey)
+		default:
+			privkey := key
+			isCompressed := true
+			var err error
+			if len(privkey) != 64 {
+				// the uncompressed wif signs with the uncompressed pubkey.
+				privkey, _, isCompressed, err = cfd.CfdGoParsePrivkeyWif(key)
+				if err != nil {
+					return nil, err
+				}
+			}
+			pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey(privkey, "", isCompressed)
+			if err != nil {
+				return nil, err
+			}
+			keySet.keys = append(keySet.keys, SigningKey{Privkey: privkey, Pubkey: pubkey})
+		}
+	}
+	return keySet, nil
+}
+
+// newSigningExtkey returns the extended privkey derived by the path.
+// The fingerprint is the key origin fingerprint of extpriv, not of the derived key.
+func newSigningExtkey(extpriv, path string) (*signingExtkey, error) {
+	info, err := cfd.CfdGoGetExtkeyInformation(extpriv)
+	if err != nil {
+		return nil, err
+	}
+	extkey := &signingExtkey{master: extpriv, extpriv: extpriv, networkType: int(cfd.KCfdNetworkTestnet)}
+	if info.Version == "0488ade4" {
+		extkey.networkType = int(cfd.KCfdNetworkMainnet)
+	}
+	if path != "" {
+		extkey.extpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(
+			extpriv, path, extkey.networkType, int(cfd.KCfdExtPrivkey))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if extkey.extpub, err = cfd.CfdGoCreateExtPubkey(extkey.extpriv, extkey.networkType); err != nil {
+		return nil, err
+	}
+	pubkey, err := cfd.CfdGoGetPubkeyFromExtkey(extpriv, extkey.networkType)
+	if err != nil {
+		return nil, err
+	}
+	pubkeyBytes, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	extkey.fingerprint = hex.EncodeToString(hashes.Hash160(pubkeyBytes)[:4])
+	return extkey, nil
+}
+
+// GetKeys returns the privkeys and the extended privkeys derived for the descriptor keys.
+// The extended privkey is matched by the extended pubkey, and derived by the child path.
+// The master key is matched by the key origin fingerprint, and derived by the origin and child path.
+func (keySet *SigningKeySet) GetKeys(descriptor string) []SigningKey {
+	keys := append([]SigningKey{}, keySet.keys...)
+	for _, match := range descriptorExtkeyRegexp.FindAllStringSubmatch(descriptor, -1) {
+		fingerprint, originPath, descExtkey, childPath := match[1], match[2], match[3], match[4]
+		for _, extkey := range keySet.extkeys {
+			base, path := "", ""
+			switch {
+			case descExtkey == extkey.extpub || descExtkey == extkey.extpriv:
+				base, path = extkey.extpriv, childPath
+			case fingerprint != "" && strings.EqualFold(fingerprint, extkey.fingerprint):
+				base, path = extkey.master, originPath+childPath
+			default:
+				continue
+			}
+			key, err := extkey.derive(base, strings.TrimPrefix(path, "/"))
+			if err == nil {
+				keys = append(keys, *key)
+			}
+		}
+	}
+	return keys
+}
+
+// derive derives the signing key from the base extended privkey by the path.
+func (extkey *signingExtkey) derive(base, path string) (*SigningKey, error) {
+	extpriv := base
+	var err error
+	if path != "" {
+		extpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(
+			extpriv, path, extkey.networkType, int(cfd.KCfdExtPrivkey))
+		if err != nil {
+			return nil, err
+		}
+	}
+	privkey, _, err := cfd.CfdGoGetPrivkeyFromExtkey(extpriv, extkey.networkType)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey(privkey, "", true)
+	if err != nil {
+		return nil, err
+	}
+	return &SigningKey{Privkey: privkey, Pubkey: pubkey}, nil
+}
+
+// FindSigningKey returns the key of the pubkey.
+func FindSigningKey(keys []SigningKey, pubkey string) *SigningKey {
+	for index := range keys {
+		if keys[index].Pubkey == pubkey {
+			return &keys[index]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const (
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyBackspace = 8
+	keyTab       = 9
+	keyLF        = 10
+	keyCR        = 13
+	keyCtrlU     = 21
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// errLineEditorEOF the end of input. (ctrl-d or EOF)
+var errLineEditorEOF = errors.New("EOF")
+
+// lineCompleter returns the completion candidates of the last word of the line.
+type lineCompleter func(line string) []string
+
+// lineEditor reads the line with the tab completion and the history.
+// The terminal is set to raw mode by stty, and it is the line input without stty. (not terminal)
+type lineEditor struct {
+	reader    *bufio.Reader
+	completer lineCompleter
+	history   []string
+	ttyState  string
+}
+
+// newLineEditor returns a new lineEditor struct.
+func newLineEditor(completer lineCompleter) *lineEditor {
+	editor := &lineEditor{
+		reader:    bufio.NewReader(os.Stdin),
+		completer: completer,
+	}
+	if state, err := runStty("-g"); err == nil {
+		editor.ttyState = strings.TrimSpace(state)
+	}
+	return editor
+}
+
+// runStty runs the stty command for stdin.
+func runStty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	output, err := command.Output()
+	return string(output), err
+}
+
+// isTerminal returns true if the input is terminal.
+func (editor *lineEditor) isTerminal() bool {
+	return editor.ttyState != ""
+}
+
+// ReadLine reads the line with the prompt.
+func (editor *lineEditor) ReadLine(prompt string) (string, error) {
+	if !editor.isTerminal() {
+		fmt.Print(prompt)
+		line, err := editor.reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return "", errLineEditorEOF
+		} else if err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	if _, err := runStty("-icanon", "-echo", "-isig", "min", "1"); err != nil {
+		return "", err
+	}
+	defer runStty(editor.ttyState)
+
+	line := []rune{}
+	historyIndex := len(editor.history)
+	redraw := func() {
+		fmt.Printf("\r\033[K%s%s", prompt, string(line))
+	}
+	redraw()
+	for {
+		char, _, err := editor.reader.ReadRune()
+		if err != nil {
+			return "", errLineEditorEOF
+		}
+		switch char {
+		case keyCR, keyLF:
+			fmt.Print("\r\n")
+			text := string(line)
+			if strings.TrimSpace(text) != "" {
+				editor.history = append(editor.history, text)
+			}
+			return text, nil
+		case keyCtrlC:
+			fmt.Print("^C\r\n")
+			line = line[:0]
+		case keyCtrlD:
+			if len(line) == 0 {
+				fmt.Print("\r\n")
+				return "", errLineEditorEOF
+			}
+		case keyCtrlU:
+			line = line[:0]
+		case keyBackspace, keyDelete:
+			if len(line) > 0 {
+				line = line[:len(line)-1]
+			}
+		case keyTab:
+			line = []rune(editor.complete(string(line)))
+		case keyEscape:
+			// arrow key. (ESC [ A/B/C/D)
+			if next, _, err := editor.reader.ReadRune(); err != nil || next != '[' {
+				continue
+			}
+			arrow, _, _ := editor.reader.ReadRune()
+			switch {
+			case arrow == 'A' && historyIndex > 0:
+				historyIndex--
+				line = []rune(editor.history[historyIndex])
+			case arrow == 'B' && historyIndex < len(editor.history)-1:
+				historyIndex++
+				line = []rune(editor.history[historyIndex])
+			case arrow == 'B':
+				historyIndex = len(editor.history)
+				line = line[:0]
+			}
+		default:
+			if char >= ' ' {
+				line = append(line, char)
+			}
+		}
+		redraw()
+	}
+}
+
+// complete completes the last word of the line.
+// If the candidates are multiple, the common prefix is completed and the candidates are printed.
+func (editor *lineEditor) complete(line string) string {
+	if editor.completer == nil {
+		return line
+	}
+	candidates := editor.completer(line)
+	if len(candidates) == 0 {
+		return line
+	}
+	word := line[strings.LastIndexAny(line, " \t")+1:]
+	base := line[:len(line)-len(word)]
+	if len(candidates) == 1 {
+		return base + candidates[0] + " "
+	}
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		return base + prefix
+	}
+	sort.Strings(candidates)
+	fmt.Printf("\r\n%s\r\n", strings.Join(candidates, "  "))
+	return line
+}
+
+// splitShellArgs splits the line into the arguments.
+// The single/double quote and the backslash escape are supported.
+func splitShellArgs(line string) ([]string, error) {
+	args := []string{}
+	var current strings.Builder
+	hasArg := false
+	var quote rune
+	isEscape := false
+	for _, char := range line {
+		switch {
+		case isEscape:
+			current.WriteRune(char)
+			isEscape = false
+		case char == '\\' && quote != '\'':
+			isEscape = true
+			hasArg = true
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(char)
+		case char == '\'' || char == '"':
+			quote = char
+			hasArg = true
+		case char == ' ' || char == '\t':
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(char)
+			hasArg = true
+		}
+	}
+	if quote != 0 || isEscape {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
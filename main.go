@@ -11,11 +11,19 @@ import (
 )
 
 // Command represent a command that can be invoked.
+// Do returns the error if the action fails.
 type Command interface {
 	Command() string
 	GetFlagSet() *flag.FlagSet
 	Init()
-	Do(context.Context)
+	Do(context.Context) error
+}
+
+// ResultCommand represent a command that returns the result of the action instead of printing it.
+// The result is encoded to JSON by the serve command.
+type ResultCommand interface {
+	Command
+	Result(context.Context) (interface{}, error)
 }
 
 var commandMap map[string]Command
@@ -41,15 +49,38 @@ func init() {
 		NewSetRawReissueAssetCmd(),
 		NewBlindRawTransactionCmd(),
 		NewEstimateFeeCmd(),
+		NewBumpFeeCmd(),
+		NewCpfpCmd(),
+		NewValidateTimelockCmd(),
 		NewCreateSignatureHashCmd(),
 		NewGetSignatureCmd(),
 		NewAddSignTransactionCmd(),
 		NewSignWithPrivkeyCmd(),
+		NewSignTransactionCmd(),
+		NewCombineTransactionCmd(),
 		NewVerifySignatureCmd(),
 		NewGetCommitmentCmd(),
 		NewCreatePubkeyFromParentPathCmd(),
 		NewParseDescriptorCmd(),
+		NewCompilePolicyCmd(),
+		NewAnalyzeDescriptorCmd(),
+		NewCreateHtlcCmd(),
+		NewSpendHtlcCmd(),
+		NewDecodeScriptCmd(),
+		NewEncodeScriptCmd(),
+		NewDebugScriptCmd(),
 		NewGetExtkeypairFromMnemonicCmd(),
+		NewServeCmd(),
+		NewRunCmd(),
+		NewShellCmd(),
+		NewHelpCmd(),
+		NewCompletionCmd(),
+		NewConfigCmd(),
+		NewRegtestInitCmd(),
+		NewRegtestFundCmd(),
+		NewRegtestSendCmd(),
+		NewRegtestMineCmd(),
+		NewRegtestListUnspentCmd(),
 	} {
 		cmd.Init()
 		commandMap[cmd.Command()] = cmd
@@ -58,12 +89,8 @@ func init() {
 
 func main() {
 	if len(os.Args) == 1 {
-		fmt.Println("Need to specify a command. Available commands are:")
-
-		for name := range commandMap {
-			fmt.Println(name)
-		}
-
+		fmt.Println("Need to specify a command.")
+		printCommandList(os.Stdout)
 		return
 	}
 
@@ -72,15 +99,32 @@ func main() {
 	cmd, ok := commandMap[cmdName]
 
 	if !ok {
-		fmt.Println("Unknown command ", cmdName)
-		return
+		printUnknownCommand(os.Stdout, cmdName)
+		os.Exit(1)
 	}
 
-	if err := cmd.GetFlagSet().Parse(os.Args[2:]); err != nil {
+	flagSet := cmd.GetFlagSet()
+	flagSet.Usage = func() {
+		printCommandHelp(flagSet.Output(), cmd)
+	}
+
+	if err := flagSet.Parse(os.Args[2:]); err != nil {
 		log.Fatalf("Error parsing flags %v", err)
 	}
+	if err := applyConfigDefaults(cmdName, flagSet); err != nil {
+		log.Fatalf("Error loading config %v", err)
+	}
 
 	ctx := context.Background()
 
-	cmd.Do(ctx)
+	var err error
+	if isPipeCommand(flagSet) {
+		err = doPipeCommand(ctx, cmdName, os.Args[2:])
+	} else {
+		err = cmd.Do(ctx)
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,245 @@
+// Package params parses the list formats of the command flags.
+// (ex. "txid,vout,key|txid2,vout2,key2", "sig1,sig2", "m/44h/0h/0h,m/44h/0h/1h")
+package params
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ListSeparator the separator of the list items. (ex. keys, files, blinding keys)
+	ListSeparator = "|"
+	// ItemSeparator the separator of the item fields and the comma-separated lists.
+	ItemSeparator = ","
+	// hardenedIndex the first hardened child index of bip32.
+	hardenedIndex = uint64(0x80000000)
+)
+
+// BlindingKey the blinding key of the input.
+type BlindingKey struct {
+	// Txid the txid as given. (compare case insensitively)
+	Txid        string
+	Vout        uint32
+	BlindingKey string
+}
+
+// OutputBlindingKey the blinding key of the output.
+type OutputBlindingKey struct {
+	Vout        uint32
+	BlindingKey string
+}
+
+// SignatureItem the signature and the related pubkey. (pubkey is empty if not specified)
+type SignatureItem struct {
+	Signature string
+	Pubkey    string
+}
+
+// ScriptElement the element of the script input stack.
+type ScriptElement struct {
+	// Data the data hex. (the compact signature if IsSignature)
+	Data string
+	// IsSignature true if the data is the compact signature to encode to der with the sighash type.
+	IsSignature bool
+}
+
+// KeyValue the key and value of the "key=value" item.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// SplitList splits the text by the separator, and trims the spaces of the items.
+// The empty text returns the empty list.
+func SplitList(text, separator string) []string {
+	if strings.TrimSpace(text) == "" {
+		return []string{}
+	}
+	items := strings.Split(text, separator)
+	for index, item := range items {
+		items[index] = strings.TrimSpace(item)
+	}
+	return items
+}
+
+// ParseNonEmptyList parses the list that must not contain the empty item.
+func ParseNonEmptyList(text, separator string) ([]string, error) {
+	items := SplitList(text, separator)
+	for index, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("item[%d] is empty", index)
+		}
+	}
+	return items, nil
+}
+
+// ParseBlindingKeys parses the blinding keys. (format: txid,vout,blindingKey|txid2,vout2,blindingKey2|...)
+func ParseBlindingKeys(text string) ([]BlindingKey, error) {
+	items := SplitList(text, ListSeparator)
+	result := make([]BlindingKey, 0, len(items))
+	for index, item := range items {
+		fields := SplitList(item, ItemSeparator)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("blindingkeys[%d] must be txid,vout,blindingKey", index)
+		}
+		if !IsHex(fields[0], 32) {
+			return nil, fmt.Errorf("blindingkeys[%d] txid is invalid", index)
+		}
+		vout, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("blindingkeys[%d] vout is invalid", index)
+		}
+		if !IsHex(fields[2], 32) {
+			return nil, fmt.Errorf("blindingkeys[%d] blinding key is invalid", index)
+		}
+		result = append(result, BlindingKey{
+			Txid:        fields[0],
+			Vout:        uint32(vout),
+			BlindingKey: strings.ToLower(fields[2]),
+		})
+	}
+	return result, nil
+}
+
+// ParseOutputBlindingKeys parses the blinding keys of the outputs. (format: vout,blindingKey|vout2,blindingKey2|...)
+func ParseOutputBlindingKeys(text string) ([]OutputBlindingKey, error) {
+	items := SplitList(text, ListSeparator)
+	result := make([]OutputBlindingKey, 0, len(items))
+	for index, item := range items {
+		fields := SplitList(item, ItemSeparator)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("blindingkeys[%d] must be vout,blindingKey", index)
+		}
+		vout, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("blindingkeys[%d] vout is invalid", index)
+		}
+		if !IsHex(fields[1], 32) {
+			return nil, fmt.Errorf("blindingkeys[%d] blinding key is invalid", index)
+		}
+		result = append(result, OutputBlindingKey{Vout: uint32(vout), BlindingKey: strings.ToLower(fields[1])})
+	}
+	return result, nil
+}
+
+// ParseSignatures parses the comma-separated signatures and pubkeys.
+// The pubkeys are optional, and the count must be same as the signatures if specified.
+// The empty signature is skipped. (ex. "sig1,,sig3" with "pubkey1,pubkey2,pubkey3")
+func ParseSignatures(signatures, pubkeys string) ([]SignatureItem, error) {
+	sigList := SplitList(signatures, ItemSeparator)
+	pubkeyList := SplitList(pubkeys, ItemSeparator)
+	if len(pubkeyList) > 0 && len(sigList) != len(pubkeyList) {
+		return nil, errors.New("pubkey count is unmatch signature count")
+	}
+	result := make([]SignatureItem, 0, len(sigList))
+	for index, signature := range sigList {
+		if signature == "" {
+			continue
+		}
+		if !IsHex(signature, 0) {
+			return nil, fmt.Errorf("signature[%d] is not hex", index)
+		}
+		item := SignatureItem{Signature: signature}
+		if len(pubkeyList) > 0 {
+			item.Pubkey = pubkeyList[index]
+			if !IsHex(item.Pubkey, 33) && !IsHex(item.Pubkey, 65) {
+				return nil, fmt.Errorf("pubkey[%d] is invalid", index)
+			}
+		}
+		result = append(result, item)
+	}
+	if len(result) == 0 {
+		return nil, errors.New("signature is required")
+	}
+	return result, nil
+}
+
+// ParseScriptElements parses the comma-separated script input elements.
+// "sig:<compact signature>" is the 64 bytes signature, "data:<hex>" or "<hex>" is pushed as is.
+// "data:" is the empty push, and the empty element is skipped. (ex. "sig:<sig>,data:<preimage>,data:")
+func ParseScriptElements(text string) ([]ScriptElement, error) {
+	items := SplitList(text, ItemSeparator)
+	result := make([]ScriptElement, 0, len(items))
+	for index, item := range items {
+		switch {
+		case item == "":
+			continue
+		case strings.HasPrefix(item, "sig:"):
+			signature := strings.TrimPrefix(item, "sig:")
+			if !IsHex(signature, 64) {
+				return nil, fmt.Errorf("signature[%d] must be the 64 bytes compact signature", index)
+			}
+			result = append(result, ScriptElement{Data: signature, IsSignature: true})
+		default:
+			data := strings.TrimPrefix(item, "data:")
+			if data != "" && !IsHex(data, 0) {
+				return nil, fmt.Errorf("signature[%d] is not hex", index)
+			}
+			result = append(result, ScriptElement{Data: data})
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("signature is required")
+	}
+	return result, nil
+}
+
+// ParseKeyPaths parses the comma-separated bip32 paths. (ex. "m/44h/0h/0h,m/44h/0h/1h")
+// The empty text returns the master path. (one empty path)
+func ParseKeyPaths(text string) ([]string, error) {
+	paths := SplitList(text, ItemSeparator)
+	if len(paths) == 0 {
+		return []string{""}, nil
+	}
+	for index, path := range paths {
+		if err := ValidateKeyPath(path); err != nil {
+			return nil, fmt.Errorf("path[%d] is invalid. %s", index, err.Error())
+		}
+	}
+	return paths, nil
+}
+
+// ValidateKeyPath validates the bip32 path. (ex. "m/44h/0'/0", "0/1")
+func ValidateKeyPath(path string) error {
+	if path == "" || path == "m" {
+		return nil
+	}
+	steps := strings.Split(strings.TrimPrefix(path, "m/"), "/")
+	for _, step := range steps {
+		number := strings.TrimRight(step, "hH'")
+		if len(step)-len(number) > 1 {
+			return fmt.Errorf("child number %s is invalid", step)
+		}
+		value, err := strconv.ParseUint(number, 10, 32)
+		if err != nil || value >= hardenedIndex || (number != "0" && strings.HasPrefix(number, "0")) {
+			return fmt.Errorf("child number %s is invalid", step)
+		}
+	}
+	return nil
+}
+
+// ParseKeyValues parses the "key=value|key2=value2" list. The value can contain "=".
+func ParseKeyValues(text string) ([]KeyValue, error) {
+	result := []KeyValue{}
+	for _, item := range SplitList(text, ListSeparator) {
+		keyValue := strings.SplitN(item, "=", 2)
+		if len(keyValue) != 2 || strings.TrimSpace(keyValue[0]) == "" {
+			return nil, fmt.Errorf("%s is invalid format", item)
+		}
+		result = append(result, KeyValue{Key: strings.TrimSpace(keyValue[0]), Value: keyValue[1]})
+	}
+	return result, nil
+}
+
+// IsHex returns true if the text is hex. (size is the byte size, 0 is any size)
+func IsHex(text string, size int) bool {
+	if text == "" || (size > 0 && len(text) != size*2) {
+		return false
+	}
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
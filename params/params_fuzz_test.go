@@ -0,0 +1,203 @@
+//go:build go1.18
+// +build go1.18
+
+package params
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const (
+	fuzzTxid        = "57a15002d066ce52573d674df925c9bc0f1164849420705f2cfad8a68111230f"
+	fuzzBlindingKey = "66e4df5035a64acef16b4aa52ddc8bebd22b22c9aca5d9de71b8bcb2a5f2a8b1"
+	fuzzPubkey      = "03f942716865bb9b62678d99aa34de4632249d066d99de2b5a2e542e54908450d6"
+	fuzzSignature   = "3044022047df6ac4d1e33c8f2e0c8c5b2b5d0b0fb5f5e5b7d6a3ce4ad6f3e1c0b19b3f520220" +
+		"7a1f7e2c1a98cd2b4b3c5c3e8f8b8f9b6e9d0a1f2c3b4a5d6e7f8091a2b3c4d501"
+)
+
+func FuzzParseBlindingKeys(f *testing.F) {
+	f.Add(fuzzTxid + ",0," + fuzzBlindingKey)
+	f.Add(fuzzTxid + ",1," + fuzzBlindingKey + "|" + fuzzTxid + ",4294967295," + fuzzBlindingKey)
+	f.Add(fuzzTxid + ",-1," + fuzzBlindingKey)
+	f.Add(fuzzTxid + "," + fuzzBlindingKey)
+	f.Add("||,,")
+	f.Fuzz(func(t *testing.T, text string) {
+		keys, err := ParseBlindingKeys(text)
+		if err != nil {
+			return
+		}
+		items := SplitList(text, ListSeparator)
+		if len(keys) != len(items) {
+			t.Fatalf("key count %d is unmatch item count %d", len(keys), len(items))
+		}
+		for index, key := range keys {
+			if !IsHex(key.Txid, 32) || !IsHex(key.BlindingKey, 32) {
+				t.Fatalf("keys[%d] is invalid: %+v", index, key)
+			}
+			// the parsed value is parsed again with the same result.
+			formatted := strings.Join([]string{key.Txid, strconv.FormatUint(uint64(key.Vout), 10), key.BlindingKey}, ItemSeparator)
+			again, err := ParseBlindingKeys(formatted)
+			if err != nil || len(again) != 1 || again[0] != key {
+				t.Fatalf("keys[%d] round trip failed: %+v, %v", index, again, err)
+			}
+		}
+	})
+}
+
+func FuzzParseOutputBlindingKeys(f *testing.F) {
+	f.Add("0," + fuzzBlindingKey)
+	f.Add("0," + fuzzBlindingKey + "|2," + fuzzBlindingKey)
+	f.Add("x," + fuzzBlindingKey)
+	f.Add("0,1,2")
+	f.Fuzz(func(t *testing.T, text string) {
+		keys, err := ParseOutputBlindingKeys(text)
+		if err != nil {
+			return
+		}
+		if len(keys) != len(SplitList(text, ListSeparator)) {
+			t.Fatalf("key count %d is unmatch", len(keys))
+		}
+		for index, key := range keys {
+			if !IsHex(key.BlindingKey, 32) {
+				t.Fatalf("keys[%d] is invalid: %+v", index, key)
+			}
+		}
+	})
+}
+
+func FuzzParseSignatures(f *testing.F) {
+	f.Add(fuzzSignature, "")
+	f.Add(fuzzSignature+","+fuzzSignature, fuzzPubkey+","+fuzzPubkey)
+	f.Add(","+fuzzSignature, fuzzPubkey+","+fuzzPubkey)
+	f.Add(fuzzSignature+","+fuzzSignature, fuzzPubkey)
+	f.Add(",,", ",")
+	f.Fuzz(func(t *testing.T, signatures, pubkeys string) {
+		items, err := ParseSignatures(signatures, pubkeys)
+		if err != nil {
+			return
+		}
+		if len(items) == 0 {
+			t.Fatal("empty result without error")
+		}
+		hasPubkey := len(SplitList(pubkeys, ItemSeparator)) > 0
+		for index, item := range items {
+			if !IsHex(item.Signature, 0) {
+				t.Fatalf("signature[%d] is not hex: %s", index, item.Signature)
+			}
+			if hasPubkey != (item.Pubkey != "") {
+				t.Fatalf("pubkey[%d] is unmatch: %s", index, item.Pubkey)
+			}
+		}
+	})
+}
+
+func FuzzParseScriptElements(f *testing.F) {
+	f.Add("sig:" + strings.Repeat("01", 64) + ",data:" + fuzzPubkey + ",data:")
+	f.Add(strings.Repeat("01", 64))
+	f.Add("sig:" + fuzzSignature)
+	f.Add("data:zz,")
+	f.Fuzz(func(t *testing.T, text string) {
+		elements, err := ParseScriptElements(text)
+		if err != nil {
+			return
+		}
+		if len(elements) == 0 {
+			t.Fatal("empty result without error")
+		}
+		items := []string{}
+		for index, element := range elements {
+			// only the "sig:" element is encoded to der.
+			if element.IsSignature {
+				if !IsHex(element.Data, 64) {
+					t.Fatalf("element[%d] signature is invalid: %s", index, element.Data)
+				}
+				items = append(items, "sig:"+element.Data)
+			} else {
+				items = append(items, "data:"+element.Data)
+			}
+		}
+		again, err := ParseScriptElements(strings.Join(items, ItemSeparator))
+		if err != nil || len(again) != len(elements) {
+			t.Fatalf("round trip failed: %v, %v", again, err)
+		}
+		for index := range again {
+			if again[index] != elements[index] {
+				t.Fatalf("element[%d] round trip unmatch: %+v, %+v", index, again[index], elements[index])
+			}
+		}
+	})
+}
+
+// formatKeyPath formats the parsed child numbers of the path again.
+func formatKeyPath(t *testing.T, path string) string {
+	if path == "" || path == "m" {
+		return path
+	}
+	prefix := ""
+	if strings.HasPrefix(path, "m/") {
+		prefix = "m/"
+	}
+	steps := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	for index, step := range steps {
+		number := strings.TrimRight(step, "hH'")
+		value, err := strconv.ParseUint(number, 10, 32)
+		if err != nil || value >= 0x80000000 {
+			t.Fatalf("path %s child number %s is accepted", path, step)
+		}
+		steps[index] = strconv.FormatUint(value, 10) + step[len(number):]
+	}
+	return prefix + strings.Join(steps, "/")
+}
+
+func FuzzParseKeyPaths(f *testing.F) {
+	f.Add("")
+	f.Add("m")
+	f.Add("m/44h/0h/0h,m/44h/0h/1h")
+	f.Add("0/1/2'")
+	f.Add("m/2147483648")
+	f.Add("m/0hh,m//0")
+	f.Add("m/01,m/+1")
+	f.Fuzz(func(t *testing.T, text string) {
+		paths, err := ParseKeyPaths(text)
+		if err != nil {
+			return
+		}
+		if len(paths) == 0 {
+			t.Fatal("empty result without error")
+		}
+		// the accepted path is canonical, and the joined paths are parsed to the same paths.
+		for index, path := range paths {
+			if formatted := formatKeyPath(t, path); formatted != path {
+				t.Fatalf("path[%d] %s is not canonical: %s", index, path, formatted)
+			}
+		}
+		again, err := ParseKeyPaths(strings.Join(paths, ItemSeparator))
+		if err != nil {
+			t.Fatalf("round trip failed: %v", err)
+		}
+		if strings.Join(again, ItemSeparator) != strings.Join(paths, ItemSeparator) {
+			t.Fatalf("round trip unmatch: %v, %v", paths, again)
+		}
+	})
+}
+
+func FuzzParseKeyValues(f *testing.F) {
+	f.Add("")
+	f.Add("a=1")
+	f.Add("a=1|b==2|c=")
+	f.Add("=1")
+	f.Add("a|b")
+	f.Fuzz(func(t *testing.T, text string) {
+		keyValues, err := ParseKeyValues(text)
+		if err != nil {
+			return
+		}
+		for index, keyValue := range keyValues {
+			if keyValue.Key == "" || strings.Contains(keyValue.Key, "=") {
+				t.Fatalf("key[%d] is invalid: %+v", index, keyValue)
+			}
+		}
+	})
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"strconv"
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/txbuilder"
 )
 
 // ParseDescriptorCmd verify signature.
@@ -17,6 +20,8 @@ type ParseDescriptorCmd struct {
 	descriptor *string
 	nettype    *string
 	childNum   *uint
+	childRange *string
+	toPublic   *bool
 }
 
 // NewParseDescriptorCmd returns a new ParseDescriptorCmd struct.
@@ -41,6 +46,10 @@ func (cmd *ParseDescriptorCmd) Init() {
 	cmd.descriptor = cmd.flagSet.String("descriptor", "", "txin's utxo output descriptor")
 	cmd.nettype = cmd.flagSet.String("network", "mainnet", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.childNum = cmd.flagSet.Uint("childnum", uint(0), "derive child number")
+	cmd.childRange = cmd.flagSet.String("range", "",
+		"derive child number range. format:[end] or [begin,end]")
+	cmd.toPublic = cmd.flagSet.Bool("topublic", false,
+		"convert private keys to public keys")
 }
 
 // GetFlagSet returns the flag set for this command.
@@ -49,55 +58,74 @@ func (cmd *ParseDescriptorCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *ParseDescriptorCmd) Do(ctx context.Context) {
-	networkType := int(cfd.KCfdNetworkMainnet)
-	switch *cmd.nettype {
-	case "mainnet":
-		networkType = int(cfd.KCfdNetworkMainnet)
-	case "testnet":
-		networkType = int(cfd.KCfdNetworkTestnet)
-	case "regtest":
-		networkType = int(cfd.KCfdNetworkRegtest)
-	case "liquidv1":
-		networkType = int(cfd.KCfdNetworkLiquidv1)
-	case "liquidregtest":
-		networkType = int(cfd.KCfdNetworkElementsRegtest)
-	case "elementsregtest":
-		networkType = int(cfd.KCfdNetworkElementsRegtest)
-	default:
-		fmt.Printf("nettype %s is unknown type.", *cmd.nettype)
-		return
-	}
-
-	derivePath := strconv.FormatUint(uint64(*cmd.childNum), 10)
-	descList, keyList, err := cfd.CfdGoParseDescriptor(*cmd.descriptor, networkType, derivePath)
+func (cmd *ParseDescriptorCmd) Do(ctx context.Context) error {
+	networkType, err := txbuilder.ParseNetworkType(*cmd.nettype)
+	if err != nil {
+		return err
+	}
+
+	descriptor, err := txbuilder.ValidateDescriptorChecksum(*cmd.descriptor)
+	if err != nil {
+		return err
+	}
+	if *cmd.toPublic {
+		descriptor, err = txbuilder.ConvertDescriptorToPublic(descriptor, networkType)
+		if err != nil {
+			return err
+		}
+	}
+	checksum, err := txbuilder.GetDescriptorChecksum(descriptor)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("descriptor: %s#%s\n", descriptor, checksum)
+
+	descriptors, err := txbuilder.ExpandMultipathDescriptor(descriptor)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+
+	if *cmd.childRange == "" {
+		if len(descriptors) > 1 {
+			return errors.New("multipath descriptor requires range.")
+		}
+		derivePath := strconv.FormatUint(uint64(*cmd.childNum), 10)
+		descList, keyList, err := cfd.CfdGoParseDescriptor(descriptor, networkType, derivePath)
+		if err != nil {
+			return err
+		}
+		printDescriptorData(descList, keyList)
+		return nil
+	}
+
+	begin, end, err := parseChildRange(*cmd.childRange)
+	if err != nil {
+		return err
+	}
+	for pathIndex, desc := range descriptors {
+		if len(descriptors) > 1 {
+			fmt.Printf("[Path:%d] %s\n", pathIndex, desc)
+		}
+		for childNum := begin; childNum <= end; childNum++ {
+			derivePath := strconv.FormatUint(uint64(childNum), 10)
+			descList, keyList, err := cfd.CfdGoParseDescriptor(desc, networkType, derivePath)
+			if err != nil {
+				return err
+			}
+			printDerivedDescriptorData(childNum, descList, keyList)
+		}
 	}
+	return nil
+}
 
+// printDescriptorData prints the parsed descriptor data.
+func printDescriptorData(descList []cfd.CfdDescriptorData, keyList []cfd.CfdDescriptorKeyData) {
 	for i := 0; i < len(descList); i++ {
 		fmt.Printf("[Depth:%d]\n", descList[i].Depth)
 		fmt.Printf("  - LockingScript: %s\n", descList[i].LockingScript)
 		if descList[i].ScriptType != int(cfd.KCfdDescriptorScriptRaw) {
 			fmt.Printf("  - Address      : %s\n", descList[i].Address)
-			hashType := ""
-			switch descList[i].HashType {
-			case int(cfd.KCfdP2pkh):
-				hashType = "p2pkh"
-			case int(cfd.KCfdP2sh):
-				hashType = "p2sh"
-			case int(cfd.KCfdP2wpkh):
-				hashType = "p2wpkh"
-			case int(cfd.KCfdP2wsh):
-				hashType = "p2wsh"
-			case int(cfd.KCfdP2shP2wpkh):
-				hashType = "p2sh-p2wpkh"
-			case int(cfd.KCfdP2shP2wsh):
-				hashType = "p2sh-p2wsh"
-			default:
-				break
-			}
+			hashType := txbuilder.GetHashTypeString(descList[i].HashType)
 			fmt.Printf("  - Type         : %s\n", hashType)
 		}
 		if (descList[i].ScriptType == int(cfd.KCfdDescriptorScriptSh)) ||
@@ -143,3 +171,63 @@ func (cmd *ParseDescriptorCmd) Do(ctx context.Context) {
 		}
 	}
 }
+
+// printDerivedDescriptorData prints the derived data of a ranged descriptor.
+func printDerivedDescriptorData(childNum uint32, descList []cfd.CfdDescriptorData, keyList []cfd.CfdDescriptorKeyData) {
+	fmt.Printf("[Index:%d]\n", childNum)
+	fmt.Printf("  - LockingScript: %s\n", descList[0].LockingScript)
+	if descList[0].ScriptType != int(cfd.KCfdDescriptorScriptRaw) {
+		fmt.Printf("  - Address      : %s\n", descList[0].Address)
+		fmt.Printf("  - Type         : %s\n", txbuilder.GetHashTypeString(descList[0].HashType))
+	}
+	redeemScript := ""
+	pubkeys := []string{}
+	for _, desc := range descList {
+		if len(desc.RedeemScript) > 0 {
+			redeemScript = desc.RedeemScript
+		}
+		if !desc.IsMultisig && desc.KeyType != int(cfd.KCfdDescriptorKeyNull) {
+			pubkey := txbuilder.GetDescriptorKeyPubkey(desc.KeyType, desc.Pubkey,
+				desc.ExtPubkey, desc.ExtPrivkey)
+			if len(pubkey) > 0 {
+				pubkeys = append(pubkeys, pubkey)
+			}
+		}
+	}
+	for _, key := range keyList {
+		pubkey := txbuilder.GetDescriptorKeyPubkey(key.KeyType, key.Pubkey,
+			key.ExtPubkey, key.ExtPrivkey)
+		if len(pubkey) > 0 {
+			pubkeys = append(pubkeys, pubkey)
+		}
+	}
+	if len(redeemScript) > 0 {
+		fmt.Printf("  - RedeemScript : %s\n", redeemScript)
+	}
+	for i, pubkey := range pubkeys {
+		fmt.Printf("  - pubkey[%d]    : %s\n", i, pubkey)
+	}
+}
+
+// parseChildRange parses the child number range. (format: end or begin,end)
+func parseChildRange(childRange string) (begin, end uint32, err error) {
+	rangeList := strings.Split(childRange, ",")
+	if len(rangeList) > 2 {
+		return 0, 0, errors.New("range format invalid")
+	}
+	values := []uint32{}
+	for _, value := range rangeList {
+		num, err := strconv.ParseUint(strings.TrimSpace(value), 10, 31)
+		if err != nil {
+			return 0, 0, fmt.Errorf("range value invalid. %s", value)
+		}
+		values = append(values, uint32(num))
+	}
+	if len(values) == 1 {
+		return 0, values[0], nil
+	}
+	if values[0] > values[1] {
+		return 0, 0, errors.New("range begin is larger than end")
+	}
+	return values[0], values[1], nil
+}
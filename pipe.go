@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"cfd-cli/cache"
+)
+
+// pipePath the -tx and -file value to read from stdin and write to stdout.
+const pipePath = "-"
+
+// isPipeCommand returns true if -tx or -file of the command is "-".
+func isPipeCommand(flagSet *flag.FlagSet) bool {
+	for _, name := range []string{"tx", "file"} {
+		if f := flagSet.Lookup(name); f != nil && f.Value.String() == pipePath {
+			return true
+		}
+	}
+	return false
+}
+
+// readPipeInput reads the transaction input from stdin.
+// The input is empty if stdin is terminal.
+func readPipeInput() (*cache.TransactionCacheData, cache.InputFormat, error) {
+	if info, err := os.Stdin.Stat(); err == nil && (info.Mode()&os.ModeCharDevice) != 0 {
+		return cache.NewTransactionCacheData(), cache.InputFormatEmpty, nil
+	}
+	input, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, cache.InputFormatEmpty, err
+	}
+	return cache.ParseTransactionInput(string(input))
+}
+
+// doPipeCommand performs the command with the transaction from stdin.
+// -tx - reads the tx hex, the tx data json or the PSBT base64.
+// -file - reads the tx data json, and the updated data is written to stdout instead of the file.
+// If the command updates the transaction, only the new tx (hex or json, same as the input) is printed.
+// Otherwise the command output is printed as is.
+func doPipeCommand(ctx context.Context, name string, args []string) error {
+	data, format, err := readPipeInput()
+	if err != nil {
+		return err
+	}
+	flagSet := commandMap[name].GetFlagSet()
+	txFlag := flagSet.Lookup("tx")
+	fileFlag := flagSet.Lookup("file")
+	isFilePipe := fileFlag != nil && fileFlag.Value.String() == pipePath
+
+	if fileFlag == nil || (!isFilePipe && fileFlag.Value.String() != "") {
+		// the data file is not available. only the tx hex is passed.
+		if txFlag == nil {
+			return errors.New("-file - is not supported")
+		}
+		output, err := dispatchCommand(ctx, name, func(flagSet *flag.FlagSet) error {
+			if err := flagSet.Parse(args); err != nil {
+				return err
+			}
+			return flagSet.Set("tx", data.Hex)
+		})
+		fmt.Print(output)
+		return err
+	}
+
+	workFile, err := ioutil.TempFile("", "cfd-cli-pipe-*.json")
+	if err != nil {
+		return err
+	}
+	workFile.Close()
+	defer os.Remove(workFile.Name())
+	if _, err = cache.WriteTransactionCache(workFile.Name(), data); err != nil {
+		return err
+	}
+	isTxPipe := txFlag != nil && txFlag.Value.String() == pipePath
+	output, err := dispatchCommand(ctx, name, func(flagSet *flag.FlagSet) error {
+		if err := flagSet.Parse(args); err != nil {
+			return err
+		}
+		if isTxPipe {
+			flagSet.Set("tx", "")
+		}
+		return flagSet.Set("file", workFile.Name())
+	})
+	if err != nil {
+		fmt.Print(output)
+		return err
+	}
+	newData, err := cache.ReadTransactionCache(workFile.Name())
+	if err != nil {
+		fmt.Print(output)
+		return nil
+	}
+	before, _ := json.Marshal(data)
+	after, _ := json.Marshal(newData)
+	switch {
+	case string(before) == string(after):
+		fmt.Print(output)
+	case isFilePipe || format == cache.InputFormatJSON:
+		jsonData, err := json.MarshalIndent(newData, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsonData))
+	default:
+		fmt.Println(newData.Hex)
+	}
+	return nil
+}
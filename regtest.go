@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"cfd-cli/cache"
+	"cfd-cli/params"
+	"cfd-cli/regtest"
+)
+
+// defaultRegtestNodePath the default regtest node state file path.
+const defaultRegtestNodePath = "regtest.json"
+
+// RegtestInitCmd initialize the regtest node state.
+type RegtestInitCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	nodePath   *string
+	isElements *bool
+	isForce    *bool
+}
+
+// RegtestFundCmd create the confirmed utxo of the regtest node.
+type RegtestFundCmd struct {
+	cmd           string
+	flagSet       *flag.FlagSet
+	nodePath      *string
+	descriptor    *string
+	address       *string
+	lockingScript *string
+	amount        *int64
+	asset         *string
+}
+
+// RegtestSendCmd validate the transaction and add it to the mempool of the regtest node.
+type RegtestSendCmd struct {
+	cmd          string
+	flagSet      *flag.FlagSet
+	nodePath     *string
+	tx           *string
+	txFilePath   *string
+	blindingKeys *string
+}
+
+// RegtestMineCmd mine the blocks of the regtest node.
+type RegtestMineCmd struct {
+	cmd      string
+	flagSet  *flag.FlagSet
+	nodePath *string
+	blocks   *uint
+}
+
+// RegtestListUnspentCmd list the utxos of the regtest node.
+type RegtestListUnspentCmd struct {
+	cmd           string
+	flagSet       *flag.FlagSet
+	nodePath      *string
+	descriptor    *string
+	lockingScript *string
+}
+
+// NewRegtestInitCmd returns a new RegtestInitCmd struct.
+func NewRegtestInitCmd() *RegtestInitCmd {
+	return &RegtestInitCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RegtestInitCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RegtestInitCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RegtestInitCmd) Init() {
+	cmd.cmd = "regtestinit"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.nodePath = cmd.flagSet.String("node", defaultRegtestNodePath, "regtest node state file path")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.isForce = cmd.flagSet.Bool("force", false, "reset the existing node state")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RegtestInitCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RegtestInitCmd) Do(ctx context.Context) error {
+	if _, err := os.Stat(*cmd.nodePath); err == nil && !*cmd.isForce {
+		return fmt.Errorf("regtest node %s already exists. use -force to reset", *cmd.nodePath)
+	}
+	node := regtest.NewNode(*cmd.isElements)
+	if err := node.Save(*cmd.nodePath); err != nil {
+		return err
+	}
+	fmt.Printf("node: %s\n", *cmd.nodePath)
+	fmt.Printf("elements: %t\n", node.IsElements)
+	fmt.Printf("height: %d\n", node.Height)
+	return nil
+}
+
+// NewRegtestFundCmd returns a new RegtestFundCmd struct.
+func NewRegtestFundCmd() *RegtestFundCmd {
+	return &RegtestFundCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RegtestFundCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RegtestFundCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RegtestFundCmd) Init() {
+	cmd.cmd = "regtestfund"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.nodePath = cmd.flagSet.String("node", defaultRegtestNodePath, "regtest node state file path")
+	cmd.descriptor = cmd.flagSet.String("descriptor", "", "output descriptor (registered to the node)")
+	cmd.address = cmd.flagSet.String("address", "", "address (not exist descriptor)")
+	cmd.lockingScript = cmd.flagSet.String("lockingscript", "", "locking script (not exist descriptor and address)")
+	cmd.amount = cmd.flagSet.Int64("amount", int64(0), "utxo amount")
+	cmd.asset = cmd.flagSet.String("asset", "", "utxo asset (elements mode only)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RegtestFundCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RegtestFundCmd) Do(ctx context.Context) error {
+	node, err := regtest.LoadNode(*cmd.nodePath)
+	if err != nil {
+		return err
+	}
+	lockingScript := *cmd.lockingScript
+	switch {
+	case *cmd.descriptor != "":
+		lockingScript, err = node.AddDescriptor(*cmd.descriptor)
+	case *cmd.address != "":
+		lockingScript, err = node.GetAddressLockingScript(*cmd.address)
+	case lockingScript == "":
+		return errors.New("descriptor, address or lockingscript is required")
+	}
+	if err != nil {
+		return err
+	}
+
+	utxo, err := node.Fund(lockingScript, *cmd.amount, *cmd.asset)
+	if err != nil {
+		return err
+	}
+	if err = node.Save(*cmd.nodePath); err != nil {
+		return err
+	}
+	fmt.Printf("txid: %s\n", utxo.Txid)
+	fmt.Printf("vout: %d\n", utxo.Vout)
+	fmt.Printf("amount: %d\n", utxo.Amount)
+	fmt.Printf("height: %d\n", node.Height)
+	return nil
+}
+
+// NewRegtestSendCmd returns a new RegtestSendCmd struct.
+func NewRegtestSendCmd() *RegtestSendCmd {
+	return &RegtestSendCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RegtestSendCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RegtestSendCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RegtestSendCmd) Init() {
+	cmd.cmd = "regtestsend"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.nodePath = cmd.flagSet.String("node", defaultRegtestNodePath, "regtest node state file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
+	cmd.blindingKeys = cmd.flagSet.String("blindingkeys", "",
+		"blinding keys of the blinded outputs (vout,blindingKey|vout2,blindingKey2|...) (elements mode only)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RegtestSendCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RegtestSendCmd) Do(ctx context.Context) error {
+	var err error
+	data := cache.NewTransactionCacheData()
+	tx := *cmd.tx
+	if *cmd.tx == "" && *cmd.txFilePath != "" {
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
+		if err != nil {
+			return err
+		}
+		tx = data.Hex
+	}
+	if tx == "" {
+		return errors.New("tx is required")
+	}
+	outputKeys, err := params.ParseOutputBlindingKeys(*cmd.blindingKeys)
+	if err != nil {
+		return err
+	}
+	blindingKeys := map[uint32]string{}
+	for _, key := range outputKeys {
+		blindingKeys[key.Vout] = key.BlindingKey
+	}
+
+	node, err := regtest.LoadNode(*cmd.nodePath)
+	if err != nil {
+		return err
+	}
+	// the descriptors of the inputs are used for the change outputs.
+	for _, utxo := range data.Utxos {
+		if utxo.Descriptor != "" {
+			node.AddDescriptor(utxo.Descriptor)
+		}
+	}
+	result, err := node.SendTransaction(tx, blindingKeys)
+	if err != nil {
+		return fmt.Errorf("send: fail. reason: %s", err.Error())
+	}
+	if err = node.Save(*cmd.nodePath); err != nil {
+		return err
+	}
+
+	fmt.Printf("txid: %s\n", result.Txid)
+	fmt.Printf("fee: %d\n", result.Fee)
+	fmt.Printf("vsize: %d\n", result.Vsize)
+	printRegtestUtxos(node, result.Utxos)
+	fmt.Println("send: success.")
+	return nil
+}
+
+// NewRegtestMineCmd returns a new RegtestMineCmd struct.
+func NewRegtestMineCmd() *RegtestMineCmd {
+	return &RegtestMineCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RegtestMineCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RegtestMineCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RegtestMineCmd) Init() {
+	cmd.cmd = "regtestmine"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.nodePath = cmd.flagSet.String("node", defaultRegtestNodePath, "regtest node state file path")
+	cmd.blocks = cmd.flagSet.Uint("blocks", uint(1), "number of blocks (the mempool txs are confirmed in the first block)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RegtestMineCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RegtestMineCmd) Do(ctx context.Context) error {
+	node, err := regtest.LoadNode(*cmd.nodePath)
+	if err != nil {
+		return err
+	}
+	confirmed := node.Mine(uint32(*cmd.blocks))
+	if err = node.Save(*cmd.nodePath); err != nil {
+		return err
+	}
+	fmt.Printf("height: %d\n", node.Height)
+	fmt.Printf("confirmed: %d\n", len(confirmed))
+	for _, txid := range confirmed {
+		fmt.Printf("  %s\n", txid)
+	}
+	return nil
+}
+
+// NewRegtestListUnspentCmd returns a new RegtestListUnspentCmd struct.
+func NewRegtestListUnspentCmd() *RegtestListUnspentCmd {
+	return &RegtestListUnspentCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RegtestListUnspentCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RegtestListUnspentCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RegtestListUnspentCmd) Init() {
+	cmd.cmd = "regtestlistunspent"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.nodePath = cmd.flagSet.String("node", defaultRegtestNodePath, "regtest node state file path")
+	cmd.descriptor = cmd.flagSet.String("descriptor", "", "output descriptor (registered to the node)")
+	cmd.lockingScript = cmd.flagSet.String("lockingscript", "", "locking script (not exist descriptor)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RegtestListUnspentCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RegtestListUnspentCmd) Do(ctx context.Context) error {
+	node, err := regtest.LoadNode(*cmd.nodePath)
+	if err != nil {
+		return err
+	}
+	lockingScript := *cmd.lockingScript
+	if *cmd.descriptor != "" {
+		if lockingScript, err = node.AddDescriptor(*cmd.descriptor); err != nil {
+			return err
+		}
+		if err = node.Save(*cmd.nodePath); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("height: %d\n", node.Height)
+	printRegtestUtxos(node, node.ListUtxos(lockingScript))
+	return nil
+}
+
+// printRegtestUtxos prints the utxo table.
+func printRegtestUtxos(node *regtest.Node, utxos []regtest.Utxo) {
+	if node.IsElements {
+		fmt.Printf("%-70s %-16s %-5s %-64s %s\n", "outpoint", "amount", "conf", "asset", "descriptor")
+	} else {
+		fmt.Printf("%-70s %-16s %-5s %s\n", "outpoint", "amount", "conf", "descriptor")
+	}
+	for index := range utxos {
+		utxo := &utxos[index]
+		outpoint := fmt.Sprintf("%s,%d", utxo.Txid, utxo.Vout)
+		descriptor := utxo.Descriptor
+		if descriptor == "" {
+			descriptor = "raw(" + utxo.LockingScript + ")"
+		}
+		if node.IsElements {
+			fmt.Printf("%-70s %-16d %-5d %-64s %s\n", outpoint, utxo.Amount,
+				node.GetConfirmations(utxo), utxo.Asset, descriptor)
+		} else {
+			fmt.Printf("%-70s %-16d %-5d %s\n", outpoint, utxo.Amount, node.GetConfirmations(utxo), descriptor)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package regtest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/txbuilder"
+)
+
+// curveOrder the order of secp256k1. (the blinding factors are the scalars)
+var curveOrder, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+// valueOpening the unblinded asset and amount of the input or output.
+// The blinders are empty if the value is explicit.
+type valueOpening struct {
+	asset         string
+	amount        int64
+	assetBlinder  string
+	amountBlinder string
+}
+
+// getOutputOpening returns the unblinded value of the output.
+// The blinded output is unblinded with the blinding key, and the commitments are checked.
+func getOutputOpening(tx *txbuilder.Transaction, txHex string, index int, blindingKeys map[uint32]string) (valueOpening, error) {
+	txout := tx.TxOut[index]
+	opening := valueOpening{}
+	if !tx.IsElements {
+		opening.amount = txout.Amount
+	} else {
+		isExplicitAsset := len(txout.Asset) == 33 && txout.Asset[0] == 1
+		isExplicitValue := len(txout.Value) == 9 && txout.Value[0] == 1
+		switch {
+		case isExplicitAsset && isExplicitValue:
+			opening.asset = txout.GetAsset()
+			opening.amount = txbuilder.GetConfidentialValueAmount(txout.Value)
+		case isExplicitAsset || isExplicitValue:
+			return opening, fmt.Errorf("txout[%d] partially blinded output is not supported", index)
+		case len(txout.Asset) != 33 || len(txout.Value) != 33:
+			return opening, fmt.Errorf("txout[%d] asset or value is invalid", index)
+		default:
+			var err error
+			if opening, err = unblindOutput(txout, txHex, index, blindingKeys); err != nil {
+				return opening, err
+			}
+		}
+	}
+	if opening.amount < 0 || opening.amount > maxMoney {
+		return opening, fmt.Errorf("txout[%d] amount %d is out of range", index, opening.amount)
+	}
+	return opening, nil
+}
+
+// unblindOutput unblinds the output, and checks the commitments are made from the unblinded value.
+func unblindOutput(txout *txbuilder.TxOut, txHex string, index int, blindingKeys map[uint32]string) (valueOpening, error) {
+	opening := valueOpening{}
+	blindingKey, ok := blindingKeys[uint32(index)]
+	if !ok {
+		return opening, fmt.Errorf("txout[%d] is blinded. the blinding key is required", index)
+	}
+	var err error
+	opening.asset, opening.amount, opening.assetBlinder, opening.amountBlinder, err = cfd.CfdGoUnblindTxOut(
+		txHex, uint32(index), blindingKey)
+	if err != nil {
+		return opening, fmt.Errorf("txout[%d] unblind failed. %s", index, err.Error())
+	}
+	assetCommitment, err := cfd.CfdGoGetAssetCommitment(opening.asset, opening.assetBlinder)
+	if err != nil {
+		return opening, err
+	}
+	amountCommitment, err := cfd.CfdGoGetAmountCommitment(opening.amount, assetCommitment, opening.amountBlinder)
+	if err != nil {
+		return opening, err
+	}
+	if assetCommitment != hex.EncodeToString(txout.Asset) || amountCommitment != hex.EncodeToString(txout.Value) {
+		return opening, fmt.Errorf("txout[%d] commitment is unmatch the unblinded value", index)
+	}
+	return opening, nil
+}
+
+// checkBalance checks the input and output values, and returns the fee.
+// For elements, the amounts of each asset and the blinding factors must balance.
+// (amount * assetBlinder + amountBlinder of the inputs and the outputs)
+func checkBalance(tx *txbuilder.Transaction, inputs, outputs []valueOpening) (int64, error) {
+	inputAmounts := map[string]int64{}
+	outputAmounts := map[string]int64{}
+	for index, input := range inputs {
+		inputAmounts[input.asset] += input.amount
+		if input.amount < 0 || input.amount > maxMoney || inputAmounts[input.asset] > maxMoney {
+			return 0, fmt.Errorf("txin[%d] amount is out of range", index)
+		}
+	}
+	for index, output := range outputs {
+		outputAmounts[output.asset] += output.amount
+		if outputAmounts[output.asset] > maxMoney {
+			return 0, fmt.Errorf("txout[%d] amount is out of range", index)
+		}
+	}
+
+	if !tx.IsElements {
+		if outputAmounts[""] > inputAmounts[""] {
+			return 0, fmt.Errorf("output amount %d exceeds input amount %d", outputAmounts[""], inputAmounts[""])
+		}
+		return inputAmounts[""] - outputAmounts[""], nil
+	}
+
+	assets := []string{}
+	for asset := range inputAmounts {
+		assets = append(assets, asset)
+	}
+	for asset := range outputAmounts {
+		if _, ok := inputAmounts[asset]; !ok {
+			assets = append(assets, asset)
+		}
+	}
+	sort.Strings(assets)
+	for _, asset := range assets {
+		if inputAmounts[asset] != outputAmounts[asset] {
+			return 0, fmt.Errorf("asset %s is unbalanced. input amount %d, output amount %d",
+				asset, inputAmounts[asset], outputAmounts[asset])
+		}
+	}
+
+	total := new(big.Int)
+	for index, input := range inputs {
+		scalar, err := getBlindingScalar(input)
+		if err != nil {
+			return 0, fmt.Errorf("txin[%d] %s", index, err.Error())
+		}
+		total.Add(total, scalar)
+	}
+	for index, output := range outputs {
+		scalar, err := getBlindingScalar(output)
+		if err != nil {
+			return 0, fmt.Errorf("txout[%d] %s", index, err.Error())
+		}
+		total.Sub(total, scalar)
+	}
+	if total.Mod(total, curveOrder).Sign() != 0 {
+		return 0, errors.New("blinding factors are unbalanced")
+	}
+
+	var fee int64
+	for index, txout := range tx.TxOut {
+		if txout.IsFee() {
+			fee += outputs[index].amount
+		}
+	}
+	return fee, nil
+}
+
+// getBlindingScalar returns amount * assetBlinder + amountBlinder.
+func getBlindingScalar(opening valueOpening) (*big.Int, error) {
+	assetBlinder, err := parseBlinder(opening.assetBlinder)
+	if err != nil {
+		return nil, fmt.Errorf("asset blinder is invalid. %s", err.Error())
+	}
+	amountBlinder, err := parseBlinder(opening.amountBlinder)
+	if err != nil {
+		return nil, fmt.Errorf("amount blinder is invalid. %s", err.Error())
+	}
+	scalar := new(big.Int).Mul(big.NewInt(opening.amount), assetBlinder)
+	return scalar.Add(scalar, amountBlinder), nil
+}
+
+// parseBlinder parses the blinder hex. The hex is reversed byte order. (same as the txid)
+func parseBlinder(blinder string) (*big.Int, error) {
+	if blinder == "" {
+		return new(big.Int), nil
+	}
+	bytes, err := hex.DecodeString(blinder)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) != 32 {
+		return nil, fmt.Errorf("size %d is invalid", len(bytes))
+	}
+	return new(big.Int).SetBytes(reverseBytes(bytes)), nil
+}
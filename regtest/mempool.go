@@ -0,0 +1,190 @@
+package regtest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+// SendResult the result of the transaction accepted to the mempool.
+type SendResult struct {
+	Txid  string
+	Fee   int64
+	Vsize int
+	// Utxos the new unspent outputs. (fee and OP_RETURN outputs are excluded)
+	Utxos []Utxo
+}
+
+// SendTransaction validates the transaction and adds it to the mempool.
+// The inputs must be unspent, the timelocks must be satisfied at the next block,
+// and all input scripts (signatures) are verified.
+// For elements, the blinded output is unblinded with the blinding key of the vout,
+// and the amounts and the blinding factors of the commitments must balance.
+// (the issuance and the pegin are not supported. the surjection proofs are not verified)
+func (node *Node) SendTransaction(txHex string, blindingKeys map[uint32]string) (*SendResult, error) {
+	txHex = strings.ToLower(strings.TrimSpace(txHex))
+	tx, err := txbuilder.DecodeTransaction(txHex, node.IsElements)
+	if err != nil {
+		return nil, err
+	}
+	txid := tx.Txid()
+	switch {
+	case len(tx.TxIn) == 0:
+		return nil, errors.New("tx has no input")
+	case len(tx.TxOut) == 0:
+		return nil, errors.New("tx has no output")
+	case node.Transactions[txid] != nil:
+		return nil, fmt.Errorf("tx %s is already accepted", txid)
+	}
+
+	utxos, err := node.getInputUtxos(tx)
+	if err != nil {
+		return nil, err
+	}
+	if err = node.checkTimelock(tx, utxos); err != nil {
+		return nil, err
+	}
+	for index, txin := range tx.TxIn {
+		checker := txbuilder.NewTxScriptChecker(tx, index, utxos[index].Amount,
+			utxos[index].AmountCommitment, node.GetNetworkType())
+		lockingScript, _ := hex.DecodeString(utxos[index].LockingScript)
+		interp := txbuilder.NewScriptInterpreter(checker)
+		if err = interp.VerifyInputScript(txin.ScriptSig, txin.Witness, lockingScript); err != nil {
+			return nil, fmt.Errorf("txin[%d] %s,%d script verify failed. %s", index, txin.Txid, txin.Vout, err.Error())
+		}
+	}
+
+	inputs := make([]valueOpening, len(utxos))
+	for index, utxo := range utxos {
+		inputs[index] = valueOpening{
+			asset:         utxo.Asset,
+			amount:        utxo.Amount,
+			assetBlinder:  utxo.AssetBlinder,
+			amountBlinder: utxo.AmountBlinder,
+		}
+	}
+	outputs := make([]valueOpening, len(tx.TxOut))
+	for index := range tx.TxOut {
+		if outputs[index], err = getOutputOpening(tx, txHex, index, blindingKeys); err != nil {
+			return nil, err
+		}
+	}
+	fee, err := checkBalance(tx, inputs, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SendResult{Txid: txid, Fee: fee, Vsize: tx.GetVsize(), Utxos: []Utxo{}}
+	for index, txout := range tx.TxOut {
+		if (node.IsElements && txout.IsFee()) || txbuilder.ClassifyScript(txout.LockingScript).Type == "nulldata" {
+			continue
+		}
+		lockingScript := hex.EncodeToString(txout.LockingScript)
+		utxo := Utxo{
+			UtxoData: cache.UtxoData{
+				Txid:          txid,
+				Vout:          uint32(index),
+				Amount:        outputs[index].amount,
+				Asset:         outputs[index].asset,
+				AssetBlinder:  outputs[index].assetBlinder,
+				AmountBlinder: outputs[index].amountBlinder,
+				Descriptor:    node.Descriptors[lockingScript],
+			},
+			LockingScript: lockingScript,
+		}
+		if node.IsElements && txout.IsBlinded() {
+			utxo.AssetCommitment = hex.EncodeToString(txout.Asset)
+			utxo.AmountCommitment = hex.EncodeToString(txout.Value)
+		}
+		result.Utxos = append(result.Utxos, utxo)
+	}
+
+	// update the utxo set.
+	spent := map[string]bool{}
+	for _, txin := range tx.TxIn {
+		key := getOutpointKey(txin.Txid, txin.Vout)
+		spent[key] = true
+		node.Spent[key] = txid
+	}
+	newUtxos := []Utxo{}
+	for _, utxo := range node.Utxos {
+		if !spent[getOutpointKey(utxo.Txid, utxo.Vout)] {
+			newUtxos = append(newUtxos, utxo)
+		}
+	}
+	node.Utxos = append(newUtxos, result.Utxos...)
+	node.Transactions[txid] = &TxRecord{Hex: txHex, Fee: fee}
+	node.Mempool = append(node.Mempool, txid)
+	return result, nil
+}
+
+// getInputUtxos returns the unspent outputs of the inputs.
+func (node *Node) getInputUtxos(tx *txbuilder.Transaction) ([]*Utxo, error) {
+	utxos := make([]*Utxo, len(tx.TxIn))
+	outpoints := map[string]bool{}
+	for index, txin := range tx.TxIn {
+		key := getOutpointKey(txin.Txid, txin.Vout)
+		switch {
+		case txin.IsPegin || txin.Issuance != nil:
+			return nil, fmt.Errorf("txin[%d] issuance and pegin are not supported", index)
+		case outpoints[key]:
+			return nil, fmt.Errorf("txin[%d] %s is duplicated", index, key)
+		}
+		outpoints[key] = true
+		utxo, err := node.GetUtxo(txin.Txid, txin.Vout)
+		if err != nil {
+			return nil, fmt.Errorf("txin[%d] %s", index, err.Error())
+		}
+		utxos[index] = utxo
+	}
+	return utxos, nil
+}
+
+// checkTimelock checks the locktime (BIP65, BIP113) and the relative timelocks (BIP68) at the next block.
+// The mempool utxo is treated as confirmed at the next block.
+func (node *Node) checkTimelock(tx *txbuilder.Transaction, utxos []*Utxo) error {
+	nextHeight := node.Height + 1
+	medianTime := GetMedianTime(node.Height)
+	isFinal := true
+	for _, txin := range tx.TxIn {
+		if txin.Sequence != txbuilder.SequenceFinal {
+			isFinal = false
+		}
+	}
+	switch {
+	case tx.Locktime == 0 || isFinal:
+	case tx.Locktime < txbuilder.LocktimeThreshold && tx.Locktime >= nextHeight:
+		return fmt.Errorf("tx is non-final. locktime %s, next block height %d",
+			txbuilder.DescribeLocktime(tx.Locktime), nextHeight)
+	case tx.Locktime >= txbuilder.LocktimeThreshold && int64(tx.Locktime) >= medianTime:
+		return fmt.Errorf("tx is non-final. locktime %s, median time past %d",
+			txbuilder.DescribeLocktime(tx.Locktime), medianTime)
+	}
+
+	if tx.Version < 2 {
+		return nil
+	}
+	for index, txin := range tx.TxIn {
+		blocks, seconds, isEnabled := txbuilder.GetRelativeTimelock(txin.Sequence)
+		if !isEnabled {
+			continue
+		}
+		height := utxos[index].Height
+		if height == 0 {
+			height = nextHeight
+		}
+		if nextHeight-height < blocks {
+			return fmt.Errorf("txin[%d] is non-final. %d blocks are required, but %d confirmations",
+				index, blocks, nextHeight-height)
+		}
+		if elapsed := medianTime - GetMedianTime(height-1); elapsed < int64(seconds) {
+			return fmt.Errorf("txin[%d] is non-final. %d seconds are required, but %d seconds elapsed",
+				index, seconds, elapsed)
+		}
+	}
+	return nil
+}
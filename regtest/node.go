@@ -0,0 +1,266 @@
+// Package regtest simulates the utxo set and the mempool of the regtest node offline.
+// The node state is saved as the json file, and the transactions are validated without the node.
+package regtest
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+const (
+	// GenesisTime the block time of the genesis block. The blocks are mined at 10 minutes interval.
+	GenesisTime = int64(1296688602)
+	// blockInterval the block time interval in seconds.
+	blockInterval = int64(600)
+	// maxMoney the maximum amount of the output.
+	maxMoney = int64(21000000) * 100000000
+)
+
+// Utxo the unspent output of the node.
+type Utxo struct {
+	cache.UtxoData
+	LockingScript string `json:"lockingscript"`
+	// Height the block height of the confirmation. (0: mempool)
+	Height uint32 `json:"height"`
+}
+
+// TxRecord the transaction accepted by the node.
+type TxRecord struct {
+	Hex string `json:"hex"`
+	Fee int64  `json:"fee"`
+	// Height the block height of the confirmation. (0: mempool)
+	Height uint32 `json:"height"`
+}
+
+// Node the regtest node state.
+type Node struct {
+	IsElements   bool                 `json:"elements"`
+	Height       uint32               `json:"height"`
+	Utxos        []Utxo               `json:"utxos"`
+	Mempool      []string             `json:"mempool"`
+	Transactions map[string]*TxRecord `json:"transactions"`
+	// Spent the spending txid of the outpoint. (key: txid,vout)
+	Spent map[string]string `json:"spent"`
+	// Descriptors the descriptor of the locking script. (key: locking script hex)
+	Descriptors map[string]string `json:"descriptors"`
+	FundCount   uint32            `json:"fundcount"`
+}
+
+// NewNode returns a new Node struct with the genesis block only.
+func NewNode(isElements bool) *Node {
+	return &Node{
+		IsElements:   isElements,
+		Utxos:        []Utxo{},
+		Mempool:      []string{},
+		Transactions: map[string]*TxRecord{},
+		Spent:        map[string]string{},
+		Descriptors:  map[string]string{},
+	}
+}
+
+// LoadNode reads the node state file.
+func LoadNode(path string) (*Node, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("regtest node %s not found. run regtestinit first", path)
+	} else if err != nil {
+		return nil, err
+	}
+	node := NewNode(false)
+	if err = json.Unmarshal(bytes, node); err != nil {
+		return nil, fmt.Errorf("regtest node %s is invalid. %s", path, err.Error())
+	}
+	return node, nil
+}
+
+// Save writes the node state file.
+func (node *Node) Save(path string) error {
+	jsonData, err := json.MarshalIndent(node, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(jsonData, '\n'), 0644)
+}
+
+// GetNetworkType returns the network type of the node.
+func (node *Node) GetNetworkType() int {
+	if node.IsElements {
+		return int(cfd.KCfdNetworkElementsRegtest)
+	}
+	return int(cfd.KCfdNetworkRegtest)
+}
+
+// GetMedianTime returns the median time past of the block. (BIP113)
+func GetMedianTime(height uint32) int64 {
+	// the median of the last 11 blocks is 5 blocks before.
+	if height < 5 {
+		return GenesisTime
+	}
+	return GenesisTime + int64(height-5)*blockInterval
+}
+
+// AddDescriptor registers the descriptor, and returns the locking script.
+// The new utxo of the locking script has the descriptor.
+func (node *Node) AddDescriptor(descriptor string) (lockingScript string, err error) {
+	descList, _, err := cfd.CfdGoParseDescriptor(descriptor, node.GetNetworkType(), "")
+	if err != nil {
+		return "", err
+	}
+	if len(descList) == 0 || descList[0].LockingScript == "" {
+		return "", fmt.Errorf("descriptor %s has no locking script", descriptor)
+	}
+	lockingScript = strings.ToLower(descList[0].LockingScript)
+	node.Descriptors[lockingScript] = descriptor
+	for index := range node.Utxos {
+		if node.Utxos[index].LockingScript == lockingScript && node.Utxos[index].Descriptor == "" {
+			node.Utxos[index].Descriptor = descriptor
+		}
+	}
+	return lockingScript, nil
+}
+
+// GetAddressLockingScript returns the locking script of the address.
+func (node *Node) GetAddressLockingScript(address string) (string, error) {
+	descList, _, err := cfd.CfdGoParseDescriptor("addr("+address+")", node.GetNetworkType(), "")
+	if err != nil {
+		return "", err
+	}
+	if len(descList) == 0 || descList[0].LockingScript == "" {
+		return "", fmt.Errorf("address %s is invalid", address)
+	}
+	return strings.ToLower(descList[0].LockingScript), nil
+}
+
+// Fund creates the utxo of the locking script with the new funding transaction, and mines the block.
+// The asset is required for elements. (explicit value only)
+func (node *Node) Fund(lockingScript string, amount int64, asset string) (*Utxo, error) {
+	script, err := hex.DecodeString(lockingScript)
+	switch {
+	case err != nil || len(script) == 0:
+		return nil, errors.New("locking script is invalid")
+	case amount <= 0 || amount > maxMoney:
+		return nil, fmt.Errorf("amount %d is out of range", amount)
+	case node.IsElements && (len(asset) != 64 || !isHex(asset)):
+		return nil, errors.New("asset is required for elements")
+	case !node.IsElements && asset != "":
+		return nil, errors.New("asset is elements only")
+	}
+
+	// the coinbase-like transaction. the scriptsig makes the txid unique.
+	node.FundCount++
+	tx := &txbuilder.Transaction{
+		Version:    2,
+		IsElements: node.IsElements,
+		TxIn: []*txbuilder.TxIn{{
+			Txid:      strings.Repeat("0", 64),
+			Vout:      0xffffffff,
+			ScriptSig: txbuilder.NewScriptBuilder().AddInt(int64(node.Height + 1)).AddInt(int64(node.FundCount)).Bytes(),
+			Sequence:  txbuilder.SequenceFinal,
+		}},
+		TxOut: []*txbuilder.TxOut{{Amount: amount, LockingScript: script}},
+	}
+	if node.IsElements {
+		assetBytes, _ := hex.DecodeString(asset)
+		tx.TxOut[0].Asset = append([]byte{1}, reverseBytes(assetBytes)...)
+		tx.TxOut[0].Value = txbuilder.NewConfidentialValue(amount)
+	}
+
+	txid := tx.Txid()
+	node.Transactions[txid] = &TxRecord{Hex: tx.Hex()}
+	utxo := Utxo{
+		UtxoData: cache.UtxoData{
+			Txid:       txid,
+			Vout:       0,
+			Amount:     amount,
+			Asset:      strings.ToLower(asset),
+			Descriptor: node.Descriptors[strings.ToLower(lockingScript)],
+		},
+		LockingScript: strings.ToLower(lockingScript),
+	}
+	node.Utxos = append(node.Utxos, utxo)
+	node.Mempool = append(node.Mempool, txid)
+	node.Mine(1)
+	return node.GetUtxo(txid, 0)
+}
+
+// Mine mines the blocks, and returns the txids confirmed in the first block.
+func (node *Node) Mine(blocks uint32) []string {
+	if blocks == 0 {
+		return []string{}
+	}
+	height := node.Height + 1
+	confirmed := node.Mempool
+	for _, txid := range confirmed {
+		node.Transactions[txid].Height = height
+	}
+	for index := range node.Utxos {
+		if node.Utxos[index].Height == 0 {
+			node.Utxos[index].Height = height
+		}
+	}
+	node.Mempool = []string{}
+	node.Height += blocks
+	return confirmed
+}
+
+// GetUtxo returns the unspent output.
+func (node *Node) GetUtxo(txid string, vout uint32) (*Utxo, error) {
+	for index := range node.Utxos {
+		if node.Utxos[index].Txid == txid && node.Utxos[index].Vout == vout {
+			return &node.Utxos[index], nil
+		}
+	}
+	if spentTxid, ok := node.Spent[getOutpointKey(txid, vout)]; ok {
+		return nil, fmt.Errorf("%s,%d is already spent by %s", txid, vout, spentTxid)
+	}
+	return nil, fmt.Errorf("%s,%d is not found", txid, vout)
+}
+
+// ListUtxos returns the unspent outputs of the locking script. (empty: all)
+func (node *Node) ListUtxos(lockingScript string) []Utxo {
+	result := []Utxo{}
+	for _, utxo := range node.Utxos {
+		if lockingScript == "" || utxo.LockingScript == strings.ToLower(lockingScript) {
+			result = append(result, utxo)
+		}
+	}
+	return result
+}
+
+// GetConfirmations returns the confirmations of the utxo. (0: mempool)
+func (node *Node) GetConfirmations(utxo *Utxo) uint32 {
+	if utxo.Height == 0 {
+		return 0
+	}
+	return node.Height - utxo.Height + 1
+}
+
+// getOutpointKey returns the key of the outpoint.
+func getOutpointKey(txid string, vout uint32) string {
+	return fmt.Sprintf("%s,%d", txid, vout)
+}
+
+// isHex returns true if the text is the even length hex.
+func isHex(text string) bool {
+	_, err := hex.DecodeString(text)
+	return err == nil
+}
+
+// reverseBytes returns the reversed copy of the data.
+func reverseBytes(data []byte) []byte {
+	result := make([]byte, len(data))
+	for index, value := range data {
+		result[len(data)-1-index] = value
+	}
+	return result
+}
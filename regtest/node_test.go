@@ -0,0 +1,247 @@
+package regtest
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+const (
+	// opTrueScript the anyone-can-spend locking script. (the signature is not required)
+	opTrueScript = "51"
+	testAsset    = "5ac9f65c0efcc4775e0baec4ec03abdde22473cd3cf33c0419ca290e0751b225"
+	// p2wpkhScript the p2wpkh locking script of p2wpkhPubkey. (the key of the BIP143 native P2WPKH example)
+	p2wpkhScript = "00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1"
+	p2wpkhPubkey = "025476c2e83188368da1ff3e292e7acafcdb3566bb0ad253f62fc70f07aeee6357"
+)
+
+// newSpendTx returns the transaction spending the outpoints of OP_TRUE to the amounts.
+func newSpendTx(isElements bool, inputs []*Utxo, amounts ...int64) *txbuilder.Transaction {
+	tx := &txbuilder.Transaction{Version: 2, IsElements: isElements}
+	for _, utxo := range inputs {
+		tx.TxIn = append(tx.TxIn, &txbuilder.TxIn{Txid: utxo.Txid, Vout: utxo.Vout, Sequence: txbuilder.SequenceFinal})
+	}
+	script, _ := hex.DecodeString(opTrueScript)
+	for _, amount := range amounts {
+		txout := &txbuilder.TxOut{Amount: amount, LockingScript: script}
+		if isElements {
+			assetBytes, _ := hex.DecodeString(testAsset)
+			txout.Asset = append([]byte{1}, reverseBytes(assetBytes)...)
+			txout.Value = txbuilder.NewConfidentialValue(amount)
+		}
+		tx.TxOut = append(tx.TxOut, txout)
+	}
+	return tx
+}
+
+func TestSendTransaction(t *testing.T) {
+	node := NewNode(false)
+	utxo, err := node.Fund(opTrueScript, 100000000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Height != 1 || node.GetConfirmations(utxo) != 1 {
+		t.Fatalf("height %d, confirmations %d", node.Height, node.GetConfirmations(utxo))
+	}
+
+	tx := newSpendTx(false, []*Utxo{utxo}, 60000000, 39990000)
+	result, err := node.SendTransaction(tx.Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Txid != tx.Txid() || result.Fee != 10000 || len(result.Utxos) != 2 {
+		t.Fatalf("result is unmatch: %+v", result)
+	}
+	if _, err = node.GetUtxo(utxo.Txid, utxo.Vout); err == nil || !strings.Contains(err.Error(), "already spent by "+tx.Txid()) {
+		t.Fatalf("spent utxo: %v", err)
+	}
+
+	// the mempool output can be spent.
+	child := newSpendTx(false, []*Utxo{&result.Utxos[0]}, 59990000)
+	if _, err = node.SendTransaction(child.Hex(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if confirmed := node.Mine(3); len(confirmed) != 2 || node.Height != 4 {
+		t.Fatalf("confirmed %v, height %d", confirmed, node.Height)
+	}
+	if len(node.Mempool) != 0 || len(node.ListUtxos("")) != 2 {
+		t.Fatalf("mempool %v, utxos %+v", node.Mempool, node.ListUtxos(""))
+	}
+}
+
+func TestSendTransactionReject(t *testing.T) {
+	node := NewNode(false)
+	utxo, err := node.Fund(opTrueScript, 100000000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spent := newSpendTx(false, []*Utxo{utxo}, 99990000)
+	if _, err = node.SendTransaction(spent.Hex(), nil); err != nil {
+		t.Fatal(err)
+	}
+	other, err := node.Fund(opTrueScript, 50000000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overspend := newSpendTx(false, []*Utxo{other}, 50000001)
+	duplicated := newSpendTx(false, []*Utxo{other, other}, 1000)
+	unknown := newSpendTx(false, []*Utxo{{LockingScript: opTrueScript}}, 1000)
+	unknown.TxIn[0].Txid = strings.Repeat("ab", 32)
+	heightLocked := newSpendTx(false, []*Utxo{other}, 1000)
+	heightLocked.Locktime = node.Height + 1
+	heightLocked.TxIn[0].Sequence = txbuilder.SequenceMaxRbf
+	csvLocked := newSpendTx(false, []*Utxo{other}, 1000)
+	csvLocked.TxIn[0].Sequence = 5
+	wrongScript := newSpendTx(false, []*Utxo{other}, 1000)
+	wrongScript.TxIn[0].ScriptSig = []byte{0x00}
+	wrongScript.TxIn[0].Witness = [][]byte{{0x01}}
+
+	testCases := []struct {
+		name   string
+		txHex  string
+		reason string
+	}{
+		{"double spend", newSpendTx(false, []*Utxo{utxo}, 1000).Hex(), "already spent"},
+		{"already accepted", spent.Hex(), "already accepted"},
+		{"overspend", overspend.Hex(), "exceeds input amount"},
+		{"duplicated input", duplicated.Hex(), "is duplicated"},
+		{"unknown input", unknown.Hex(), "is not found"},
+		{"locktime", heightLocked.Hex(), "tx is non-final"},
+		{"csv", csvLocked.Hex(), "5 blocks are required"},
+		{"script", wrongScript.Hex(), "script verify failed"},
+	}
+	for _, testCase := range testCases {
+		if _, err := node.SendTransaction(testCase.txHex, nil); err == nil || !strings.Contains(err.Error(), testCase.reason) {
+			t.Errorf("%s: error %v, want %s", testCase.name, err, testCase.reason)
+		}
+	}
+
+	// the timelocks are satisfied after the blocks.
+	node.Mine(4)
+	if _, err = node.SendTransaction(csvLocked.Hex(), nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSendTransactionP2wpkh(t *testing.T) {
+	// the signatures are calculated for the spending tx of the fixed outpoint (11...11,0) of 1 BTC.
+	// the bad signature is valid der, but calculated with the amount 100000001.
+	testCases := []struct {
+		name      string
+		signature string
+		reason    string
+	}{
+		{"signed", "3045022100c7e4bdb0d4a53d94bbef21761a21e26316b8918264a99f23dbfcd8a08a13aff5" +
+			"0220171736e88765e8b421f2287c026b3053a2bc64cfadceec29afa06d319b42e90a01", ""},
+		{"bad signature", "304402202c1dd109bd2f12901076ce3ff13bef226c9ebf393039855baadd0ab1f608b9d6" +
+			"02204d558334003dd07b51d2110faab7a82d6b5c6ec7927656b8a4a1a55c7b3c6d3701", "script verify failed"},
+	}
+	for _, testCase := range testCases {
+		node := NewNode(false)
+		node.Mine(1)
+		utxo := Utxo{
+			UtxoData:      cache.UtxoData{Txid: strings.Repeat("11", 32), Vout: 0, Amount: 100000000},
+			LockingScript: p2wpkhScript,
+			Height:        1,
+		}
+		node.Utxos = append(node.Utxos, utxo)
+
+		tx := newSpendTx(false, []*Utxo{&utxo}, 99990000)
+		signature, _ := hex.DecodeString(testCase.signature)
+		pubkey, _ := hex.DecodeString(p2wpkhPubkey)
+		tx.TxIn[0].Witness = [][]byte{signature, pubkey}
+		result, err := node.SendTransaction(tx.Hex(), nil)
+		switch {
+		case testCase.reason == "" && err != nil:
+			t.Errorf("%s: %v", testCase.name, err)
+		case testCase.reason == "" && (result.Fee != 10000 || len(result.Utxos) != 1):
+			t.Errorf("%s: result is unmatch: %+v", testCase.name, result)
+		case testCase.reason != "" && (err == nil || !strings.Contains(err.Error(), testCase.reason)):
+			t.Errorf("%s: error %v, want %s", testCase.name, err, testCase.reason)
+		case testCase.reason != "" && len(node.Mempool) != 0:
+			t.Errorf("%s: the rejected tx is in the mempool", testCase.name)
+		}
+	}
+}
+
+func TestSendTransactionElements(t *testing.T) {
+	node := NewNode(true)
+	utxo, err := node.Fund(opTrueScript, 100000000, testAsset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := newSpendTx(true, []*Utxo{utxo}, 99990000)
+	if _, err = node.SendTransaction(tx.Hex(), nil); err == nil || !strings.Contains(err.Error(), "is unbalanced") {
+		t.Fatalf("unbalanced: %v", err)
+	}
+	fee := newSpendTx(true, nil, 10000).TxOut[0]
+	fee.LockingScript = nil
+	tx.TxOut = append(tx.TxOut, fee)
+	result, err := node.SendTransaction(tx.Hex(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Fee != 10000 || len(result.Utxos) != 1 || result.Utxos[0].Asset != testAsset {
+		t.Fatalf("result is unmatch: %+v", result)
+	}
+}
+
+func TestCheckBalanceBlinders(t *testing.T) {
+	tx := &txbuilder.Transaction{IsElements: true, TxOut: []*txbuilder.TxOut{{}, {}}}
+	blinder := func(value byte) string {
+		return hex.EncodeToString(append([]byte{value}, make([]byte, 31)...))
+	}
+	inputs := []valueOpening{{asset: testAsset, amount: 3, assetBlinder: blinder(1), amountBlinder: blinder(2)}}
+	// 3*1 + 2 = 1*1 + 2 + 2*1 + 0
+	outputs := []valueOpening{
+		{asset: testAsset, amount: 1, assetBlinder: blinder(1), amountBlinder: blinder(2)},
+		{asset: testAsset, amount: 2, assetBlinder: blinder(1), amountBlinder: ""},
+	}
+	if _, err := checkBalance(tx, inputs, outputs); err != nil {
+		t.Fatal(err)
+	}
+	outputs[1].amountBlinder = blinder(1)
+	if _, err := checkBalance(tx, inputs, outputs); err == nil || err.Error() != "blinding factors are unbalanced" {
+		t.Fatalf("unbalanced blinders: %v", err)
+	}
+}
+
+func TestSaveAndLoadNode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfd-cli-regtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "node.json")
+	if _, err = LoadNode(path); err == nil {
+		t.Fatal("not found node is loaded")
+	}
+
+	node := NewNode(false)
+	utxo, err := node.Fund(opTrueScript, 1000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = node.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadNode(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadedUtxo, err := loaded.GetUtxo(utxo.Txid, utxo.Vout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loadedUtxo, utxo) || loaded.Height != node.Height {
+		t.Fatalf("loaded node is unmatch: %+v", loadedUtxo)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"cfd-cli/cache"
+	"cfd-cli/params"
+)
+
+// recipeOutputRegexp matches the "key: value" line of the command output.
+var recipeOutputRegexp = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9 _()-]*):\s*(.*)$`)
+
+// recipeKeyRegexp matches the characters replaced with underscore in the output key.
+var recipeKeyRegexp = regexp.MustCompile(`[^a-z0-9]+`)
+
+// recipeVariableRegexp matches the variable reference. ex) ${vars.privkey}, ${sighash.signature_hash}
+var recipeVariableRegexp = regexp.MustCompile(`\$\{([A-Za-z0-9_-]+)\.([A-Za-z0-9_-]+)\}`)
+
+// RunCmd execute the multi-step transaction recipe.
+type RunCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	recipePath *string
+	txFilePath *string
+	vars       *string
+	isDryRun   *bool
+}
+
+// Recipe multi-step transaction recipe. (yaml or json)
+type Recipe struct {
+	// Vars the variables referenced by ${vars.name}.
+	Vars map[string]interface{} `yaml:"vars"`
+	// File the transaction data file shared by the steps. (default: temporary file)
+	File  string       `yaml:"file"`
+	Steps []RecipeStep `yaml:"steps"`
+}
+
+// RecipeStep the step of the recipe.
+type RecipeStep struct {
+	// ID the step id referenced by ${id.key}. (default: step<index>)
+	ID      string                 `yaml:"id"`
+	Command string                 `yaml:"command"`
+	Params  map[string]interface{} `yaml:"params"`
+	// Expect the regexp that the output must match.
+	Expect string `yaml:"expect"`
+}
+
+// recipeStepResult the result of the recipe step.
+type recipeStepResult struct {
+	status  string
+	outputs map[string]string
+}
+
+// recipeRunner execute the recipe steps.
+type recipeRunner struct {
+	recipe *Recipe
+	vars   map[string]string
+	// data the transaction data shared by the steps in memory.
+	data *cache.TransactionCacheData
+	// cachePath the memory path of data passed to the steps as -file. (empty if not running)
+	cachePath string
+	// filePath the file that data is loaded from and saved to. (optional)
+	filePath string
+	isDryRun bool
+	results  []recipeStepResult
+}
+
+// NewRunCmd returns a new RunCmd struct.
+func NewRunCmd() *RunCmd {
+	return &RunCmd{}
+}
+
+// Command returns the command name.
+func (cmd *RunCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *RunCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *RunCmd) Init() {
+	cmd.cmd = "run"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.recipePath = cmd.flagSet.String("recipe", "", "recipe file path (yaml or json)")
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path (default: file of the recipe)")
+	cmd.vars = cmd.flagSet.String("vars", "", "recipe variables (name1=value1|name2=value2|...)")
+	cmd.isDryRun = cmd.flagSet.Bool("dryrun", false, "print the steps without execution")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *RunCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *RunCmd) Do(ctx context.Context) error {
+	if *cmd.recipePath == "" {
+		return errors.New("recipe is required")
+	}
+	recipe, err := ReadRecipe(*cmd.recipePath)
+	if err != nil {
+		return err
+	}
+	runner, err := newRecipeRunner(recipe, *cmd.vars, *cmd.isDryRun)
+	if err != nil {
+		return err
+	}
+	if *cmd.txFilePath != "" {
+		runner.filePath = *cmd.txFilePath
+	} else if recipe.File != "" {
+		runner.filePath = recipe.File
+	}
+	if _, statErr := os.Stat(runner.filePath); runner.filePath != "" && statErr == nil {
+		if runner.data, err = cache.ReadTransactionCache(runner.filePath); err != nil {
+			return err
+		}
+	}
+
+	err = runner.Run(ctx)
+	if runner.filePath != "" && !runner.isDryRun {
+		// the data is saved even if the step failed, same as the step by step commands.
+		if _, writeErr := cache.WriteTransactionCache(runner.filePath, runner.data); writeErr != nil && err == nil {
+			err = writeErr
+		}
+	}
+	runner.PrintSummary()
+	if err != nil {
+		return fmt.Errorf("run: fail. reason: %s", err.Error())
+	}
+	fmt.Println("run: success.")
+	return nil
+}
+
+// ReadRecipe reads the recipe file. (yaml or json)
+func ReadRecipe(path string) (*Recipe, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var recipe Recipe
+	if err = yaml.UnmarshalStrict(bytes, &recipe); err != nil {
+		return nil, err
+	}
+	if len(recipe.Steps) == 0 {
+		return nil, errors.New("recipe steps are empty")
+	}
+	return &recipe, nil
+}
+
+// newRecipeRunner returns a new recipeRunner struct.
+// vars is "name=value|..." format, and overrides the recipe variables.
+func newRecipeRunner(recipe *Recipe, vars string, isDryRun bool) (*recipeRunner, error) {
+	runner := &recipeRunner{
+		recipe:   recipe,
+		vars:     map[string]string{},
+		data:     cache.NewTransactionCacheData(),
+		isDryRun: isDryRun,
+		results:  make([]recipeStepResult, len(recipe.Steps)),
+	}
+	for name, value := range recipe.Vars {
+		runner.vars[name] = formatRecipeValue(value)
+	}
+	keyValues, err := params.ParseKeyValues(vars)
+	if err != nil {
+		return nil, fmt.Errorf("vars is invalid. %s", err.Error())
+	}
+	for _, keyValue := range keyValues {
+		runner.vars[keyValue.Key] = keyValue.Value
+	}
+
+	ids := map[string]bool{"vars": true, "cache": true}
+	for index := range recipe.Steps {
+		step := &recipe.Steps[index]
+		if step.ID == "" {
+			step.ID = fmt.Sprintf("step%d", index+1)
+		}
+		if ids[step.ID] {
+			return nil, fmt.Errorf("step id %s is duplicated or reserved", step.ID)
+		}
+		ids[step.ID] = true
+		cmd, ok := commandMap[step.Command]
+		if !ok || !isRecipeCommand(cmd) {
+			return nil, fmt.Errorf("step %s: command %s is not available", step.ID, step.Command)
+		}
+		if step.Expect != "" {
+			if _, err := regexp.Compile(step.Expect); err != nil {
+				return nil, fmt.Errorf("step %s: expect is invalid regexp. %s", step.ID, err.Error())
+			}
+		}
+		runner.results[index].status = "skipped"
+	}
+	return runner, nil
+}
+
+// isRecipeCommand returns true if the command can be used in the recipe.
+func isRecipeCommand(cmd Command) bool {
+	switch cmd.(type) {
+	case *RunCmd, *ServeCmd, *ShellCmd:
+		return false
+	}
+	return true
+}
+
+// Run executes the steps in order. It stops at the first failed step.
+func (runner *recipeRunner) Run(ctx context.Context) error {
+	if !runner.isDryRun {
+		runner.cachePath = cache.NewMemoryCache(runner.data)
+		defer cache.ReleaseMemoryCache(runner.cachePath)
+	}
+	for index := range runner.recipe.Steps {
+		step := &runner.recipe.Steps[index]
+		if err := runner.runStep(ctx, index, step); err != nil {
+			runner.results[index].status = "failed"
+			return fmt.Errorf("step %s: %s", step.ID, err.Error())
+		}
+		if runner.isDryRun {
+			runner.results[index].status = "dryrun"
+		} else {
+			runner.results[index].status = "ok"
+		}
+	}
+	return nil
+}
+
+// runStep executes the step with the resolved params.
+func (runner *recipeRunner) runStep(ctx context.Context, index int, step *RecipeStep) error {
+	args := []string{}
+	setFlags := func(flagSet *flag.FlagSet) error {
+		params := map[string]string{}
+		for name, value := range step.Params {
+			if flagSet.Lookup(name) == nil {
+				return fmt.Errorf("param %s is unknown", name)
+			}
+			text, err := runner.resolve(formatRecipeValue(value))
+			if err != nil {
+				return err
+			}
+			params[name] = text
+		}
+		_, hasFile := params["file"]
+		_, hasTx := params["tx"]
+		if flagSet.Lookup("file") != nil && !hasFile && !hasTx && runner.cachePath != "" {
+			// share the transaction data in memory between the steps.
+			params["file"] = runner.cachePath
+		}
+
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			value := params[name]
+			if runner.isDryRun && recipeVariableRegexp.MatchString(value) {
+				// the step output is not resolved in dry-run mode.
+			} else if err := flagSet.Set(name, value); err != nil {
+				return fmt.Errorf("param %s is invalid. %s", name, err.Error())
+			}
+			args = append(args, fmt.Sprintf("-%s %s", name, strconv.Quote(value)))
+		}
+		return nil
+	}
+	printStep := func() {
+		fmt.Printf("== [%d] %s: %s %s\n", index+1, step.ID, step.Command, strings.Join(args, " "))
+	}
+	if runner.isDryRun {
+		if err := prepareCommand(commandMap[step.Command], setFlags); err != nil {
+			return err
+		}
+		printStep()
+		return nil
+	}
+
+	output, err := dispatchCommand(ctx, step.Command, func(flagSet *flag.FlagSet) error {
+		if err := setFlags(flagSet); err != nil {
+			return err
+		}
+		printStep()
+		return nil
+	})
+	fmt.Print(output)
+	if err != nil {
+		return err
+	}
+	runner.results[index].outputs = parseRecipeOutput(output)
+	if step.Expect != "" && !regexp.MustCompile(step.Expect).MatchString(output) {
+		return fmt.Errorf("output is unmatch the expect: %s", step.Expect)
+	}
+	return nil
+}
+
+// resolve replaces the variable references of the text.
+// In dry-run mode, the step output references are not replaced.
+func (runner *recipeRunner) resolve(text string) (string, error) {
+	var resolveErr error
+	result := recipeVariableRegexp.ReplaceAllStringFunc(text, func(ref string) string {
+		match := recipeVariableRegexp.FindStringSubmatch(ref)
+		name, key := match[1], match[2]
+		switch name {
+		case "vars":
+			if value, ok := runner.vars[key]; ok {
+				return value
+			}
+			resolveErr = fmt.Errorf("variable %s is not found", ref)
+			return ref
+		case "cache":
+			if runner.isDryRun {
+				return ref
+			}
+			if key != "hex" {
+				resolveErr = fmt.Errorf("variable %s is not found", ref)
+				return ref
+			}
+			return runner.data.Hex
+		}
+		for index, step := range runner.recipe.Steps {
+			if step.ID != name {
+				continue
+			}
+			if runner.isDryRun {
+				return ref
+			}
+			if value, ok := runner.results[index].outputs[key]; ok {
+				return value
+			}
+			break
+		}
+		resolveErr = fmt.Errorf("variable %s is not found", ref)
+		return ref
+	})
+	return result, resolveErr
+}
+
+// PrintSummary prints the step results and the transaction.
+func (runner *recipeRunner) PrintSummary() {
+	fmt.Println("== summary ==")
+	fmt.Printf("%-5s %-20s %-28s %s\n", "index", "id", "command", "status")
+	for index, step := range runner.recipe.Steps {
+		fmt.Printf("%-5d %-20s %-28s %s\n", index+1, step.ID, step.Command, runner.results[index].status)
+	}
+	if runner.isDryRun {
+		return
+	}
+	if runner.data.Hex != "" {
+		fmt.Printf("tx: %s\n", runner.data.Hex)
+	}
+	if runner.filePath != "" {
+		fmt.Printf("file: %s\n", runner.filePath)
+	}
+}
+
+// parseRecipeOutput returns the "key: value" of the output.
+// The key is lower case with underscore. (ex. "signature hash" -> "signature_hash")
+// If the value is empty, the next line is the value. "output" is the whole output.
+func parseRecipeOutput(output string) map[string]string {
+	outputs := map[string]string{"output": strings.TrimSpace(output)}
+	lines := strings.Split(output, "\n")
+	for index, line := range lines {
+		match := recipeOutputRegexp.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+		key := strings.Trim(recipeKeyRegexp.ReplaceAllString(strings.ToLower(match[1]), "_"), "_")
+		value := strings.Trim(strings.TrimSpace(match[2]), "'")
+		if value == "" && index+1 < len(lines) && !recipeOutputRegexp.MatchString(strings.TrimSpace(lines[index+1])) {
+			value = strings.TrimSpace(lines[index+1])
+		}
+		if _, ok := outputs[key]; !ok && key != "" {
+			outputs[key] = value
+		}
+	}
+	return outputs
+}
+
+// formatRecipeValue returns the text of the recipe value.
+func formatRecipeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRecipeRunnerStepFailure(t *testing.T) {
+	// the config files are not used.
+	loadedConfig = &cliConfig{}
+	recipe := &Recipe{Steps: []RecipeStep{
+		{ID: "encode", Command: "encodescript", Params: map[string]interface{}{"asm": "OP_TRUE"}},
+		{ID: "append", Command: "appendtxin", Params: map[string]interface{}{
+			"tx": "02000000000000000000", "txid": "00", "vout": 0}},
+		{ID: "last", Command: "encodescript", Params: map[string]interface{}{"asm": "OP_TRUE"}},
+	}}
+	runner, err := newRecipeRunner(recipe, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var runErr error
+	output, err := captureOutput(func() { runErr = runner.Run(context.Background()) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runErr == nil || runErr.Error() != "step append: txid size invalid" {
+		t.Errorf("run error: %v", runErr)
+	}
+	for index, status := range []string{"ok", "failed", "skipped"} {
+		if runner.results[index].status != status {
+			t.Errorf("step %d: status %s, want %s", index+1, runner.results[index].status, status)
+		}
+	}
+	if strings.Contains(output, "== [3]") {
+		t.Errorf("the step after the failed step is executed.\n%s", output)
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const (
+	// JSON-RPC 2.0 error codes
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+	// rpcCommandError the command failed. (server error)
+	rpcCommandError = -32000
+
+	// rpcDescribeMethod the method name to describe the methods and params.
+	rpcDescribeMethod = "rpc.describe"
+	// rpcMaxRequestSize max size of the request body.
+	rpcMaxRequestSize = 16 * 1024 * 1024
+)
+
+// ServeCmd serve the commands as JSON-RPC methods.
+type ServeCmd struct {
+	cmd     string
+	flagSet *flag.FlagSet
+	socket  *string
+	listen  *string
+	token   *string
+}
+
+// rpcRequest JSON-RPC request.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse JSON-RPC response.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError JSON-RPC error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	// Data the result or the output of the failed command.
+	Data interface{} `json:"data,omitempty"`
+}
+
+// rpcCommandResult the result of the command method.
+type rpcCommandResult struct {
+	Output string `json:"output"`
+}
+
+// rpcMethodInfo the params and the result type of the command method.
+type rpcMethodInfo struct {
+	Params []rpcParamInfo `json:"params"`
+	// Result "json" for the commands of rpcResultCommands, "output" for the other commands.
+	Result string `json:"result"`
+}
+
+// rpcParamInfo the param of the command method.
+type rpcParamInfo struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default string `json:"default"`
+	Usage   string `json:"usage"`
+}
+
+// rpcResultCommands the constructors of the commands that return the json result.
+// A new command is created for each call, so the calls run concurrently.
+// Only the transaction building commands are supported. The other commands
+// return the printed output as rpcCommandResult, and run one by one.
+var rpcResultCommands = map[string]func() ResultCommand{
+	"decoderawtransaction":  func() ResultCommand { return NewDecodeRawTransactionCmd() },
+	"initializetransaction": func() ResultCommand { return NewInitializeTransactionCmd() },
+	"appendtxin":            func() ResultCommand { return NewAppendTxInCmd() },
+	"appendtxout":           func() ResultCommand { return NewAppendTxOutCmd() },
+	"verifysigntransaction": func() ResultCommand { return NewVerifySignTransactionCmd() },
+}
+
+// rpcServer execute the commands by JSON-RPC request.
+// The commands of rpcResultCommands are called directly.
+// The other commands print the output to stdout, so the execution is serialized.
+type rpcServer struct {
+	token   string
+	methods map[string]rpcMethodInfo
+	mutex   sync.Mutex
+}
+
+// NewServeCmd returns a new ServeCmd struct.
+func NewServeCmd() *ServeCmd {
+	return &ServeCmd{}
+}
+
+// Command returns the command name.
+func (cmd *ServeCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *ServeCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *ServeCmd) Init() {
+	cmd.cmd = "serve"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.socket = cmd.flagSet.String("socket", "", "unix socket path")
+	cmd.listen = cmd.flagSet.String("listen", "", "localhost address. ex) 127.0.0.1:8080")
+	cmd.token = cmd.flagSet.String("token", "", "auth token (Authorization: Bearer <token>)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *ServeCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *ServeCmd) Do(ctx context.Context) error {
+	var listener net.Listener
+	var err error
+	switch {
+	case *cmd.socket != "" && *cmd.listen != "":
+		return errors.New("socket and listen are exclusive")
+	case *cmd.socket != "":
+		if info, statErr := os.Stat(*cmd.socket); statErr == nil && (info.Mode()&os.ModeSocket) != 0 {
+			// remove the stale socket.
+			os.Remove(*cmd.socket)
+		}
+		if listener, err = listenPrivateSocket(*cmd.socket); err == nil {
+			defer os.Remove(*cmd.socket)
+		}
+	case *cmd.listen != "":
+		if err = validateLocalAddress(*cmd.listen); err == nil {
+			listener, err = net.Listen("tcp", *cmd.listen)
+		}
+	default:
+		return errors.New("socket or listen is required")
+	}
+	if err != nil {
+		return err
+	}
+	// load the config before the concurrent calls.
+	if _, err = getConfig(); err != nil {
+		listener.Close()
+		return err
+	}
+
+	server := &http.Server{Handler: newRPCServer(*cmd.token)}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		select {
+		case <-sigChan:
+		case <-ctx.Done():
+		}
+		server.Shutdown(context.Background())
+	}()
+
+	fmt.Printf("serve: %s\n", listener.Addr().String())
+	if err = server.Serve(listener); err != nil && err != http.ErrServerClosed {
+		fmt.Println(err)
+	}
+	return nil
+}
+
+// listenPrivateSocket listens the unix socket that only the owner can connect.
+// The socket is created in the private directory, and moved to the path after the permission is set.
+func listenPrivateSocket(path string) (net.Listener, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".cfd-cli-serve-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tempPath := filepath.Join(dir, "serve.sock")
+	listener, err := net.Listen("unix", tempPath)
+	if err != nil {
+		return nil, err
+	}
+	// the socket file is removed by the caller.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err = os.Chmod(tempPath, 0600); err == nil {
+		err = os.Rename(tempPath, path)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// validateLocalAddress validates the address is the loopback address.
+func validateLocalAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return errors.New("listen address must be localhost")
+	}
+	return nil
+}
+
+// newRPCServer returns a new rpcServer struct with the methods of commandMap.
+func newRPCServer(token string) *rpcServer {
+	server := &rpcServer{
+		token:   token,
+		methods: map[string]rpcMethodInfo{},
+	}
+	for name, cmd := range commandMap {
+		switch cmd.(type) {
+		case *ServeCmd, *ShellCmd:
+			// the interactive commands are not available.
+			continue
+		}
+		params := []rpcParamInfo{}
+		cmd.GetFlagSet().VisitAll(func(f *flag.Flag) {
+			params = append(params, rpcParamInfo{
+				Name:    f.Name,
+				Type:    getFlagType(f),
+				Default: f.DefValue,
+				Usage:   f.Usage,
+			})
+		})
+		result := "output"
+		if _, ok := rpcResultCommands[name]; ok {
+			result = "json"
+		}
+		server.methods[name] = rpcMethodInfo{Params: params, Result: result}
+	}
+	return server
+}
+
+// ServeHTTP handles the JSON-RPC request.
+func (server *rpcServer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodPost {
+		http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if server.token != "" {
+		auth := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(server.token)) != 1 {
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	response := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	var req rpcRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, rpcMaxRequestSize))
+	decoder.UseNumber()
+	if err := decoder.Decode(&req); err != nil {
+		response.Error = &rpcError{Code: rpcParseError, Message: err.Error()}
+	} else if req.JSONRPC != "2.0" || req.Method == "" {
+		response.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+	} else {
+		if len(req.ID) > 0 {
+			response.ID = req.ID
+		}
+		response.Result, response.Error = server.call(request.Context(), req.Method, req.Params)
+	}
+	if len(req.ID) == 0 && response.Error == nil {
+		// notification
+		writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(writer).Encode(&response)
+}
+
+// call calls the method with the params.
+func (server *rpcServer) call(ctx context.Context, method string, params json.RawMessage) (interface{}, *rpcError) {
+	if method == rpcDescribeMethod {
+		return server.methods, nil
+	}
+	_, ok := commandMap[method]
+	if _, isExist := server.methods[method]; !ok || !isExist {
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + method}
+	}
+	paramMap := map[string]json.RawMessage{}
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &paramMap); err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: "params must be object"}
+		}
+	}
+
+	var paramErr error
+	setFlags := func(flagSet *flag.FlagSet) error {
+		for name, value := range paramMap {
+			if paramErr = setFlagParam(flagSet, name, value); paramErr != nil {
+				return paramErr
+			}
+		}
+		return nil
+	}
+	var result interface{}
+	var err error
+	if newCommand, ok := rpcResultCommands[method]; ok {
+		cmd := newCommand()
+		if err = prepareCommand(cmd, setFlags); err == nil {
+			result, err = cmd.Result(ctx)
+		}
+	} else {
+		result, err = server.dispatch(ctx, method, setFlags)
+	}
+	if paramErr != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: paramErr.Error()}
+	} else if err != nil {
+		return nil, &rpcError{Code: rpcCommandError, Message: err.Error(), Data: result}
+	}
+	return result, nil
+}
+
+// dispatch calls the command that prints the output, and returns the output.
+// The output is returned with the error if the command fails.
+func (server *rpcServer) dispatch(ctx context.Context, method string, setFlags func(flagSet *flag.FlagSet) error) (interface{}, error) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	output, err := dispatchCommand(ctx, method, setFlags)
+	if output == "" {
+		return nil, err
+	}
+	return &rpcCommandResult{Output: output}, err
+}
+
+// getFlagType returns the param type of the flag. (bool, string, int, uint, int64, uint64, float64)
+func getFlagType(f *flag.Flag) string {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		return fmt.Sprintf("%T", getter.Get())
+	}
+	return "string"
+}
+
+// setFlagParam sets the JSON param value to the flag.
+func setFlagParam(flagSet *flag.FlagSet, name string, value json.RawMessage) error {
+	f := flagSet.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("param %s is unknown", name)
+	}
+	var text string
+	var err error
+	paramType := getFlagType(f)
+	switch paramType {
+	case "bool":
+		var boolValue bool
+		if err = json.Unmarshal(value, &boolValue); err == nil {
+			text = strconv.FormatBool(boolValue)
+		}
+	case "string":
+		err = json.Unmarshal(value, &text)
+	default:
+		var number json.Number
+		if trimmed := bytes.TrimSpace(value); len(trimmed) == 0 || trimmed[0] == '"' {
+			err = errors.New("not number")
+		} else if err = json.Unmarshal(trimmed, &number); err == nil {
+			text = number.String()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("param %s must be %s", name, paramType)
+	}
+	if err = flagSet.Set(name, text); err != nil {
+		return fmt.Errorf("param %s is invalid. %s", name, err.Error())
+	}
+	return nil
+}
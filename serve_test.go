@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRPCServerCall(t *testing.T) {
+	// the config files are not used.
+	loadedConfig = &cliConfig{}
+	server := newRPCServer("")
+	ctx := context.Background()
+
+	// the command that prints the output.
+	result, rpcErr := server.call(ctx, "encodescript", json.RawMessage(`{"asm":"OP_TRUE"}`))
+	if rpcErr != nil {
+		t.Fatalf("encodescript: %v", rpcErr)
+	}
+	if output := result.(*rpcCommandResult).Output; !strings.Contains(output, "hex : 51\n") {
+		t.Errorf("encodescript: output %s", output)
+	}
+	_, rpcErr = server.call(ctx, "encodescript", json.RawMessage(`{"asm":"OP_PUSHDATA1"}`))
+	if rpcErr == nil || rpcErr.Code != rpcCommandError {
+		t.Errorf("encodescript: error %v", rpcErr)
+	}
+
+	// the command that returns the result.
+	_, rpcErr = server.call(ctx, "appendtxin", json.RawMessage(`{"tx":"02000000000000000000","txid":"00"}`))
+	if rpcErr == nil || rpcErr.Code != rpcCommandError || rpcErr.Message != "txid size invalid" {
+		t.Errorf("appendtxin: error %v", rpcErr)
+	}
+	_, rpcErr = server.call(ctx, "appendtxin", json.RawMessage(`{"vout":"0"}`))
+	if rpcErr == nil || rpcErr.Code != rpcInvalidParams {
+		t.Errorf("appendtxin: params error %v", rpcErr)
+	}
+	describe, rpcErr := server.call(ctx, rpcDescribeMethod, nil)
+	if rpcErr != nil {
+		t.Fatalf("describe: %v", rpcErr)
+	}
+	methods := describe.(map[string]rpcMethodInfo)
+	if methods["appendtxin"].Result != "json" || methods["encodescript"].Result != "output" {
+		t.Errorf("describe: result appendtxin %s, encodescript %s",
+			methods["appendtxin"].Result, methods["encodescript"].Result)
+	}
+	_, rpcErr = server.call(ctx, "shell", nil)
+	if rpcErr == nil || rpcErr.Code != rpcMethodNotFound {
+		t.Errorf("shell: error %v", rpcErr)
+	}
+}
+
+func TestListenPrivateSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cfd-cli-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "serve.sock")
+
+	listener, err := listenPrivateSocket(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Errorf("socket mode %s", info.Mode())
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("private dir is not removed. %d files", len(files))
+	}
+}
@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
 )
 
 // SetRawReissueAssetCmd append tx input.
@@ -60,28 +63,25 @@ func (cmd *SetRawReissueAssetCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *SetRawReissueAssetCmd) Do(ctx context.Context) {
+func (cmd *SetRawReissueAssetCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	// other input parameter check
 	if len(*cmd.txid) != 64 {
-		fmt.Println("txid size invalid.")
-		return
+		return errors.New("txid size invalid.")
 	}
 	assetBlinder := *cmd.assetBlinder
 	if len(*cmd.assetBlinder) != 64 {
@@ -98,29 +98,26 @@ func (cmd *SetRawReissueAssetCmd) Do(ctx context.Context) {
 			}
 		}
 		if !isFind {
-			fmt.Println("asset blinder size invalid.")
-			return
+			return errors.New("asset blinder size invalid.")
 		}
 	}
 	if len(*cmd.entropy) != 64 {
-		fmt.Println("entropy size invalid.")
-		return
+		return errors.New("entropy size invalid.")
 	}
 
 	asset, txHex, err := cfd.CfdGoSetRawReissueAsset(tx, *cmd.txid, uint32(*cmd.vout),
 		*cmd.amount, assetBlinder, *cmd.entropy, *cmd.address, *cmd.lockingScript)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	if *cmd.txFilePath != "" {
 		data.Hex = txHex
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
 	fmt.Printf("reissue asset: %s\n", asset)
+	return nil
 }
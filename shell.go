@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"cfd-cli/cache"
+)
+
+// shellBuiltins the builtin commands of the shell.
+var shellBuiltins = map[string]string{
+	"help":     "help [command]: show the commands, or the help of the command",
+	"show":     "show the decoded current transaction",
+	"status":   "show the shell settings and the utxo data",
+	"new":      "clear the current transaction data",
+	"load":     "load <path>: load the transaction data file",
+	"save":     "save [path]: save the transaction data file (default: the loaded file)",
+	"elements": "elements on|off: set -elements to the commands",
+	"network":  "network <network>: set -network to the commands",
+	"exit":     "exit the shell",
+}
+
+// ShellCmd interactive shell with the current transaction data.
+type ShellCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	txFilePath *string
+	isElements *bool
+	nettype    *string
+}
+
+// shellSession the state of the shell.
+type shellSession struct {
+	data       *cache.TransactionCacheData
+	isElements bool
+	nettype    string
+	filePath   string
+	workPath   string
+}
+
+// NewShellCmd returns a new ShellCmd struct.
+func NewShellCmd() *ShellCmd {
+	return &ShellCmd{}
+}
+
+// Command returns the command name.
+func (cmd *ShellCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *ShellCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *ShellCmd) Init() {
+	cmd.cmd = "shell"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path to load")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *ShellCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *ShellCmd) Do(ctx context.Context) error {
+	session := &shellSession{
+		data:       cache.NewTransactionCacheData(),
+		isElements: *cmd.isElements,
+		nettype:    *cmd.nettype,
+	}
+	if *cmd.txFilePath != "" {
+		if err := session.load(*cmd.txFilePath); err != nil {
+			return err
+		}
+	}
+	workFile, err := ioutil.TempFile("", "cfd-cli-shell-*.json")
+	if err != nil {
+		return err
+	}
+	workFile.Close()
+	session.workPath = workFile.Name()
+	defer os.Remove(session.workPath)
+
+	editor := newLineEditor(completeShellLine)
+	fmt.Println("cfd-cli shell. type \"help\" to show the commands, tab to complete.")
+	for {
+		prompt := "cfd-cli> "
+		if session.isElements {
+			prompt = "cfd-cli(elements)> "
+		}
+		line, err := editor.ReadLine(prompt)
+		if err != nil {
+			return nil
+		}
+		args, err := splitShellArgs(line)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if args[0] == "exit" || args[0] == "quit" {
+			return nil
+		}
+		if err = session.execute(ctx, args); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// execute executes the builtin or the command.
+func (session *shellSession) execute(ctx context.Context, args []string) error {
+	switch args[0] {
+	case "help":
+		if len(args) == 2 {
+			cmd, ok := commandMap[args[1]]
+			if !ok || !isShellCommand(cmd) {
+				printUnknownCommand(os.Stdout, args[1])
+				return nil
+			}
+			printCommandHelp(os.Stdout, cmd)
+			return nil
+		}
+		session.printHelp()
+	case "show":
+		session.showTransaction()
+	case "status":
+		fmt.Printf("elements: %t\nnetwork: %s\nfile: %s\nutxos: %d\n",
+			session.isElements, session.nettype, session.filePath, len(session.data.Utxos))
+		for _, utxo := range session.data.Utxos {
+			fmt.Printf("  %s,%d amount=%d descriptor=%s\n", utxo.Txid, utxo.Vout, utxo.Amount, utxo.Descriptor)
+		}
+	case "new":
+		session.data = cache.NewTransactionCacheData()
+	case "load":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s", shellBuiltins["load"])
+		}
+		if err := session.load(args[1]); err != nil {
+			return err
+		}
+		session.showTransaction()
+	case "save":
+		path := session.filePath
+		if len(args) == 2 {
+			path = args[1]
+		}
+		if path == "" {
+			return fmt.Errorf("usage: %s", shellBuiltins["save"])
+		}
+		if _, err := cache.WriteTransactionCache(path, session.data); err != nil {
+			return err
+		}
+		session.filePath = path
+		fmt.Printf("saved: %s\n", path)
+	case "elements":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			return fmt.Errorf("usage: %s", shellBuiltins["elements"])
+		}
+		session.isElements = args[1] == "on"
+	case "network":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s", shellBuiltins["network"])
+		}
+		session.nettype = args[1]
+	default:
+		return session.runCommand(ctx, args[0], args[1:])
+	}
+	return nil
+}
+
+// load loads the transaction data file.
+func (session *shellSession) load(path string) error {
+	data, err := cache.ReadTransactionCache(path)
+	if err != nil {
+		return err
+	}
+	session.data = data
+	session.filePath = path
+	return nil
+}
+
+// runCommand runs the command with the current transaction data.
+// -file, -elements and -network are added if they are not set.
+func (session *shellSession) runCommand(ctx context.Context, name string, args []string) error {
+	cmd, ok := commandMap[name]
+	if !ok || !isShellCommand(cmd) {
+		printUnknownCommand(os.Stdout, name)
+		return nil
+	}
+	isParseError := false
+	useWorkFile := false
+	output, err := dispatchCommand(ctx, name, func(flagSet *flag.FlagSet) error {
+		flagSet.Init(name, flag.ContinueOnError)
+		if err := flagSet.Parse(args); err != nil {
+			isParseError = true
+			return err
+		}
+		isSet := map[string]bool{}
+		flagSet.Visit(func(f *flag.Flag) {
+			isSet[f.Name] = true
+		})
+		useWorkFile = flagSet.Lookup("file") != nil && !isSet["file"] && !isSet["tx"]
+		if useWorkFile {
+			if _, err := cache.WriteTransactionCache(session.workPath, session.data); err != nil {
+				return err
+			}
+			flagSet.Set("file", session.workPath)
+		}
+		if session.isElements && flagSet.Lookup("elements") != nil && !isSet["elements"] {
+			flagSet.Set("elements", "true")
+		}
+		if session.nettype != "" && flagSet.Lookup("network") != nil && !isSet["network"] {
+			flagSet.Set("network", session.nettype)
+		}
+		return nil
+	})
+	fmt.Print(output)
+	if isParseError {
+		// the error and the usage are printed by the flag set.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !useWorkFile {
+		return nil
+	}
+	data, err := cache.ReadTransactionCache(session.workPath)
+	if err != nil {
+		return nil
+	}
+	before, _ := json.Marshal(session.data)
+	after, _ := json.Marshal(data)
+	if string(before) != string(after) {
+		isTxUpdated := data.Hex != session.data.Hex
+		session.data = data
+		if isTxUpdated {
+			session.showTransaction()
+		}
+	}
+	return nil
+}
+
+// showTransaction prints the decoded current transaction.
+func (session *shellSession) showTransaction() {
+	if session.data.Hex == "" {
+		fmt.Println("tx is empty")
+		return
+	}
+	fmt.Println("== current tx ==")
+	output, err := dispatchCommand(context.Background(), "decoderawtransaction", func(flagSet *flag.FlagSet) error {
+		flagSet.Set("tx", session.data.Hex)
+		if session.isElements {
+			flagSet.Set("elements", "true")
+		}
+		if session.nettype != "" {
+			flagSet.Set("network", session.nettype)
+		}
+		return nil
+	})
+	fmt.Print(output)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// printHelp prints the builtins and the commands.
+func (session *shellSession) printHelp() {
+	fmt.Println("builtins:")
+	for _, name := range sortedKeys(shellBuiltins) {
+		fmt.Printf("  %-10s %s\n", name, shellBuiltins[name])
+	}
+	fmt.Println("commands: (-file, -elements and -network are set from the shell)")
+	for _, name := range getShellCommandNames() {
+		fmt.Printf("  %s\n", name)
+	}
+}
+
+// isShellCommand returns true if the command can be used in the shell.
+func isShellCommand(cmd Command) bool {
+	switch cmd.(type) {
+	case *ShellCmd, *ServeCmd:
+		return false
+	}
+	return true
+}
+
+// getShellCommandNames returns the sorted command names of the shell.
+func getShellCommandNames() []string {
+	names := []string{}
+	for name, cmd := range commandMap {
+		if isShellCommand(cmd) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeShellLine returns the candidates of the command name or the flag name.
+func completeShellLine(line string) []string {
+	words := strings.Fields(line)
+	if len(line) == 0 || line[len(line)-1] == ' ' {
+		words = append(words, "")
+	}
+	word := words[len(words)-1]
+	candidates := []string{}
+	if len(words) == 1 {
+		names := append(getShellCommandNames(), sortedKeys(shellBuiltins)...)
+		for _, name := range names {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name)
+			}
+		}
+		return candidates
+	}
+	cmd, ok := commandMap[words[0]]
+	if !ok || !strings.HasPrefix(word, "-") {
+		return candidates
+	}
+	cmd.GetFlagSet().VisitAll(func(f *flag.Flag) {
+		if strings.HasPrefix("-"+f.Name, word) {
+			candidates = append(candidates, "-"+f.Name)
+		}
+	})
+	return candidates
+}
+
+// sortedKeys returns the sorted keys of the map.
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
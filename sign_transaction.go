@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"cfd-cli/cache"
+	"cfd-cli/keys"
+	"cfd-cli/params"
+	"cfd-cli/txbuilder"
+)
+
+// SignTransactionCmd sign all inputs of transaction.
+type SignTransactionCmd struct {
+	cmd          string
+	flagSet      *flag.FlagSet
+	txFilePath   *string
+	tx           *string
+	isElements   *bool
+	nettype      *string
+	keys         *string
+	keystore     *string
+	sigHashType  *string
+	anyoneCanPay *bool
+	grindR       *bool
+}
+
+// NewSignTransactionCmd returns a new SignTransactionCmd struct.
+func NewSignTransactionCmd() *SignTransactionCmd {
+	return &SignTransactionCmd{}
+}
+
+// Command returns the command name.
+func (cmd *SignTransactionCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *SignTransactionCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *SignTransactionCmd) Init() {
+	cmd.cmd = "signtransaction"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format (use utxo data of the file)")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+	cmd.keys = cmd.flagSet.String("keys", "",
+		"signing keys (privkey hex, wif, extended privkey, extended privkey with bip32 path or keystore id. ex. key1|xprv.../0/1|keystore:<id>|...)")
+	cmd.keystore = cmd.flagSet.String("keystore", "", "keystore file path (yaml. keys: {<id>: <key>})")
+	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
+	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "sighash anyonecanpay flag")
+	cmd.grindR = cmd.flagSet.Bool("grindr", false, "Grind-R option")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *SignTransactionCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *SignTransactionCmd) Do(ctx context.Context) error {
+	if *cmd.txFilePath == "" {
+		return errors.New("file is required")
+	}
+	data, err := cache.ReadTransactionCache(*cmd.txFilePath)
+	if err != nil {
+		return err
+	}
+	if *cmd.tx != "" {
+		data.Hex = *cmd.tx
+	}
+	if data.Hex == "" {
+		return errors.New("tx is required")
+	}
+	if *cmd.keys == "" {
+		return errors.New("keys is required")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	var keystore keys.Keystore
+	if *cmd.keystore != "" {
+		if keystore, err = keys.LoadKeystore(*cmd.keystore); err != nil {
+			return err
+		}
+	}
+	keySet, err := keys.NewSigningKeySet(params.SplitList(*cmd.keys, params.ListSeparator), keystore)
+	if err != nil {
+		return err
+	}
+
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sigHashType, *cmd.anyoneCanPay)
+	if err == nil {
+		err = sighashType.ValidateEcdsa(*cmd.isElements)
+	}
+	if err != nil {
+		return err
+	}
+
+	results, err := txbuilder.SignTransaction(data, *cmd.isElements, txbuilder.SignOption{
+		NetworkType: networkType,
+		KeySet:      keySet,
+		SighashType: sighashType,
+		GrindR:      *cmd.grindR,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
+	if err != nil {
+		return err
+	}
+
+	incompletes := 0
+	fmt.Printf("%-5s %-70s %-14s %s\n", "index", "outpoint", "status", "detail")
+	for index, result := range results {
+		fmt.Printf("%-5d %-70s %-14s %s\n", index,
+			fmt.Sprintf("%s,%d", result.Txid, result.Vout), result.Status, result.Detail)
+		if !result.IsComplete() {
+			incompletes++
+		}
+	}
+	if incompletes == 0 {
+		fmt.Println("all inputs are signed.")
+	} else {
+		fmt.Printf("%d inputs are incomplete.\n", incompletes)
+	}
+	return nil
+}
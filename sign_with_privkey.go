@@ -2,10 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/keys"
+	"cfd-cli/txbuilder"
 )
 
 // SignWithPrivkeyCmd create sighash.
@@ -15,12 +21,14 @@ type SignWithPrivkeyCmd struct {
 	txFilePath       *string
 	tx               *string
 	isElements       *bool
+	nettype          *string
 	txid             *string
 	vout             *uint
 	privkey          *string
 	extpriv          *string
 	bip32path        *string
 	addrType         *string
+	redeemScript     *string
 	amount           *int64
 	amountCommitment *string
 	sigHashType      *string
@@ -50,6 +58,7 @@ func (cmd *SignWithPrivkeyCmd) Init() {
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.txid = cmd.flagSet.String("txid", "", "append transaction id")
 	cmd.vout = cmd.flagSet.Uint("vout", uint(0), "append transaction output number")
 	cmd.privkey = cmd.flagSet.String("privkey", "", "privkey")
@@ -57,11 +66,11 @@ func (cmd *SignWithPrivkeyCmd) Init() {
 	cmd.bip32path = cmd.flagSet.String("bip32path", "", "derive bip32 path")
 	cmd.addrType = cmd.flagSet.String("addresstype", "",
 		"txin's utxo addressType (p2wpkh, p2wsh, p2sh-p2wpkh, p2sh-p2wsh, p2pkh, p2sh)")
+	cmd.redeemScript = cmd.flagSet.String("script", "", "multisig redeem script (p2sh, p2wsh, p2sh-p2wsh)")
 	cmd.amount = cmd.flagSet.Int64("amount", int64(0), "utxo amount")
 	cmd.amountCommitment = cmd.flagSet.String("amountcommitment", "",
 		"amount commitment (for blind transaction)")
-	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all",
-		"sighashtype (all,single,none)")
+	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
 	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "sighash anyonecanpay flag")
 	cmd.grindR = cmd.flagSet.Bool("grindr", false, "Grind-R option")
 }
@@ -72,22 +81,20 @@ func (cmd *SignWithPrivkeyCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) {
+func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) error {
 	var err error
-	data := NewTransactionCacheData()
+	data := cache.NewTransactionCacheData()
 
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		data, err = ReadTransactionCache(*cmd.txFilePath)
+		data, err = cache.ReadTransactionCache(*cmd.txFilePath)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		tx = data.Hex
 	}
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	var privkey string
@@ -101,15 +108,13 @@ func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) {
 					*cmd.privkey, int(cfd.KCfdNetworkTestnet))
 			}
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
 	} else {
 		info, err := cfd.CfdGoGetExtkeyInformation(*cmd.extpriv)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		nettype := int(cfd.KCfdNetworkTestnet)
 		if info.Version == "0488ade4" {
@@ -120,39 +125,40 @@ func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) {
 			extpriv, err = cfd.CfdGoCreateExtkeyFromParentPath(
 				extpriv, *cmd.bip32path, nettype, int(cfd.KCfdExtPrivkey))
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 		}
 
 		privkey, _, err = cfd.CfdGoGetPrivkeyFromExtkey(extpriv, nettype)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
 
 	// parameter check
 	if len(*cmd.txid) != 64 {
-		fmt.Println("txid size invalid.")
-		return
+		return errors.New("txid size invalid.")
 	}
 	amountCommitment := *cmd.amountCommitment
 	if len(amountCommitment) > 0 && len(amountCommitment) != 66 {
-		fmt.Println("amount commitment size invalid.")
-		return
+		return errors.New("amount commitment size invalid.")
 	}
 
 	pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey(privkey, "", true)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
 	}
 
 	amount := *cmd.amount
 	addrType := -1
-	checkPubkey, _, tempAddrType, tempAmount, tempCommitment, err := GetDescriptorInfoFromUtxoList(
-		*cmd.txid, uint32(*cmd.vout), data.Utxos)
+	redeemScript := *cmd.redeemScript
+	checkPubkey, tempScript, tempAddrType, tempAmount, tempCommitment, err := txbuilder.GetDescriptorInfoFromUtxoList(
+		*cmd.txid, uint32(*cmd.vout), data.Utxos, networkType)
 	if tempAddrType != -1 {
 		if len(*cmd.addrType) == 0 {
 			addrType = tempAddrType
@@ -163,7 +169,10 @@ func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) {
 		if len(amountCommitment) == 0 {
 			amountCommitment = tempCommitment
 		}
-		if checkPubkey != pubkey {
+		if len(redeemScript) == 0 {
+			redeemScript = tempScript
+		}
+		if len(tempScript) == 0 && checkPubkey != pubkey {
 			fmt.Printf("unmatch pubkey. %s, %s\n", checkPubkey, pubkey)
 			fmt.Printf("privkey: %s\n", privkey)
 		}
@@ -177,47 +186,105 @@ func (cmd *SignWithPrivkeyCmd) Do(ctx context.Context) {
 			addrType = int(cfd.KCfdP2shP2wpkh)
 		case "p2wpkh":
 			addrType = int(cfd.KCfdP2wpkh)
+		case "p2sh":
+			addrType = int(cfd.KCfdP2sh)
+		case "p2sh-p2wsh":
+			addrType = int(cfd.KCfdP2shP2wsh)
+		case "p2wsh":
+			addrType = int(cfd.KCfdP2wsh)
 		default:
-			fmt.Printf("addresstype %s is unknown type.", *cmd.addrType)
-			return
+			return fmt.Errorf("addresstype %s is unknown type.", *cmd.addrType)
 		}
 	}
 
-	sigHashType := int(cfd.KCfdSigHashAll)
-	switch *cmd.sigHashType {
-	case "all":
-		sigHashType = int(cfd.KCfdSigHashAll)
-	case "none":
-		sigHashType = int(cfd.KCfdSigHashNone)
-	case "single":
-		sigHashType = int(cfd.KCfdSigHashSingle)
-	default:
-		fmt.Printf("sighashtype %s is unknown type.", *cmd.sigHashType)
-		return
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sigHashType, *cmd.anyoneCanPay)
+	if err == nil {
+		err = sighashType.ValidateEcdsa(*cmd.isElements)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch addrType {
+	case int(cfd.KCfdP2sh), int(cfd.KCfdP2wsh), int(cfd.KCfdP2shP2wsh):
+		return cmd.signMultisig(data, tx, networkType, addrType, redeemScript, keys.SigningKey{Privkey: privkey, Pubkey: pubkey},
+			amount, amountCommitment, sighashType)
 	}
 
 	var txHex string
 	if *cmd.isElements {
 		txHex, err = cfd.CfdGoAddConfidentialTxSignWithPrivkey(
 			tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
-			privkey, amount, amountCommitment, sigHashType,
-			*cmd.anyoneCanPay, *cmd.grindR)
+			privkey, amount, amountCommitment, sighashType.GetCfdType(),
+			sighashType.AnyoneCanPay, *cmd.grindR)
 	} else {
-		txHex, err = cfd.CfdGoAddTxSignWithPrivkey(int(cfd.KCfdNetworkMainnet),
+		txHex, err = cfd.CfdGoAddTxSignWithPrivkey(networkType,
 			tx, *cmd.txid, uint32(*cmd.vout), addrType, pubkey,
-			privkey, amount, sigHashType, *cmd.anyoneCanPay, *cmd.grindR)
+			privkey, amount, sighashType.GetCfdType(), sighashType.AnyoneCanPay, *cmd.grindR)
 	}
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
+	}
+
+	if *cmd.txFilePath != "" {
+		data.Hex = txHex
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signMultisig adds the partial signature to the utxo data of the file,
+// and sets the scriptsig/witness once the signatures reach the required number.
+func (cmd *SignWithPrivkeyCmd) signMultisig(data *cache.TransactionCacheData, tx string, networkType, addrType int,
+	redeemScript string, key keys.SigningKey, amount int64, amountCommitment string, sighashType txbuilder.SighashType) error {
+	if len(redeemScript) == 0 {
+		return errors.New("script is required for multisig")
+	}
+	var utxo *cache.UtxoData
+	for index := range data.Utxos {
+		if data.Utxos[index].Txid == *cmd.txid && data.Utxos[index].Vout == uint32(*cmd.vout) {
+			utxo = &data.Utxos[index]
+			break
+		}
+	}
+	if utxo == nil {
+		data.Utxos = append(data.Utxos, cache.UtxoData{Txid: *cmd.txid, Vout: uint32(*cmd.vout)})
+		utxo = &data.Utxos[len(data.Utxos)-1]
 	}
+	utxo.Amount = amount
+	utxo.AmountCommitment = amountCommitment
 
+	txHex, isComplete, err := txbuilder.SignMultisigInput(tx, *cmd.isElements, networkType, utxo,
+		addrType, redeemScript, []keys.SigningKey{key}, sighashType, *cmd.grindR)
+	if err != nil {
+		return err
+	}
+	if _, ok := utxo.PartialSigs[key.Pubkey]; !isComplete && !ok {
+		return fmt.Errorf("pubkey %s is not found in the multisig script.", key.Pubkey)
+	}
 	if *cmd.txFilePath != "" {
 		data.Hex = txHex
-		_, err = WriteTransactionCache(*cmd.txFilePath, data)
+		_, err = cache.WriteTransactionCache(*cmd.txFilePath, data)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 	}
+
+	if isComplete {
+		fmt.Println("multisig signatures are complete.")
+		if *cmd.txFilePath == "" {
+			fmt.Printf("tx: %s\n", txHex)
+		}
+		return nil
+	}
+	script, _ := hex.DecodeString(redeemScript)
+	fmt.Printf("multisig signatures: %d of %d\n", len(utxo.PartialSigs), txbuilder.ClassifyScript(script).RequiredSigs)
+	if *cmd.txFilePath == "" {
+		// partial signatures cannot be saved without the file.
+		fmt.Printf("signature: %s\n", utxo.PartialSigs[key.Pubkey])
+	}
+	return nil
 }
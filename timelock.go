@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
+)
+
+// ValidateTimelockCmd validate timelock of transaction.
+type ValidateTimelockCmd struct {
+	cmd        string
+	flagSet    *flag.FlagSet
+	txFilePath *string
+	tx         *string
+	isElements *bool
+	nettype    *string
+}
+
+// NewValidateTimelockCmd returns a new ValidateTimelockCmd struct.
+func NewValidateTimelockCmd() *ValidateTimelockCmd {
+	return &ValidateTimelockCmd{}
+}
+
+// Command returns the command name.
+func (cmd *ValidateTimelockCmd) Command() string {
+	return cmd.cmd
+}
+
+// Parse parses the command arguments.
+func (cmd *ValidateTimelockCmd) Parse(args []string) {
+	cmd.flagSet.Parse(args)
+}
+
+// Init initializes the command.
+func (cmd *ValidateTimelockCmd) Init() {
+	cmd.cmd = "validatetimelock"
+	cmd.flagSet = flag.NewFlagSet(cmd.cmd, flag.ExitOnError)
+	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
+	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
+	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
+}
+
+// GetFlagSet returns the flag set for this command.
+func (cmd *ValidateTimelockCmd) GetFlagSet() *flag.FlagSet {
+	return cmd.flagSet
+}
+
+// Do performs the command action.
+func (cmd *ValidateTimelockCmd) Do(ctx context.Context) error {
+	data := cache.NewTransactionCacheData()
+	tx := *cmd.tx
+	if *cmd.tx == "" && *cmd.txFilePath != "" {
+		if _, err := os.Stat(*cmd.txFilePath); err != nil && !cache.IsMemoryPath(*cmd.txFilePath) {
+			return errors.New("tx data file not found.")
+		}
+		txcache, err := cache.ReadTransactionCache(*cmd.txFilePath)
+		if err == nil {
+			data = txcache
+			tx = txcache.Hex
+		} else {
+			bytes, err := ioutil.ReadFile(*cmd.txFilePath)
+			if err != nil {
+				return err
+			}
+			tx = strings.TrimSpace(string(bytes))
+		}
+	}
+	if tx == "" {
+		return errors.New("tx is required")
+	}
+	networkType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+	txData, err := txbuilder.DecodeTransaction(tx, *cmd.isElements)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("version : %d\n", txData.Version)
+	fmt.Printf("locktime: %s\n", txbuilder.DescribeLocktime(txData.Locktime))
+	for index, txin := range txData.TxIn {
+		fmt.Printf("  [%d] %s:%d sequence: %s\n", index, txin.Txid, txin.Vout,
+			txbuilder.DescribeSequence(txin.Sequence))
+	}
+	warnings := txbuilder.ValidateTimelock(txData, data.Utxos, networkType)
+	for _, warning := range warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	if len(warnings) == 0 {
+		fmt.Println("timelock is valid.")
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DescriptorAnalysis analysis result of descriptor.
+type DescriptorAnalysis struct {
+	ScriptType     string
+	Miniscript     *Miniscript
+	Script         []byte
+	IsResolved     bool
+	OpsCount       int
+	Paths          []SpendingPath
+	MaxPath        SpendingPath
+	MaxWitnessSize int
+	MaxScriptSig   int
+	IsSegwit       bool
+}
+
+// AnalyzeDescriptor analyzes the descriptor (or miniscript) spending paths.
+// support: pkh, wpkh, sh(wpkh), sh(ms), wsh(ms), sh(wsh(ms)), and miniscript(as wsh).
+func AnalyzeDescriptor(descriptor string, resolver MiniscriptKeyResolver) (*DescriptorAnalysis, error) {
+	expr, err := parseExpression(descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &DescriptorAnalysis{ScriptType: "p2wsh", IsSegwit: true}
+	nestedSize := 0
+	switch {
+	case expr.name == "sh" && len(expr.args) == 1 && expr.args[0].name == "wsh":
+		analysis.ScriptType = "p2sh-p2wsh"
+		nestedSize = 34
+		expr = expr.args[0].args[0]
+	case expr.name == "sh" && len(expr.args) == 1 && expr.args[0].name == "wpkh":
+		analysis.ScriptType = "p2sh-p2wpkh"
+		nestedSize = 22
+		expr = expr.args[0]
+	case expr.name == "sh" && len(expr.args) == 1:
+		analysis.ScriptType = "p2sh"
+		analysis.IsSegwit = false
+		expr = expr.args[0]
+	case expr.name == "wsh" && len(expr.args) == 1:
+		expr = expr.args[0]
+	case expr.name == "pkh":
+		analysis.ScriptType = "p2pkh"
+		analysis.IsSegwit = false
+	case expr.name == "wpkh":
+		analysis.ScriptType = "p2wpkh"
+	}
+
+	if expr.name == "pkh" || expr.name == "wpkh" {
+		if len(expr.args) != 1 {
+			return nil, fmt.Errorf("%s requires a key", expr.name)
+		}
+		if analysis.ScriptType != "p2pkh" && analysis.ScriptType != "p2wpkh" &&
+			analysis.ScriptType != "p2sh-p2wpkh" {
+			return nil, fmt.Errorf("%s is not allowed in script", expr.name)
+		}
+		// pkh satisfaction: <sig> <pubkey> (without script)
+		expr.name = "pkh"
+		analysis.Miniscript, err = newMiniscriptFromExpr(expr)
+		if err != nil {
+			return nil, err
+		}
+		sats, _ := analysis.Miniscript.Satisfactions()
+		analysis.MaxPath = SpendingPath{
+			Keys:       sats[0].Keys,
+			StackSizes: []int{signatureSize, pubkeySize},
+		}
+		analysis.Paths = []SpendingPath{analysis.MaxPath}
+		if !analysis.IsSegwit {
+			analysis.MaxScriptSig = analysis.GetPathSize(analysis.MaxPath)
+			return analysis, nil
+		}
+		analysis.MaxWitnessSize = analysis.GetPathSize(analysis.MaxPath)
+		if nestedSize > 0 {
+			analysis.MaxScriptSig = GetPushDataSize(nestedSize)
+		}
+		return analysis, nil
+	}
+
+	analysis.Miniscript, err = newMiniscriptFromExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+	if analysis.Miniscript.Type.Base != 'B' {
+		return nil, errors.New("top level miniscript must be B type")
+	}
+
+	analysis.Script, err = analysis.Miniscript.CompileScript(resolver)
+	analysis.IsResolved = err == nil
+	if err != nil {
+		// calculate the size with dummy keys.
+		if analysis.Script, err = analysis.Miniscript.CompileScript(dummyKeyResolver{}); err != nil {
+			return nil, err
+		}
+	}
+	analysis.OpsCount = GetOpsCount(analysis.Script)
+
+	sats, _ := analysis.Miniscript.Satisfactions()
+	if len(sats) == 0 {
+		return nil, errors.New("miniscript has no satisfaction")
+	}
+	analysis.Paths = dedupSpendingPaths(sats)
+	maxSize := -1
+	for _, path := range analysis.Paths {
+		if path.IsMixingLock {
+			continue
+		}
+		if size := analysis.GetPathSize(path); size > maxSize {
+			analysis.MaxPath = path
+			maxSize = size
+		}
+	}
+	if maxSize < 0 {
+		return nil, errors.New("miniscript has no satisfiable path")
+	}
+	if !analysis.IsSegwit {
+		analysis.MaxScriptSig = maxSize
+		return analysis, nil
+	}
+	analysis.MaxWitnessSize = maxSize
+	if nestedSize > 0 {
+		analysis.MaxScriptSig = GetPushDataSize(nestedSize)
+	}
+	return analysis, nil
+}
+
+// GetPathSize returns the witness size (segwit) or scriptsig size of the path.
+func (analysis *DescriptorAnalysis) GetPathSize(path SpendingPath) int {
+	if len(analysis.Script) > 0 {
+		if analysis.IsSegwit {
+			return path.GetWitnessSize(len(analysis.Script))
+		}
+		return path.GetScriptSigSize(len(analysis.Script))
+	}
+	// pkh, wpkh: stack items only
+	size := 0
+	for _, stackSize := range path.StackSizes {
+		if analysis.IsSegwit {
+			size += GetSerializeSize(stackSize)
+		} else {
+			size += GetPushDataSize(stackSize)
+		}
+	}
+	if analysis.IsSegwit {
+		size += GetVarIntSize(len(path.StackSizes))
+	}
+	return size
+}
+
+// dedupSpendingPaths removes the duplicate paths, and keeps the largest one.
+func dedupSpendingPaths(paths []SpendingPath) []SpendingPath {
+	result := []SpendingPath{}
+	indexMap := map[string]int{}
+	for _, path := range paths {
+		key := path.PathSummary()
+		index, ok := indexMap[key]
+		if !ok {
+			indexMap[key] = len(result)
+			result = append(result, path)
+			continue
+		}
+		if path.GetWitnessSize(0) > result[index].GetWitnessSize(0) {
+			result[index] = path
+		}
+	}
+	return result
+}
+
+// GetInputWeight returns the worst-case weight of the input.
+// (outpoint, sequence, scriptsig and witness. exclude empty witness of non-segwit input)
+func (analysis *DescriptorAnalysis) GetInputWeight(isElements bool) int {
+	baseSize := 32 + 4 + 4 + GetVarIntSize(analysis.MaxScriptSig) + analysis.MaxScriptSig
+	weight := baseSize * 4
+	if analysis.IsSegwit {
+		weight += analysis.MaxWitnessSize
+		if isElements {
+			// issuance amount rangeproof, inflation keys rangeproof, pegin witness
+			weight += 3
+		}
+	}
+	return weight
+}
+
+// CreateScriptsigTemplateFromDescriptor creates the worst-case scriptsig template
+// from the descriptor. Returns empty template if the descriptor is pkh/wpkh type.
+func CreateScriptsigTemplateFromDescriptor(descriptor string, networkType int) (template string, analysis *DescriptorAnalysis, err error) {
+	descriptor, err = ValidateDescriptorChecksum(descriptor)
+	if err != nil {
+		return "", nil, err
+	}
+	resolver := NewDescriptorKeyResolver(networkType, "0")
+	analysis, err = AnalyzeDescriptor(descriptor, resolver)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(analysis.Script) == 0 {
+		return "", analysis, nil
+	}
+	return CreateScriptsigTemplate(analysis.MaxPath.StackSizes, analysis.Script), analysis, nil
+}
@@ -0,0 +1,203 @@
+// Package txbuilder builds, signs and verifies the bitcoin and elements transactions.
+// The cfd-cli commands are the thin layer over this package.
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+)
+
+// InitializeTransactionRequest initialize transaction request.
+type InitializeTransactionRequest struct {
+	Version    uint32
+	Locktime   uint32
+	IsElements bool
+}
+
+// AppendTxInRequest append tx input request.
+// Utxo is added to the transaction data. (updated if the outpoint already exists)
+type AppendTxInRequest struct {
+	IsElements bool
+	// NetworkType the network type of the descriptor. (cfd network type)
+	NetworkType int
+	Sequence    uint32
+	Utxo        cache.UtxoData
+}
+
+// AppendTxInResponse append tx input response.
+type AppendTxInResponse struct {
+	// ScriptsigTemplate the template created from the descriptor. (empty if not created)
+	ScriptsigTemplate string
+	// Warnings the scriptsig template and the timelock warnings of the transaction.
+	Warnings []string
+}
+
+// AppendTxOutRequest append tx output request.
+type AppendTxOutRequest struct {
+	IsElements      bool
+	Amount          int64
+	Asset           string
+	Address         string
+	LockingScript   string
+	IsDestroyAmount bool
+	IsFee           bool
+}
+
+// InitializeTransaction returns a new transaction data with the empty transaction.
+func InitializeTransaction(req InitializeTransactionRequest) (*cache.TransactionCacheData, error) {
+	var handle uintptr
+	var err error
+	if req.IsElements {
+		handle, err = cfd.CfdGoInitializeConfidentialTransaction(req.Version, req.Locktime)
+	} else {
+		handle, err = cfd.CfdGoInitializeTransaction(req.Version, req.Locktime)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cfd.CfdGoFreeTransactionHandle(handle)
+
+	data := cache.NewTransactionCacheData()
+	data.Hex, err = cfd.CfdGoFinalizeTransaction(handle)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// AppendTxIn adds the input to the transaction data, and adds or updates the utxo data.
+func AppendTxIn(data *cache.TransactionCacheData, req AppendTxInRequest) (*AppendTxInResponse, error) {
+	utxo := req.Utxo
+	switch {
+	case data == nil || data.Hex == "":
+		return nil, errors.New("tx is required")
+	case len(utxo.Txid) != 64:
+		return nil, errors.New("txid size invalid")
+	case len(utxo.Asset) > 0 && len(utxo.Asset) != 64:
+		return nil, errors.New("asset size invalid")
+	case len(utxo.AssetBlinder) > 0 && len(utxo.AssetBlinder) != 64:
+		return nil, errors.New("asset blinder size invalid")
+	case len(utxo.AmountBlinder) > 0 && len(utxo.AmountBlinder) != 64:
+		return nil, errors.New("amount blinder size invalid")
+	case len(utxo.AssetCommitment) > 0 && len(utxo.AssetCommitment) != 66:
+		return nil, errors.New("asset commitment size invalid")
+	case len(utxo.AmountCommitment) > 0 && len(utxo.AmountCommitment) != 66:
+		return nil, errors.New("amount commitment size invalid")
+	}
+
+	response := &AppendTxInResponse{}
+	netType := req.NetworkType
+	if len(utxo.Descriptor) > 0 {
+		if _, _, err := cfd.CfdGoParseDescriptor(utxo.Descriptor, netType, ""); err != nil {
+			return nil, errors.New("descriptor is invalid. " + err.Error())
+		}
+		if utxo.ScriptsigTemplate == "" {
+			// worst-case template for script input. (pkh/wpkh is not needed)
+			// the descriptor that is not analyzable (addr, raw, etc.) is warned.
+			template, _, err := CreateScriptsigTemplateFromDescriptor(utxo.Descriptor, netType)
+			if err != nil {
+				response.Warnings = append(response.Warnings, fmt.Sprintf(
+					"input %s,%d scriptsig template is not created. %s", utxo.Txid, utxo.Vout, err.Error()))
+			} else if template != "" {
+				utxo.ScriptsigTemplate = template
+				response.ScriptsigTemplate = template
+			}
+		}
+	}
+
+	txHex := data.Hex
+	isFind := false
+	if _, err := cfd.CfdGoGetConfidentialTxInIndex(txHex, utxo.Txid, utxo.Vout); err == nil {
+		// already exist. tx not update.
+		isFind = true
+	} else {
+		var handle uintptr
+		if req.IsElements {
+			handle, err = cfd.CfdGoInitializeConfidentialTransactionByHex(txHex)
+		} else {
+			handle, err = cfd.CfdGoInitializeTransactionByHex(txHex)
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer cfd.CfdGoFreeTransactionHandle(handle)
+
+		if err = cfd.CfdGoAddTxInput(handle, utxo.Txid, utxo.Vout, req.Sequence); err != nil {
+			return nil, err
+		}
+		if txHex, err = cfd.CfdGoFinalizeTransaction(handle); err != nil {
+			return nil, err
+		}
+	}
+
+	data.Hex = txHex
+	isUpdate := false
+	if isFind {
+		// update utxo data
+		for index, utxoData := range data.Utxos {
+			if utxo.Txid == utxoData.Txid && utxo.Vout == utxoData.Vout {
+				data.Utxos[index] = utxo
+				isUpdate = true
+				break
+			}
+		}
+	}
+	if !isUpdate {
+		data.Utxos = append(data.Utxos, utxo)
+	}
+
+	if tx, err := DecodeTransaction(txHex, req.IsElements); err == nil {
+		response.Warnings = append(response.Warnings, ValidateTimelock(tx, data.Utxos, netType)...)
+	}
+	return response, nil
+}
+
+// AppendTxOut adds the output to the transaction data.
+func AppendTxOut(data *cache.TransactionCacheData, req AppendTxOutRequest) error {
+	if data == nil || data.Hex == "" {
+		return errors.New("tx is required")
+	}
+	if len(req.Asset) > 0 && len(req.Asset) != 64 {
+		return errors.New("asset size invalid")
+	}
+
+	var handle uintptr
+	var err error
+	if req.IsElements {
+		handle, err = cfd.CfdGoInitializeConfidentialTransactionByHex(data.Hex)
+	} else {
+		handle, err = cfd.CfdGoInitializeTransactionByHex(data.Hex)
+	}
+	if err != nil {
+		return err
+	}
+	defer cfd.CfdGoFreeTransactionHandle(handle)
+
+	switch {
+	case req.IsElements && req.IsFee:
+		err = cfd.CfdGoAddConfidentialTxOutputFee(handle, req.Asset, req.Amount)
+	case req.IsElements && req.IsDestroyAmount:
+		err = cfd.CfdGoAddConfidentialTxOutputDestroyAmount(handle, req.Asset, req.Amount)
+	case req.IsElements && len(req.LockingScript) > 0:
+		err = cfd.CfdGoAddConfidentialTxOutputByScript(handle, req.Asset, req.Amount, req.LockingScript)
+	case req.IsElements:
+		err = cfd.CfdGoAddConfidentialTxOutput(handle, req.Asset, req.Amount, req.Address)
+	case len(req.LockingScript) > 0:
+		err = cfd.CfdGoAddTxOutputByScript(handle, req.Amount, req.LockingScript)
+	default:
+		err = cfd.CfdGoAddTxOutput(handle, req.Amount, req.Address)
+	}
+	if err != nil {
+		return err
+	}
+	txHex, err := cfd.CfdGoFinalizeTransaction(handle)
+	if err != nil {
+		return err
+	}
+	data.Hex = txHex
+	return nil
+}
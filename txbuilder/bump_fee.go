@@ -0,0 +1,150 @@
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"cfd-cli/cache"
+)
+
+// dust amount of the change output.
+const dustAmount = int64(546)
+
+// BumpFeeOption bump fee option.
+type BumpFeeOption struct {
+	FeeRate        float64
+	IncrementalFee float64
+	ChangeIndex    int
+	NetworkType    int
+	Exponent       int64
+	MinimumBits    int64
+}
+
+// BumpFeeResult bump fee result.
+type BumpFeeResult struct {
+	Tx             *Transaction
+	OldFee         int64
+	NewFee         int64
+	OldVsize       int
+	NewVsize       int
+	AddedUtxos     []cache.UtxoData
+	StrippedInputs []int
+}
+
+// BumpFee raises the fee of the replaceable transaction.
+// The fee is paid from the change output (and additional utxos).
+func BumpFee(tx *Transaction, utxos, addUtxos []cache.UtxoData, option BumpFeeOption) (*BumpFeeResult, error) {
+	if !tx.IsReplaceable() {
+		return nil, errors.New("transaction does not signal BIP125 replaceability")
+	}
+	if option.ChangeIndex < 0 || option.ChangeIndex >= len(tx.TxOut) {
+		return nil, fmt.Errorf("change index %d is invalid", option.ChangeIndex)
+	}
+	change := tx.TxOut[option.ChangeIndex]
+	if tx.IsElements {
+		if change.IsFee() {
+			return nil, errors.New("change output must not be fee output")
+		}
+		for _, txout := range tx.TxOut {
+			if txout.IsBlinded() {
+				return nil, errors.New("blinded transaction is not supported. use the transaction before blinding")
+			}
+		}
+	}
+
+	report, err := CreateFeeReport(tx, utxos, FeeReportOption{
+		Exponent:    option.Exponent,
+		MinimumBits: option.MinimumBits,
+		NetworkType: option.NetworkType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !report.HasCurrentFee {
+		return nil, errors.New("current fee is unknown. set the utxo data of all inputs")
+	}
+	if tx.IsElements && !report.HasFeeOutput {
+		return nil, errors.New("fee output is not found")
+	}
+	for _, input := range report.Inputs {
+		if !input.IsSigned && !input.IsEstimated {
+			return nil, fmt.Errorf("input %s:%d size is unknown. set the descriptor", input.Txid, input.Vout)
+		}
+	}
+
+	result := &BumpFeeResult{
+		OldFee:   report.CurrentFee,
+		OldVsize: report.GetFinalVsize(),
+	}
+	newTx, err := DecodeTransaction(tx.Hex(), tx.IsElements)
+	if err != nil {
+		return nil, err
+	}
+	// the added inputs are estimated with the dummy signature, so the witness flag
+	// and the empty witness of the other inputs are counted if the tx had no witness.
+	estimatedTx := report.estimatedTx
+	weight := report.BlindedWeight
+	changeAmount := change.Amount
+	for {
+		vsize := GetVsizeFromWeight(weight)
+		newFee := int64(math.Ceil(float64(vsize) * option.FeeRate))
+		// BIP125 rule 4: pay for the bandwidth of the replacement.
+		minFee := result.OldFee + int64(math.Ceil(float64(vsize)*option.IncrementalFee))
+		if newFee < minFee {
+			newFee = minFee
+		}
+		delta := newFee - result.OldFee
+		if changeAmount-delta >= dustAmount {
+			result.NewFee = newFee
+			result.NewVsize = vsize
+			changeAmount -= delta
+			break
+		}
+		if len(addUtxos) == 0 {
+			return nil, fmt.Errorf("insufficient change amount. need %d more", delta+dustAmount-changeAmount)
+		}
+
+		utxo := addUtxos[0]
+		addUtxos = addUtxos[1:]
+		if _, err := tx.GetTxInIndex(utxo.Txid, utxo.Vout); err == nil {
+			continue
+		}
+		if tx.IsElements && utxo.Asset != "" && change.GetAsset() != utxo.Asset {
+			continue
+		}
+		_, analysis, err := CreateScriptsigTemplateFromDescriptor(utxo.Descriptor, option.NetworkType)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s:%d descriptor is invalid. %s", utxo.Txid, utxo.Vout, err.Error())
+		}
+		newTx.TxIn = append(newTx.TxIn, &TxIn{Txid: utxo.Txid, Vout: utxo.Vout, Sequence: SequenceMaxRbf})
+		estimatedTxIn := &TxIn{Txid: utxo.Txid, Vout: utxo.Vout, Sequence: SequenceMaxRbf}
+		setDummySignature(estimatedTxIn, analysis)
+		estimatedTx.TxIn = append(estimatedTx.TxIn, estimatedTxIn)
+		weight = estimatedTx.GetWeight()
+		changeAmount += utxo.Amount
+		result.AddedUtxos = append(result.AddedUtxos, utxo)
+	}
+
+	newChange := newTx.TxOut[option.ChangeIndex]
+	newChange.Amount = changeAmount
+	if tx.IsElements {
+		newChange.Value = NewConfidentialValue(changeAmount)
+		for _, txout := range newTx.TxOut {
+			if txout.IsFee() {
+				txout.Amount += result.NewFee - result.OldFee
+				txout.Value = NewConfidentialValue(txout.Amount)
+				break
+			}
+		}
+	}
+	for index, txin := range newTx.TxIn {
+		if txin.IsSigned() {
+			txin.ScriptSig = nil
+			txin.Witness = nil
+			result.StrippedInputs = append(result.StrippedInputs, index)
+		}
+	}
+	result.Tx = newTx
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package txbuilder
+
+import (
+	"strings"
+	"testing"
+
+	"cfd-cli/cache"
+)
+
+func TestBumpFeeAddSegwitInput(t *testing.T) {
+	txid := strings.Repeat("11", 32)
+	// the legacy transaction without witness. (signed p2pkh input)
+	tx := &Transaction{
+		Version: 2,
+		TxIn:    []*TxIn{{Txid: txid, Vout: 0, ScriptSig: make([]byte, 107), Sequence: SequenceMaxRbf}},
+		TxOut:   []*TxOut{{Amount: 1000, LockingScript: make([]byte, 25)}},
+	}
+	utxos := []cache.UtxoData{{Txid: txid, Vout: 0, Amount: 1100}}
+	addUtxos := []cache.UtxoData{{Txid: strings.Repeat("22", 32), Vout: 1, Amount: 100000,
+		Descriptor: "wpkh(0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798)"}}
+
+	result, err := BumpFee(tx, utxos, addUtxos, BumpFeeOption{FeeRate: 10, IncrementalFee: 1, ChangeIndex: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// base 192*4, added input 41*4, marker and flag 2, empty witness of the legacy input 1,
+	// witness of the added input 108 (count, signature and pubkey).
+	weight := 192*4 + 41*4 + 2 + 1 + 108
+	if result.OldVsize != 192 || result.NewVsize != GetVsizeFromWeight(weight) {
+		t.Errorf("vsize %d -> %d, want 192 -> %d", result.OldVsize, result.NewVsize, GetVsizeFromWeight(weight))
+	}
+	if result.NewFee != int64(GetVsizeFromWeight(weight))*10 || len(result.AddedUtxos) != 1 {
+		t.Errorf("fee %d, added %d", result.NewFee, len(result.AddedUtxos))
+	}
+	if len(result.Tx.TxIn) != 2 || result.Tx.TxIn[1].IsSigned() {
+		t.Errorf("added input %+v", result.Tx.TxIn)
+	}
+	if change := result.Tx.TxOut[0].Amount; change != 1000+100000-(result.NewFee-result.OldFee) {
+		t.Errorf("change %d", change)
+	}
+}
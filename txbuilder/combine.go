@@ -0,0 +1,169 @@
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+)
+
+// CombineTransactionData merges the signatures and the utxo data of the transaction data,
+// and finalizes the multisig inputs that the partial signatures reach the required number.
+func CombineTransactionData(dataList []*cache.TransactionCacheData, isElements bool, networkType int) (
+	*cache.TransactionCacheData, []TxInSignResult, error) {
+	if len(dataList) == 0 {
+		return nil, nil, errors.New("transaction data is empty")
+	}
+	baseTx, err := DecodeTransaction(dataList[0].Hex, isElements)
+	if err != nil {
+		return nil, nil, err
+	}
+	baseHex := getUnsignedTransactionHex(baseTx)
+
+	combined := &cache.TransactionCacheData{}
+	for index, data := range dataList {
+		tx, err := DecodeTransaction(data.Hex, isElements)
+		if err != nil {
+			return nil, nil, err
+		}
+		if getUnsignedTransactionHex(tx) != baseHex {
+			return nil, nil, fmt.Errorf("transaction data[%d] is different base transaction", index)
+		}
+		for txinIndex, txin := range tx.TxIn {
+			if txin.IsSigned() && !baseTx.TxIn[txinIndex].IsSigned() {
+				baseTx.TxIn[txinIndex].ScriptSig = txin.ScriptSig
+				baseTx.TxIn[txinIndex].Witness = txin.Witness
+			}
+		}
+		for _, utxo := range data.Utxos {
+			if err = mergeUtxoData(combined, utxo); err != nil {
+				return nil, nil, fmt.Errorf("transaction data[%d] %s", index, err.Error())
+			}
+		}
+	}
+	combined.Hex = baseTx.Hex()
+
+	results := make([]TxInSignResult, len(baseTx.TxIn))
+	for index, txin := range baseTx.TxIn {
+		results[index] = TxInSignResult{Txid: txin.Txid, Vout: txin.Vout}
+		var utxo *cache.UtxoData
+		for utxoIndex := range combined.Utxos {
+			if combined.Utxos[utxoIndex].Txid == txin.Txid && combined.Utxos[utxoIndex].Vout == txin.Vout {
+				utxo = &combined.Utxos[utxoIndex]
+			}
+		}
+		switch {
+		case txin.IsSigned():
+			results[index].Status = "already signed"
+			if utxo != nil {
+				utxo.PartialSigs = nil
+			}
+			continue
+		case utxo == nil || len(utxo.PartialSigs) == 0:
+			results[index].Status = "unsigned"
+			continue
+		case utxo.Descriptor == "":
+			results[index].Status = "partial"
+			results[index].Detail = "utxo descriptor is not found"
+			continue
+		}
+		_, redeemScript, hashType, _, err := ParseDescriptor(utxo.Descriptor, networkType)
+		if err != nil {
+			return nil, nil, err
+		}
+		count := len(utxo.PartialSigs)
+		txHex, isComplete, err := SignMultisigInput(combined.Hex, isElements, networkType, utxo,
+			hashType, redeemScript, nil, SighashType{Type: int(cfd.KCfdSigHashAll)}, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		combined.Hex = txHex
+		if isComplete {
+			results[index].Status = "complete"
+		} else {
+			results[index].Status = "partial"
+			results[index].Detail = fmt.Sprintf("%d signatures", count)
+		}
+	}
+	return combined, results, nil
+}
+
+// mergeUtxoData merges the utxo into the transaction data.
+// It returns error if the utxo data is mismatched.
+func mergeUtxoData(data *cache.TransactionCacheData, utxo cache.UtxoData) error {
+	for index := range data.Utxos {
+		current := &data.Utxos[index]
+		if current.Txid != utxo.Txid || current.Vout != utxo.Vout {
+			continue
+		}
+		fields := []struct {
+			name           string
+			current, other *string
+		}{
+			{"asset", &current.Asset, &utxo.Asset},
+			{"assetblinder", &current.AssetBlinder, &utxo.AssetBlinder},
+			{"assetcommitment", &current.AssetCommitment, &utxo.AssetCommitment},
+			{"blinder", &current.AmountBlinder, &utxo.AmountBlinder},
+			{"amountcommitment", &current.AmountCommitment, &utxo.AmountCommitment},
+			{"descriptor", &current.Descriptor, &utxo.Descriptor},
+			{"scriptsigTemplate", &current.ScriptsigTemplate, &utxo.ScriptsigTemplate},
+		}
+		for _, field := range fields {
+			switch {
+			case *field.other == "":
+			case *field.current == "":
+				*field.current = *field.other
+			case *field.current != *field.other:
+				return fmt.Errorf("utxo %s,%d %s is mismatched", utxo.Txid, utxo.Vout, field.name)
+			}
+		}
+		switch {
+		case utxo.Amount == 0:
+		case current.Amount == 0:
+			current.Amount = utxo.Amount
+		case current.Amount != utxo.Amount:
+			return fmt.Errorf("utxo %s,%d amount is mismatched", utxo.Txid, utxo.Vout)
+		}
+		for pubkey, signature := range utxo.PartialSigs {
+			if current.PartialSigs == nil {
+				current.PartialSigs = map[string]string{}
+			}
+			if currentSignature, ok := current.PartialSigs[pubkey]; !ok {
+				current.PartialSigs[pubkey] = signature
+			} else if currentSignature != signature {
+				return fmt.Errorf("utxo %s,%d partial signature %s is mismatched", utxo.Txid, utxo.Vout, pubkey)
+			}
+		}
+		return nil
+	}
+	utxo.PartialSigs = copyPartialSigs(utxo.PartialSigs)
+	data.Utxos = append(data.Utxos, utxo)
+	return nil
+}
+
+// copyPartialSigs returns the copy of the partial signatures.
+func copyPartialSigs(partialSigs map[string]string) map[string]string {
+	if partialSigs == nil {
+		return nil
+	}
+	result := make(map[string]string, len(partialSigs))
+	for pubkey, signature := range partialSigs {
+		result[pubkey] = signature
+	}
+	return result
+}
+
+// getUnsignedTransactionHex returns the transaction hex without scriptsig and witness.
+func getUnsignedTransactionHex(tx *Transaction) string {
+	unsigned := *tx
+	unsigned.TxIn = make([]*TxIn, len(tx.TxIn))
+	for index, txin := range tx.TxIn {
+		txinCopy := *txin
+		txinCopy.ScriptSig = nil
+		txinCopy.Witness = nil
+		unsigned.TxIn[index] = &txinCopy
+	}
+	return unsigned.Hex()
+}
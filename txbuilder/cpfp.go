@@ -0,0 +1,150 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+)
+
+// CpfpOption cpfp option.
+type CpfpOption struct {
+	Vout        uint32
+	Descriptor  string
+	Amount      int64
+	Asset       string
+	Address     string
+	FeeRate     float64
+	ParentFee   int64
+	NetworkType int
+}
+
+// CpfpResult cpfp result.
+type CpfpResult struct {
+	Tx           *Transaction
+	Utxo         cache.UtxoData
+	ParentFee    int64
+	ParentVsize  int
+	ChildFee     int64
+	ChildVsize   int
+	PackageVsize int
+}
+
+// CreateCpfpTransaction creates the child transaction that spends the parent output
+// with the fee to reach the target package fee rate.
+func CreateCpfpTransaction(parentTx *Transaction, parentUtxos []cache.UtxoData, option CpfpOption) (*CpfpResult, error) {
+	if int(option.Vout) >= len(parentTx.TxOut) {
+		return nil, fmt.Errorf("vout %d is out of range", option.Vout)
+	}
+	parentOut := parentTx.TxOut[option.Vout]
+	utxo := cache.UtxoData{
+		Txid:       parentTx.Txid(),
+		Vout:       option.Vout,
+		Amount:     option.Amount,
+		Asset:      option.Asset,
+		Descriptor: option.Descriptor,
+	}
+	if utxo.Amount < 0 {
+		if parentTx.IsElements && parentOut.IsBlinded() {
+			return nil, errors.New("parent output is blinded. amount is required")
+		}
+		utxo.Amount = parentOut.Amount
+	}
+	if parentTx.IsElements {
+		if utxo.Asset == "" {
+			utxo.Asset = parentOut.GetAsset()
+		}
+		if utxo.Asset == "" {
+			return nil, errors.New("parent output asset is blinded. asset is required")
+		}
+		if parentOut.IsBlinded() {
+			utxo.AmountCommitment = hex.EncodeToString(parentOut.Value)
+			utxo.AssetCommitment = hex.EncodeToString(parentOut.Asset)
+		}
+	}
+
+	parentReport, err := CreateFeeReport(parentTx, parentUtxos, FeeReportOption{
+		MinimumBits: 52, NetworkType: option.NetworkType})
+	if err != nil {
+		return nil, err
+	}
+	result := &CpfpResult{Utxo: utxo, ParentFee: option.ParentFee, ParentVsize: parentReport.GetFinalVsize()}
+	if result.ParentFee < 0 {
+		if !parentReport.HasCurrentFee {
+			return nil, errors.New("parent fee is unknown. set the parentfee")
+		}
+		result.ParentFee = parentReport.CurrentFee
+	}
+
+	// estimate child size with the placeholder amount.
+	childTx, err := createCpfpChildTransaction(parentTx.IsElements, utxo, option.Address, utxo.Amount, 0)
+	if err != nil {
+		return nil, err
+	}
+	childReport, err := CreateFeeReport(childTx, []cache.UtxoData{utxo}, FeeReportOption{
+		MinimumBits: 52, NetworkType: option.NetworkType})
+	if err != nil {
+		return nil, err
+	}
+	if !childReport.Inputs[0].IsEstimated {
+		return nil, errors.New("descriptor is invalid for the size estimation")
+	}
+	result.ChildVsize = childReport.GetFinalVsize()
+	result.PackageVsize = result.ParentVsize + result.ChildVsize
+
+	packageFee := int64(math.Ceil(float64(result.PackageVsize) * option.FeeRate))
+	result.ChildFee = packageFee - result.ParentFee
+	if minFee := int64(math.Ceil(float64(result.ChildVsize) * option.FeeRate)); result.ChildFee < minFee {
+		// parent already reaches the target. child pays only own fee.
+		result.ChildFee = minFee
+	}
+	if utxo.Amount-result.ChildFee < dustAmount {
+		return nil, fmt.Errorf("insufficient amount. output amount %d is less than fee %d + dust",
+			utxo.Amount, result.ChildFee)
+	}
+
+	result.Tx, err = createCpfpChildTransaction(parentTx.IsElements, utxo,
+		option.Address, utxo.Amount-result.ChildFee, result.ChildFee)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createCpfpChildTransaction creates the child transaction.
+func createCpfpChildTransaction(isElements bool, utxo cache.UtxoData, address string, amount, fee int64) (*Transaction, error) {
+	var handle uintptr
+	var err error
+	if isElements {
+		handle, err = cfd.CfdGoInitializeConfidentialTransaction(uint32(2), uint32(0))
+	} else {
+		handle, err = cfd.CfdGoInitializeTransaction(uint32(2), uint32(0))
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer cfd.CfdGoFreeTransactionHandle(handle)
+
+	if err = cfd.CfdGoAddTxInput(handle, utxo.Txid, utxo.Vout, SequenceMaxRbf); err != nil {
+		return nil, err
+	}
+	if isElements {
+		if err = cfd.CfdGoAddConfidentialTxOutput(handle, utxo.Asset, amount, address); err != nil {
+			return nil, err
+		}
+		if err = cfd.CfdGoAddConfidentialTxOutputFee(handle, utxo.Asset, fee); err != nil {
+			return nil, err
+		}
+	} else if err = cfd.CfdGoAddTxOutput(handle, amount, address); err != nil {
+		return nil, err
+	}
+	txHex, err := cfd.CfdGoFinalizeTransaction(handle)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeTransaction(txHex, isElements)
+}
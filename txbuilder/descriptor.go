@@ -0,0 +1,363 @@
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+)
+
+const (
+	descriptorInputCharset = "0123456789()[],'/*abcdefgh@:$%{}" +
+		"IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~" +
+		"ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// hexRegexp matches a hex string.
+var hexRegexp = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// descriptorKeyRegexp matches a descriptor key expression.
+// (origin, key, derive path)
+var descriptorKeyRegexp = regexp.MustCompile(
+	`(\[[0-9a-fA-F]{8}(?:/[0-9]+['h]?)*\])?([1-9A-HJ-NP-Za-km-z]{50,112})((?:/(?:[0-9]+['h]?|\*['h]?|<[0-9;'h]+>))*)`)
+
+// GetHashTypeString returns the hash type name.
+func GetHashTypeString(hashType int) string {
+	switch hashType {
+	case int(cfd.KCfdP2pkh):
+		return "p2pkh"
+	case int(cfd.KCfdP2sh):
+		return "p2sh"
+	case int(cfd.KCfdP2wpkh):
+		return "p2wpkh"
+	case int(cfd.KCfdP2wsh):
+		return "p2wsh"
+	case int(cfd.KCfdP2shP2wpkh):
+		return "p2sh-p2wpkh"
+	case int(cfd.KCfdP2shP2wsh):
+		return "p2sh-p2wsh"
+	default:
+		return ""
+	}
+}
+
+// GetDescriptorKeyPubkey returns the (derived) pubkey of a descriptor key.
+func GetDescriptorKeyPubkey(keyType int, pubkey, extPubkey, extPrivkey string) string {
+	if len(pubkey) > 0 {
+		return pubkey
+	}
+	extkey := ""
+	switch keyType {
+	case int(cfd.KCfdDescriptorKeyBip32):
+		extkey = extPubkey
+	case int(cfd.KCfdDescriptorKeyBip32Priv):
+		extkey = extPrivkey
+	default:
+		return ""
+	}
+	key, err := cfd.CfdGoGetPubkeyFromExtkey(extkey, int(cfd.KCfdNetworkMainnet))
+	if err != nil {
+		key, err = cfd.CfdGoGetPubkeyFromExtkey(extkey, int(cfd.KCfdNetworkTestnet))
+	}
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// ParseNetworkType parse network type name.
+func ParseNetworkType(nettype string) (networkType int, err error) {
+	switch nettype {
+	case "mainnet":
+		networkType = int(cfd.KCfdNetworkMainnet)
+	case "testnet":
+		networkType = int(cfd.KCfdNetworkTestnet)
+	case "regtest":
+		networkType = int(cfd.KCfdNetworkRegtest)
+	case "liquidv1":
+		networkType = int(cfd.KCfdNetworkLiquidv1)
+	case "liquidregtest":
+		networkType = int(cfd.KCfdNetworkElementsRegtest)
+	case "elementsregtest":
+		networkType = int(cfd.KCfdNetworkElementsRegtest)
+	default:
+		return -1, fmt.Errorf("nettype %s is unknown type", nettype)
+	}
+	return networkType, nil
+}
+
+// GetNetworkType returns the network type. If empty, mainnet or liquidv1.
+func GetNetworkType(nettype string, isElements bool) (int, error) {
+	if nettype != "" {
+		return ParseNetworkType(nettype)
+	}
+	if isElements {
+		return int(cfd.KCfdNetworkLiquidv1), nil
+	}
+	return int(cfd.KCfdNetworkMainnet), nil
+}
+
+// GetDescriptorChecksum calculate descriptor checksum. (BIP-380)
+func GetDescriptorChecksum(descriptor string) (checksum string, err error) {
+	polymod := func(c uint64, value int) uint64 {
+		c0 := c >> 35
+		c = ((c & 0x7ffffffff) << 5) ^ uint64(value)
+		for i, gen := range [...]uint64{0xf5dee51989, 0xa9fdca3312,
+			0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+			if (c0>>uint(i))&1 != 0 {
+				c ^= gen
+			}
+		}
+		return c
+	}
+
+	c := uint64(1)
+	cls := 0
+	clsCount := 0
+	for _, ch := range descriptor {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("descriptor contains invalid character. '%c'", ch)
+		}
+		c = polymod(c, pos&31)
+		cls = cls*3 + (pos >> 5)
+		clsCount++
+		if clsCount == 3 {
+			c = polymod(c, cls)
+			cls = 0
+			clsCount = 0
+		}
+	}
+	if clsCount > 0 {
+		c = polymod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = polymod(c, 0)
+	}
+	c ^= 1
+
+	result := make([]byte, 8)
+	for i := 0; i < 8; i++ {
+		result[i] = descriptorChecksumCharset[(c>>(5*uint(7-i)))&31]
+	}
+	return string(result), nil
+}
+
+// ValidateDescriptorChecksum validate descriptor checksum if exists,
+// and returns the descriptor without checksum.
+func ValidateDescriptorChecksum(descriptor string) (string, error) {
+	descriptor = strings.TrimSpace(descriptor)
+	index := strings.LastIndex(descriptor, "#")
+	if index < 0 {
+		return descriptor, nil
+	}
+	body := descriptor[:index]
+	checksum, err := GetDescriptorChecksum(body)
+	if err != nil {
+		return "", err
+	}
+	if descriptor[index+1:] != checksum {
+		return "", fmt.Errorf("descriptor checksum unmatch. expected: %s", checksum)
+	}
+	return body, nil
+}
+
+// ExpandMultipathDescriptor expands multipath key expressions (BIP-389).
+// ex) wpkh(xpub/<0;1>/*) -> wpkh(xpub/0/*), wpkh(xpub/1/*)
+func ExpandMultipathDescriptor(descriptor string) (descriptors []string, err error) {
+	multipathRegexp := regexp.MustCompile(`<([^<>]*)>`)
+	matches := multipathRegexp.FindAllStringSubmatch(descriptor, -1)
+	if len(matches) == 0 {
+		return []string{descriptor}, nil
+	}
+
+	pathCount := 0
+	for _, match := range matches {
+		paths := strings.Split(match[1], ";")
+		if len(paths) < 2 {
+			return nil, fmt.Errorf("multipath invalid. %s", match[0])
+		}
+		if pathCount != 0 && pathCount != len(paths) {
+			return nil, errors.New("multipath count unmatch")
+		}
+		pathCount = len(paths)
+	}
+
+	for i := 0; i < pathCount; i++ {
+		desc := multipathRegexp.ReplaceAllStringFunc(descriptor, func(match string) string {
+			return strings.Split(match[1:len(match)-1], ";")[i]
+		})
+		descriptors = append(descriptors, desc)
+	}
+	return descriptors, nil
+}
+
+// ConvertDescriptorToPublic converts private keys (xprv, wif) to public keys.
+// If the key has hardened derivation, it is derived with the private key.
+func ConvertDescriptorToPublic(descriptor string, networkType int) (string, error) {
+	var convertErr error
+	result := descriptorKeyRegexp.ReplaceAllStringFunc(descriptor, func(match string) string {
+		if convertErr != nil {
+			return match
+		}
+		parts := descriptorKeyRegexp.FindStringSubmatch(match)
+		key, err := convertDescriptorKeyToPublic(parts[1], parts[2], parts[3], networkType)
+		if err != nil {
+			convertErr = err
+			return match
+		}
+		return key
+	})
+	if convertErr != nil {
+		return "", convertErr
+	}
+	return result, nil
+}
+
+// convertDescriptorKeyToPublic converts a private key expression to public.
+func convertDescriptorKeyToPublic(origin, key, path string, networkType int) (string, error) {
+	keyNetType := int(cfd.KCfdNetworkMainnet)
+	if networkType != int(cfd.KCfdNetworkMainnet) && networkType != int(cfd.KCfdNetworkLiquidv1) {
+		keyNetType = int(cfd.KCfdNetworkTestnet)
+	}
+
+	if !strings.HasPrefix(key, "xprv") && !strings.HasPrefix(key, "tprv") {
+		if (len(key) != 51 && len(key) != 52) ||
+			!strings.ContainsAny(key[:1], "5KL9c") || hexRegexp.MatchString(key) {
+			// public key or address
+			return origin + key + path, nil
+		}
+		pubkey, err := cfd.CfdGoGetPubkeyFromPrivkey("", key, len(key) == 52)
+		if err != nil {
+			return "", err
+		}
+		return origin + pubkey + path, nil
+	}
+
+	// split the path at the last hardened derivation.
+	steps := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if path == "" {
+		steps = []string{}
+	}
+	hardenedNum := 0
+	for i, step := range steps {
+		if step != "*'" && step != "*h" &&
+			(strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h")) {
+			hardenedNum = i + 1
+		}
+	}
+
+	extkey := key
+	if hardenedNum > 0 {
+		hardenedPath := strings.Join(steps[:hardenedNum], "/")
+		info, err := cfd.CfdGoGetExtkeyInformation(key)
+		if err != nil {
+			return "", err
+		}
+		if info.Depth == 0 {
+			// fingerprint of the master key equals the parent fingerprint of its child.
+			child, err := cfd.CfdGoCreateExtkeyFromParentPath(
+				key, "0", keyNetType, int(cfd.KCfdExtPrivkey))
+			if err != nil {
+				return "", err
+			}
+			childInfo, err := cfd.CfdGoGetExtkeyInformation(child)
+			if err != nil {
+				return "", err
+			}
+			if origin == "" {
+				origin = "[" + childInfo.Fingerprint + "]"
+			}
+		}
+		if origin == "" {
+			return "", errors.New("hardened derivation from private key requires key origin")
+		}
+		origin = origin[:len(origin)-1] + "/" + hardenedPath + "]"
+		extkey, err = cfd.CfdGoCreateExtkeyFromParentPath(
+			key, hardenedPath, keyNetType, int(cfd.KCfdExtPrivkey))
+		if err != nil {
+			return "", err
+		}
+		steps = steps[hardenedNum:]
+	}
+	for _, step := range steps {
+		if step == "*'" || step == "*h" {
+			return "", errors.New("hardened wildcard cannot be converted to public key")
+		}
+	}
+
+	xpub, err := cfd.CfdGoCreateExtPubkey(extkey, keyNetType)
+	if err != nil {
+		return "", err
+	}
+	if len(steps) > 0 {
+		return origin + xpub + "/" + strings.Join(steps, "/"), nil
+	}
+	return origin + xpub, nil
+}
+
+// GetDescriptorInfoFromUtxoList get descriptor info.
+func GetDescriptorInfoFromUtxoList(txid string, vout uint32, utxoList []cache.UtxoData, networkType int) (
+	pubkey, redeemScript string, hashType int, amount int64,
+	amountCommitment string, err error) {
+	hashType = -1
+	if utxoList != nil {
+		desc := ""
+		for _, utxo := range utxoList {
+			if utxo.Txid == txid && utxo.Vout == vout {
+				amount = utxo.Amount
+				amountCommitment = utxo.AmountCommitment
+				desc = utxo.Descriptor
+				break
+			}
+		}
+		if len(desc) > 0 {
+			pubkey, redeemScript, hashType, _, err = ParseDescriptor(desc, networkType)
+			if err != nil {
+				return "", "", -1, -1, "", err
+			}
+		}
+	}
+	return pubkey, redeemScript, hashType, amount, amountCommitment, nil
+}
+
+// ParseDescriptor parse descriptor
+func ParseDescriptor(descriptor string, networkType int) (pubkey, redeemScript string, hashType int, address string, err error) {
+	descList, _, err := cfd.CfdGoParseDescriptor(
+		descriptor, networkType, "")
+	if err != nil {
+		return "", "", -1, "", err
+	}
+	hashType = descList[0].HashType
+	address = descList[0].Address
+	redeemScript = descList[len(descList)-1].RedeemScript
+	extkey := ""
+	switch descList[len(descList)-1].KeyType {
+	case int(cfd.KCfdDescriptorKeyPublic):
+		pubkey = descList[len(descList)-1].Pubkey
+	case int(cfd.KCfdDescriptorKeyBip32):
+		extkey = descList[len(descList)-1].ExtPubkey
+	case int(cfd.KCfdDescriptorKeyBip32Priv):
+		extkey = descList[len(descList)-1].ExtPrivkey
+	default:
+		// do nothing
+	}
+	if len(extkey) > 0 {
+		key, err := cfd.CfdGoGetPubkeyFromExtkey(
+			extkey, int(cfd.KCfdNetworkMainnet))
+		if err != nil {
+			key, err = cfd.CfdGoGetPubkeyFromExtkey(
+				extkey, int(cfd.KCfdNetworkTestnet))
+		}
+		if err != nil {
+			return "", "", -1, "", err
+		}
+		pubkey = key
+	}
+	return pubkey, redeemScript, hashType, address, nil
+}
@@ -0,0 +1,212 @@
+package txbuilder
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"cfd-cli/cache"
+)
+
+// elements surjection proof max inputs
+const surjectionProofMaxInputs = 3
+
+// FeeReportOption fee report option.
+type FeeReportOption struct {
+	Exponent    int64
+	MinimumBits int64
+	NetworkType int
+}
+
+// FeeReport fee report data.
+type FeeReport struct {
+	BaseSize      int
+	WitnessSize   int
+	Weight        int
+	Vsize         int
+	SignedWeight  int
+	SignedVsize   int
+	BlindedWeight int
+	BlindedVsize  int
+	HasFeeOutput  bool
+	CurrentFee    int64
+	HasCurrentFee bool
+	Inputs        []InputFeeReport
+	Outputs       []OutputFeeReport
+	// estimatedTx the signed and blinded transaction with the dummy data.
+	estimatedTx *Transaction
+}
+
+// InputFeeReport input fee report data.
+type InputFeeReport struct {
+	Txid        string
+	Vout        uint32
+	Weight      int
+	IsSigned    bool
+	IsEstimated bool
+}
+
+// OutputFeeReport output fee report data.
+type OutputFeeReport struct {
+	Amount        int64
+	Weight        int
+	BlindedWeight int
+	IsFee         bool
+	IsBlindTarget bool
+}
+
+// CreateFeeReport creates the fee report from the transaction and utxos.
+func CreateFeeReport(tx *Transaction, utxos []cache.UtxoData, option FeeReportOption) (*FeeReport, error) {
+	report := &FeeReport{
+		BaseSize: tx.GetBaseSize(),
+		Weight:   tx.GetWeight(),
+		Vsize:    tx.GetVsize(),
+	}
+	report.WitnessSize = tx.GetTotalSize() - report.BaseSize
+
+	// estimate signed transaction with dummy scriptsig/witness.
+	signedTx, err := DecodeTransaction(tx.Hex(), tx.IsElements)
+	if err != nil {
+		return nil, err
+	}
+	utxoMap := map[string]cache.UtxoData{}
+	for _, utxo := range utxos {
+		utxoMap[fmt.Sprintf("%s:%d", utxo.Txid, utxo.Vout)] = utxo
+	}
+	var inputAmount int64
+	isKnownAllInputs := true
+	for _, txin := range signedTx.TxIn {
+		input := InputFeeReport{Txid: txin.Txid, Vout: txin.Vout, IsSigned: txin.IsSigned()}
+		utxo, ok := utxoMap[fmt.Sprintf("%s:%d", txin.Txid, txin.Vout)]
+		if !ok {
+			isKnownAllInputs = false
+		} else {
+			inputAmount += utxo.Amount
+		}
+		if !input.IsSigned && ok && utxo.Descriptor != "" {
+			_, analysis, err := CreateScriptsigTemplateFromDescriptor(utxo.Descriptor, option.NetworkType)
+			if err == nil {
+				setDummySignature(txin, analysis)
+				input.IsEstimated = true
+			}
+		}
+		report.Inputs = append(report.Inputs, input)
+	}
+
+	if tx.IsElements {
+		for _, txout := range signedTx.TxOut {
+			if txout.IsFee() {
+				report.HasFeeOutput = true
+				report.HasCurrentFee = true
+				report.CurrentFee += txout.Amount
+			}
+		}
+		if !report.HasFeeOutput {
+			// fee output is added by the fee setting.
+			signedTx.TxOut = append(signedTx.TxOut, &TxOut{
+				Asset: append([]byte{1}, make([]byte, 32)...), Value: NewConfidentialValue(0), Nonce: []byte{}})
+		}
+	} else if isKnownAllInputs && len(tx.TxIn) > 0 {
+		var outputAmount int64
+		for _, txout := range tx.TxOut {
+			outputAmount += txout.Amount
+		}
+		report.CurrentFee = inputAmount - outputAmount
+		report.HasCurrentFee = true
+	}
+	report.SignedWeight = signedTx.GetWeight()
+	report.SignedVsize = signedTx.GetVsize()
+	for index, txin := range signedTx.TxIn {
+		report.Inputs[index].Weight = txin.GetWeight(tx.IsElements)
+	}
+
+	// estimate blinded transaction.
+	blindedTx, err := DecodeTransaction(signedTx.Hex(), tx.IsElements)
+	if err != nil {
+		return nil, err
+	}
+	for index, txout := range blindedTx.TxOut {
+		output := OutputFeeReport{
+			Amount: txout.Amount,
+			Weight: signedTx.TxOut[index].GetWeight(tx.IsElements),
+			IsFee:  txout.IsFee(),
+		}
+		if tx.IsElements && !txout.IsFee() && !txout.IsBlinded() && len(txout.Nonce) == 33 {
+			output.IsBlindTarget = true
+			txout.Value = make([]byte, 33)
+			txout.SurjectionProof = make([]byte, GetSurjectionProofSize(len(blindedTx.TxIn)))
+			txout.Rangeproof = make([]byte, GetRangeproofSize(
+				txout.Amount, option.Exponent, option.MinimumBits))
+		}
+		output.BlindedWeight = txout.GetWeight(tx.IsElements)
+		report.Outputs = append(report.Outputs, output)
+	}
+	report.BlindedWeight = blindedTx.GetWeight()
+	report.BlindedVsize = blindedTx.GetVsize()
+	report.estimatedTx = blindedTx
+	return report, nil
+}
+
+// setDummySignature sets the dummy scriptsig and witness of the worst path.
+func setDummySignature(txin *TxIn, analysis *DescriptorAnalysis) {
+	if analysis.MaxScriptSig > 0 {
+		txin.ScriptSig = make([]byte, analysis.MaxScriptSig)
+	}
+	if !analysis.IsSegwit {
+		return
+	}
+	txin.Witness = [][]byte{}
+	for _, stackSize := range analysis.MaxPath.StackSizes {
+		txin.Witness = append(txin.Witness, make([]byte, stackSize))
+	}
+	if len(analysis.Script) > 0 {
+		txin.Witness = append(txin.Witness, analysis.Script)
+	}
+}
+
+// GetFinalVsize returns the vsize of the final (signed and blinded) transaction.
+func (report *FeeReport) GetFinalVsize() int {
+	return report.BlindedVsize
+}
+
+// GetRequiredFee returns the fee of the final transaction with the fee rate.
+func (report *FeeReport) GetRequiredFee(feeRate float64) int64 {
+	return int64(math.Ceil(float64(report.GetFinalVsize()) * feeRate))
+}
+
+// GetFeeRate returns the effective fee rate (sat/vB) of the fee.
+func (report *FeeReport) GetFeeRate(fee int64) float64 {
+	if report.GetFinalVsize() == 0 {
+		return 0
+	}
+	return float64(fee) / float64(report.GetFinalVsize())
+}
+
+// GetSurjectionProofSize returns the surjection proof size.
+func GetSurjectionProofSize(inputNum int) int {
+	usedNum := inputNum
+	if usedNum > surjectionProofMaxInputs {
+		usedNum = surjectionProofMaxInputs
+	}
+	return 2 + (inputNum+7)/8 + 32*(1+usedNum)
+}
+
+// GetRangeproofSize returns the rangeproof size.
+func GetRangeproofSize(amount, exponent, minimumBits int64) int {
+	value := uint64(amount)
+	for i := int64(0); i < exponent && value > 0; i++ {
+		value /= 10
+	}
+	mantissa := 64 - bits.LeadingZeros64(value)
+	if mantissa < int(minimumBits) {
+		mantissa = int(minimumBits)
+	}
+	if mantissa > 64 {
+		mantissa = 64
+	} else if mantissa < 1 {
+		mantissa = 1
+	}
+	rings := (mantissa + 1) / 2
+	npubs := rings*4 - 2*(mantissa%2)
+	return 10 + 32*(npubs+rings-1) + 32 + (rings+6)/8
+}
@@ -0,0 +1,137 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+)
+
+// HtlcScript hash-time-locked contract script data.
+// andor(pk(receiver),sha256(hash),and_v(v:pk(refund),older(timeout)|after(timeout)))
+type HtlcScript struct {
+	Hash           []byte
+	ReceiverPubkey []byte
+	RefundPubkey   []byte
+	Timeout        uint32
+	IsAbsolute     bool
+}
+
+// Miniscript returns the HTLC miniscript.
+func (htlc *HtlcScript) Miniscript() string {
+	timelock := fmt.Sprintf("older(%d)", htlc.Timeout)
+	if htlc.IsAbsolute {
+		timelock = fmt.Sprintf("after(%d)", htlc.Timeout)
+	}
+	return fmt.Sprintf("andor(pk(%s),sha256(%s),and_v(v:pk(%s),%s))",
+		hex.EncodeToString(htlc.ReceiverPubkey), hex.EncodeToString(htlc.Hash),
+		hex.EncodeToString(htlc.RefundPubkey), timelock)
+}
+
+// Script returns the HTLC witness script.
+func (htlc *HtlcScript) Script() ([]byte, error) {
+	miniscript, err := ParseMiniscript(htlc.Miniscript())
+	if err != nil {
+		return nil, err
+	}
+	// keys are hex pubkeys. (network is not used)
+	return miniscript.CompileScript(NewDescriptorKeyResolver(int(cfd.KCfdNetworkMainnet), ""))
+}
+
+// ParseHtlcScript parses the HTLC witness script created by createhtlc.
+func ParseHtlcScript(script []byte) (*HtlcScript, error) {
+	elements, err := ParseScriptBytes(script)
+	if err != nil {
+		return nil, err
+	}
+	invalidErr := errors.New("script is not HTLC script")
+	if len(elements) != 15 {
+		return nil, invalidErr
+	}
+	opcodes := map[int]byte{
+		1: OpCheckSig, 2: OpNotIf, 4: OpCheckSigVerify, 7: OpElse, 8: OpSize,
+		10: OpEqualVerify, 11: OpSha256, 13: OpEqual, 14: OpEndIf,
+	}
+	for index, opcode := range opcodes {
+		if elements[index].IsPush || elements[index].Opcode != opcode {
+			return nil, invalidErr
+		}
+	}
+	if !elements[0].IsPush || len(elements[0].Data) != 33 ||
+		!elements[3].IsPush || len(elements[3].Data) != 33 ||
+		!elements[12].IsPush || len(elements[12].Data) != 32 {
+		return nil, invalidErr
+	}
+	if size, err := GetElementNumber(elements[9], 4); err != nil || size != 32 {
+		return nil, invalidErr
+	}
+	htlc := &HtlcScript{
+		ReceiverPubkey: elements[0].Data,
+		RefundPubkey:   elements[3].Data,
+		Hash:           elements[12].Data,
+	}
+	switch elements[6].Opcode {
+	case OpCheckLockTimeVerify:
+		htlc.IsAbsolute = true
+	case OpCheckSequenceVerify:
+	default:
+		return nil, invalidErr
+	}
+	timeout, err := GetElementNumber(elements[5], 5)
+	if err != nil || timeout <= 0 || timeout > int64(^uint32(0)) {
+		return nil, invalidErr
+	}
+	htlc.Timeout = uint32(timeout)
+	return htlc, nil
+}
+
+// GetHtlcHashType returns the hash type of HTLC script type.
+func GetHtlcHashType(scriptType string) (int, error) {
+	switch scriptType {
+	case "wsh":
+		return int(cfd.KCfdP2wsh), nil
+	case "sh-wsh":
+		return int(cfd.KCfdP2shP2wsh), nil
+	}
+	return -1, fmt.Errorf("type %s is unknown type", scriptType)
+}
+
+// CreateHtlcSpendTransaction creates the HTLC spending transaction. (1 input, 1 output)
+func CreateHtlcSpendTransaction(isElements bool, locktime uint32, txid string, vout, sequence uint32,
+	address, asset string, amount, fee int64) (string, error) {
+	var handle uintptr
+	var err error
+	if isElements {
+		handle, err = cfd.CfdGoInitializeConfidentialTransaction(uint32(2), locktime)
+	} else {
+		handle, err = cfd.CfdGoInitializeTransaction(uint32(2), locktime)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer cfd.CfdGoFreeTransactionHandle(handle)
+
+	if err = cfd.CfdGoAddTxInput(handle, txid, vout, sequence); err != nil {
+		return "", err
+	}
+	if isElements {
+		if err = cfd.CfdGoAddConfidentialTxOutput(handle, asset, amount, address); err != nil {
+			return "", err
+		}
+		if err = cfd.CfdGoAddConfidentialTxOutputFee(handle, asset, fee); err != nil {
+			return "", err
+		}
+	} else if err = cfd.CfdGoAddTxOutput(handle, amount, address); err != nil {
+		return "", err
+	}
+	txHex, err := cfd.CfdGoFinalizeTransaction(handle)
+	if err != nil {
+		return "", err
+	}
+	if tx, err := DecodeTransaction(txHex, isElements); err == nil && isElements &&
+		len(tx.TxOut[0].Nonce) > 0 {
+		return "", errors.New("confidential address is not supported. use unconfidential address")
+	}
+	return txHex, nil
+}
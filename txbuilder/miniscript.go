@@ -0,0 +1,1056 @@
+package txbuilder
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MiniscriptMaxScriptSize max witness script size (P2WSH standardness).
+	MiniscriptMaxScriptSize = 3600
+	// MiniscriptMaxOpsCount max non-push opcode count.
+	MiniscriptMaxOpsCount = 201
+	// miniscriptMaxPathCount max count of enumerated spending paths.
+	miniscriptMaxPathCount = 4096
+	// signatureSize max DER signature size with sighash type byte.
+	signatureSize = 72
+	// pubkeySize compressed pubkey size.
+	pubkeySize = 33
+	// LocktimeThreshold the boundary of block height and unix time.
+	LocktimeThreshold = 500000000
+	// sequenceTypeFlag BIP-68 time-based relative locktime flag.
+	sequenceTypeFlag = uint32(1 << 22)
+)
+
+// exprNode expression tree node. ex) name(arg1,arg2)
+type exprNode struct {
+	name    string
+	args    []*exprNode
+	hasArgs bool
+}
+
+// parseExpression parses the expression string into the tree.
+func parseExpression(text string) (*exprNode, error) {
+	node, rest, err := parseExpressionNode(strings.TrimSpace(text))
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected string: %s", rest)
+	}
+	return node, nil
+}
+
+func parseExpressionNode(text string) (*exprNode, string, error) {
+	index := strings.IndexAny(text, "(),")
+	if index < 0 {
+		return &exprNode{name: text}, "", nil
+	}
+	node := &exprNode{name: strings.TrimSpace(text[:index])}
+	if text[index] != '(' {
+		return node, text[index:], nil
+	}
+	if node.name == "" {
+		return nil, "", errors.New("expression name is empty")
+	}
+	node.hasArgs = true
+	text = text[index+1:]
+	for {
+		arg, rest, err := parseExpressionNode(strings.TrimSpace(text))
+		if err != nil {
+			return nil, "", err
+		}
+		node.args = append(node.args, arg)
+		if rest == "" {
+			return nil, "", fmt.Errorf("missing ')' of %s", node.name)
+		}
+		if rest[0] == ')' {
+			return node, strings.TrimSpace(rest[1:]), nil
+		}
+		if rest[0] != ',' {
+			return nil, "", fmt.Errorf("unexpected string: %s", rest)
+		}
+		text = rest[1:]
+	}
+}
+
+// String returns the expression string.
+func (node *exprNode) String() string {
+	if !node.hasArgs {
+		return node.name
+	}
+	args := make([]string, len(node.args))
+	for i, arg := range node.args {
+		args[i] = arg.String()
+	}
+	return node.name + "(" + strings.Join(args, ",") + ")"
+}
+
+// MiniscriptType miniscript type and properties.
+type MiniscriptType struct {
+	Base byte // 'B', 'V', 'K', 'W'
+	Z    bool // zero-arg
+	O    bool // one-arg
+	N    bool // nonzero
+	D    bool // dissatisfiable
+	U    bool // unit
+	S    bool // signed
+	F    bool // forced
+	E    bool // expressive
+	M    bool // non-malleable
+}
+
+// String returns the type and properties string. ex) Bondu
+func (mtype MiniscriptType) String() string {
+	result := string(mtype.Base)
+	for _, prop := range []struct {
+		flag bool
+		name string
+	}{{mtype.Z, "z"}, {mtype.O, "o"}, {mtype.N, "n"}, {mtype.D, "d"},
+		{mtype.U, "u"}, {mtype.S, "s"}, {mtype.F, "f"}, {mtype.E, "e"},
+		{mtype.M, "m"}} {
+		if prop.flag {
+			result += prop.name
+		}
+	}
+	return result
+}
+
+// Miniscript miniscript node.
+type Miniscript struct {
+	Fragment string
+	Subs     []*Miniscript
+	K        int
+	Keys     []string
+	Value    uint32
+	Hash     string
+	IsSorted bool
+	Type     MiniscriptType
+}
+
+// SpendingPath spending path (satisfaction) of miniscript.
+type SpendingPath struct {
+	Keys         []string
+	Hashes       []string
+	OlderBlocks  uint32
+	OlderTime    uint32
+	AfterHeight  uint32
+	AfterTime    uint32
+	StackSizes   []int
+	HasTimelock  bool
+	IsMixingLock bool
+}
+
+// miniscriptWrappers wrapper fragment names.
+const miniscriptWrappers = "asctdvjnlu"
+
+// hashFragmentSize hash fragment hash sizes.
+var hashFragmentSize = map[string]int{
+	"sha256": 32, "hash256": 32, "ripemd160": 20, "hash160": 20,
+}
+
+// ParseMiniscript parses the miniscript string.
+func ParseMiniscript(text string) (*Miniscript, error) {
+	expr, err := parseExpression(text)
+	if err != nil {
+		return nil, err
+	}
+	return newMiniscriptFromExpr(expr)
+}
+
+func newMiniscriptFromExpr(expr *exprNode) (*Miniscript, error) {
+	name := expr.name
+	if index := strings.Index(name, ":"); index > 0 {
+		wrappers := name[:index]
+		inner := *expr
+		inner.name = name[index+1:]
+		node, err := newMiniscriptFromExpr(&inner)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(wrappers) - 1; i >= 0; i-- {
+			if !strings.ContainsRune(miniscriptWrappers, rune(wrappers[i])) {
+				return nil, fmt.Errorf("unknown wrapper: %c", wrappers[i])
+			}
+			node, err = newMiniscriptWrapper(string(wrappers[i]), node)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+
+	if !expr.hasArgs {
+		switch name {
+		case "0", "1":
+			return newMiniscriptNode(&Miniscript{Fragment: name})
+		}
+		return nil, fmt.Errorf("unknown fragment: %s", name)
+	}
+
+	switch name {
+	case "pk", "pkh", "pk_k", "pk_h":
+		if len(expr.args) != 1 || expr.args[0].hasArgs {
+			return nil, fmt.Errorf("%s requires a key", name)
+		}
+		key := expr.args[0].name
+		switch name {
+		case "pk":
+			inner, err := newMiniscriptNode(&Miniscript{Fragment: "pk_k", Keys: []string{key}})
+			if err != nil {
+				return nil, err
+			}
+			return newMiniscriptWrapper("c", inner)
+		case "pkh":
+			inner, err := newMiniscriptNode(&Miniscript{Fragment: "pk_h", Keys: []string{key}})
+			if err != nil {
+				return nil, err
+			}
+			return newMiniscriptWrapper("c", inner)
+		}
+		return newMiniscriptNode(&Miniscript{Fragment: name, Keys: []string{key}})
+	case "older", "after":
+		if len(expr.args) != 1 || expr.args[0].hasArgs {
+			return nil, fmt.Errorf("%s requires a number", name)
+		}
+		value, err := strconv.ParseUint(expr.args[0].name, 10, 32)
+		if err != nil || value < 1 || value >= 0x80000000 {
+			return nil, fmt.Errorf("%s value is invalid: %s", name, expr.args[0].name)
+		}
+		return newMiniscriptNode(&Miniscript{Fragment: name, Value: uint32(value)})
+	case "sha256", "hash256", "ripemd160", "hash160":
+		if len(expr.args) != 1 || expr.args[0].hasArgs {
+			return nil, fmt.Errorf("%s requires a hash", name)
+		}
+		hash := expr.args[0].name
+		if data, err := hex.DecodeString(hash); err != nil || len(data) != hashFragmentSize[name] {
+			return nil, fmt.Errorf("%s hash is invalid: %s", name, hash)
+		}
+		return newMiniscriptNode(&Miniscript{Fragment: name, Hash: hash})
+	case "multi", "sortedmulti", "thresh":
+		if len(expr.args) < 2 {
+			return nil, fmt.Errorf("%s requires threshold and arguments", name)
+		}
+		k, err := strconv.Atoi(expr.args[0].name)
+		if err != nil || k < 1 || k > len(expr.args)-1 {
+			return nil, fmt.Errorf("%s threshold is invalid: %s", name, expr.args[0].name)
+		}
+		node := &Miniscript{Fragment: name, K: k}
+		if name == "sortedmulti" {
+			node.Fragment = "multi"
+			node.IsSorted = true
+		}
+		for _, arg := range expr.args[1:] {
+			if node.Fragment == "multi" {
+				if arg.hasArgs {
+					return nil, errors.New("multi requires keys")
+				}
+				node.Keys = append(node.Keys, arg.name)
+				continue
+			}
+			sub, err := newMiniscriptFromExpr(arg)
+			if err != nil {
+				return nil, err
+			}
+			node.Subs = append(node.Subs, sub)
+		}
+		if node.Fragment == "multi" && len(node.Keys) > 20 {
+			return nil, errors.New("multi key count is over 20")
+		}
+		return newMiniscriptNode(node)
+	case "and_v", "and_b", "and_n", "or_b", "or_c", "or_d", "or_i", "andor":
+		argNum := 2
+		if name == "andor" {
+			argNum = 3
+		}
+		if len(expr.args) != argNum {
+			return nil, fmt.Errorf("%s requires %d arguments", name, argNum)
+		}
+		node := &Miniscript{Fragment: name}
+		for _, arg := range expr.args {
+			sub, err := newMiniscriptFromExpr(arg)
+			if err != nil {
+				return nil, err
+			}
+			node.Subs = append(node.Subs, sub)
+		}
+		if name == "and_n" {
+			zero, _ := newMiniscriptNode(&Miniscript{Fragment: "0"})
+			node.Fragment = "andor"
+			node.Subs = append(node.Subs, zero)
+		}
+		return newMiniscriptNode(node)
+	}
+	return nil, fmt.Errorf("unknown fragment: %s", name)
+}
+
+// newMiniscriptWrapper creates the wrapper node.
+// t:X = and_v(X,1), l:X = or_i(0,X), u:X = or_i(X,0)
+func newMiniscriptWrapper(wrapper string, sub *Miniscript) (*Miniscript, error) {
+	switch wrapper {
+	case "t":
+		one, _ := newMiniscriptNode(&Miniscript{Fragment: "1"})
+		return newMiniscriptNode(&Miniscript{Fragment: "and_v", Subs: []*Miniscript{sub, one}})
+	case "l":
+		zero, _ := newMiniscriptNode(&Miniscript{Fragment: "0"})
+		return newMiniscriptNode(&Miniscript{Fragment: "or_i", Subs: []*Miniscript{zero, sub}})
+	case "u":
+		zero, _ := newMiniscriptNode(&Miniscript{Fragment: "0"})
+		return newMiniscriptNode(&Miniscript{Fragment: "or_i", Subs: []*Miniscript{sub, zero}})
+	}
+	return newMiniscriptNode(&Miniscript{Fragment: wrapper, Subs: []*Miniscript{sub}})
+}
+
+// newMiniscriptNode computes the node type, and validates type requirements.
+func newMiniscriptNode(node *Miniscript) (*Miniscript, error) {
+	var x, y, z MiniscriptType
+	if len(node.Subs) > 0 {
+		x = node.Subs[0].Type
+	}
+	if len(node.Subs) > 1 {
+		y = node.Subs[1].Type
+	}
+	if len(node.Subs) > 2 {
+		z = node.Subs[2].Type
+	}
+	t := MiniscriptType{}
+	typeErr := func(requirement string) error {
+		return fmt.Errorf("%s requires %s", node.Fragment, requirement)
+	}
+
+	switch node.Fragment {
+	case "0":
+		t = MiniscriptType{Base: 'B', Z: true, U: true, D: true, S: true, E: true, M: true}
+	case "1":
+		t = MiniscriptType{Base: 'B', Z: true, U: true, F: true, M: true}
+	case "pk_k":
+		t = MiniscriptType{Base: 'K', O: true, N: true, D: true, U: true, S: true, E: true, M: true}
+	case "pk_h":
+		t = MiniscriptType{Base: 'K', N: true, D: true, U: true, S: true, E: true, M: true}
+	case "older", "after":
+		t = MiniscriptType{Base: 'B', Z: true, F: true, M: true}
+	case "sha256", "hash256", "ripemd160", "hash160":
+		t = MiniscriptType{Base: 'B', O: true, N: true, D: true, U: true, M: true}
+	case "multi":
+		t = MiniscriptType{Base: 'B', N: true, D: true, U: true, S: true, E: true, M: true}
+	case "andor":
+		if x.Base != 'B' || !x.D || !x.U {
+			return nil, typeErr("X is Bdu")
+		}
+		if y.Base != z.Base || y.Base == 'W' {
+			return nil, typeErr("Y and Z are both B, K, or V")
+		}
+		t.Base = y.Base
+		t.Z = x.Z && y.Z && z.Z
+		t.O = (x.Z && y.O && z.O) || (x.O && y.Z && z.Z)
+		t.U = y.U && z.U
+		t.D = z.D
+		t.S = z.S && (x.S || y.S)
+		t.F = z.F && (x.S || y.F)
+		t.E = z.E && (x.S || y.F)
+		t.M = x.M && y.M && z.M && x.E && (x.S || y.S || z.S)
+	case "and_v":
+		if x.Base != 'V' || y.Base == 'W' {
+			return nil, typeErr("X is V; Y is B, K, or V")
+		}
+		t.Base = y.Base
+		t.Z = x.Z && y.Z
+		t.O = (x.Z && y.O) || (y.Z && x.O)
+		t.N = x.N || (x.Z && y.N)
+		t.U = y.U
+		t.S = x.S || y.S
+		t.F = x.S || y.F
+		t.M = x.M && y.M
+	case "and_b":
+		if x.Base != 'B' || y.Base != 'W' {
+			return nil, typeErr("X is B; Y is W")
+		}
+		t.Base = 'B'
+		t.Z = x.Z && y.Z
+		t.O = (x.Z && y.O) || (y.Z && x.O)
+		t.N = x.N || (x.Z && y.N)
+		t.D = x.D && y.D
+		t.U = true
+		t.S = x.S || y.S
+		t.F = (x.F && y.F) || (x.S && x.F) || (y.S && y.F)
+		t.E = x.E && y.E && x.S && y.S
+		t.M = x.M && y.M
+	case "or_b":
+		if x.Base != 'B' || !x.D || y.Base != 'W' || !y.D {
+			return nil, typeErr("X is Bd; Z is Wd")
+		}
+		t.Base = 'B'
+		t.Z = x.Z && y.Z
+		t.O = (x.Z && y.O) || (y.Z && x.O)
+		t.D = true
+		t.U = true
+		t.S = x.S && y.S
+		t.E = true
+		t.M = x.M && y.M && x.E && y.E && (x.S || y.S)
+	case "or_c":
+		if x.Base != 'B' || !x.D || !x.U || y.Base != 'V' {
+			return nil, typeErr("X is Bdu; Z is V")
+		}
+		t.Base = 'V'
+		t.Z = x.Z && y.Z
+		t.O = x.O && y.Z
+		t.S = x.S && y.S
+		t.F = true
+		t.M = x.M && y.M && x.E && (x.S || y.S)
+	case "or_d":
+		if x.Base != 'B' || !x.D || !x.U || y.Base != 'B' {
+			return nil, typeErr("X is Bdu; Z is B")
+		}
+		t.Base = 'B'
+		t.Z = x.Z && y.Z
+		t.O = x.O && y.Z
+		t.D = y.D
+		t.U = y.U
+		t.S = x.S && y.S
+		t.F = y.F
+		t.E = x.E && y.E
+		t.M = x.M && y.M && x.E && (x.S || y.S)
+	case "or_i":
+		if x.Base != y.Base || x.Base == 'W' {
+			return nil, typeErr("X and Z are both B, K, or V")
+		}
+		t.Base = x.Base
+		t.O = x.Z && y.Z
+		t.U = x.U && y.U
+		t.D = x.D || y.D
+		t.S = x.S && y.S
+		t.F = x.F && y.F
+		t.E = (x.E && y.F) || (y.E && x.F)
+		t.M = x.M && y.M && (x.S || y.S)
+	case "thresh":
+		t = MiniscriptType{Base: 'B', Z: true, D: true, U: true, E: true, M: true}
+		zeroCount, oneCount, signedCount := 0, 0, 0
+		for i, sub := range node.Subs {
+			st := sub.Type
+			if (i == 0 && st.Base != 'B') || (i > 0 && st.Base != 'W') || !st.D || !st.U {
+				return nil, typeErr("X1 is Bdu; others are Wdu")
+			}
+			if st.Z {
+				zeroCount++
+			} else if st.O {
+				oneCount++
+			}
+			if st.S {
+				signedCount++
+			}
+			t.E = t.E && st.E && st.S
+			t.M = t.M && st.E && st.M
+		}
+		t.Z = zeroCount == len(node.Subs)
+		t.O = zeroCount == len(node.Subs)-1 && oneCount == 1
+		t.S = signedCount >= node.K
+		t.M = t.M && (len(node.Subs)-signedCount) <= (len(node.Subs)-node.K)
+	case "a", "s":
+		if x.Base != 'B' || (node.Fragment == "s" && !x.O) {
+			if node.Fragment == "s" {
+				return nil, typeErr("X is Bo")
+			}
+			return nil, typeErr("X is B")
+		}
+		t = x
+		t.Base = 'W'
+		t.Z, t.O, t.N = false, false, false
+	case "c":
+		if x.Base != 'K' {
+			return nil, typeErr("X is K")
+		}
+		t = x
+		t.Base = 'B'
+		t.U = true
+	case "d":
+		if x.Base != 'V' || !x.Z {
+			return nil, typeErr("X is Vz")
+		}
+		t = MiniscriptType{Base: 'B', O: true, N: true, D: true, S: x.S, E: true, M: x.M}
+	case "v":
+		if x.Base != 'B' {
+			return nil, typeErr("X is B")
+		}
+		t = MiniscriptType{Base: 'V', Z: x.Z, O: x.O, N: x.N, S: x.S, F: true, M: x.M}
+	case "j":
+		if x.Base != 'B' || !x.N {
+			return nil, typeErr("X is Bn")
+		}
+		t = MiniscriptType{Base: 'B', O: x.O, N: true, D: true, U: x.U, S: x.S, E: x.F, M: x.M}
+	case "n":
+		if x.Base != 'B' {
+			return nil, typeErr("X is B")
+		}
+		t = x
+		t.U = true
+	default:
+		return nil, fmt.Errorf("unknown fragment: %s", node.Fragment)
+	}
+	node.Type = t
+	return node, nil
+}
+
+// String returns the miniscript string.
+func (node *Miniscript) String() string {
+	wrappers := ""
+	for {
+		if len(node.Fragment) == 1 && strings.Contains("ascdvjn", node.Fragment) {
+			sub := node.Subs[0]
+			if node.Fragment == "c" && (sub.Fragment == "pk_k" || sub.Fragment == "pk_h") {
+				break
+			}
+			wrappers += node.Fragment
+			node = sub
+		} else if node.Fragment == "and_v" && node.Subs[1].Fragment == "1" {
+			wrappers += "t"
+			node = node.Subs[0]
+		} else if node.Fragment == "or_i" && node.Subs[0].Fragment == "0" {
+			wrappers += "l"
+			node = node.Subs[1]
+		} else if node.Fragment == "or_i" && node.Subs[1].Fragment == "0" {
+			wrappers += "u"
+			node = node.Subs[0]
+		} else {
+			break
+		}
+	}
+	if wrappers != "" {
+		wrappers += ":"
+	}
+
+	switch node.Fragment {
+	case "0", "1":
+		return wrappers + node.Fragment
+	case "c":
+		name := "pk"
+		if node.Subs[0].Fragment == "pk_h" {
+			name = "pkh"
+		}
+		return wrappers + name + "(" + node.Subs[0].Keys[0] + ")"
+	case "pk_k", "pk_h":
+		return wrappers + node.Fragment + "(" + node.Keys[0] + ")"
+	case "older", "after":
+		return fmt.Sprintf("%s%s(%d)", wrappers, node.Fragment, node.Value)
+	case "sha256", "hash256", "ripemd160", "hash160":
+		return wrappers + node.Fragment + "(" + node.Hash + ")"
+	case "multi":
+		name := "multi"
+		if node.IsSorted {
+			name = "sortedmulti"
+		}
+		return fmt.Sprintf("%s%s(%d,%s)", wrappers, name, node.K, strings.Join(node.Keys, ","))
+	}
+	args := []string{}
+	if node.Fragment == "thresh" {
+		args = append(args, strconv.Itoa(node.K))
+	}
+	for _, sub := range node.Subs {
+		args = append(args, sub.String())
+	}
+	return wrappers + node.Fragment + "(" + strings.Join(args, ",") + ")"
+}
+
+// MiniscriptKeyResolver resolves the key to the pubkey and pubkey hash.
+type MiniscriptKeyResolver interface {
+	GetPubkey(key string) ([]byte, error)
+	GetPubkeyHash(key string) ([]byte, error)
+}
+
+// dummyKeyResolver returns dummy data. (for size calculation)
+type dummyKeyResolver struct{}
+
+func (resolver dummyKeyResolver) GetPubkey(key string) ([]byte, error) {
+	return make([]byte, pubkeySize), nil
+}
+
+func (resolver dummyKeyResolver) GetPubkeyHash(key string) ([]byte, error) {
+	return make([]byte, 20), nil
+}
+
+// CompileScript compiles the miniscript into the script.
+func (node *Miniscript) CompileScript(resolver MiniscriptKeyResolver) ([]byte, error) {
+	builder := NewScriptBuilder()
+	if err := node.appendScript(builder, resolver, false); err != nil {
+		return nil, err
+	}
+	return builder.Bytes(), nil
+}
+
+// appendScript appends the script of the node.
+// if verify is true, the last opcode is converted to VERIFY opcode.
+func (node *Miniscript) appendScript(builder *ScriptBuilder, resolver MiniscriptKeyResolver, verify bool) error {
+	subScript := func(index int, subVerify bool) error {
+		return node.Subs[index].appendScript(builder, resolver, subVerify)
+	}
+	// appendVerify appends the verify opcode, or VERIFY version of the last opcode.
+	appendVerify := func(opcode, verifyOpcode byte) {
+		if verify {
+			builder.AddOp(verifyOpcode)
+		} else {
+			builder.AddOp(opcode)
+		}
+	}
+
+	switch node.Fragment {
+	case "0":
+		builder.AddOp(OpFalse)
+	case "1":
+		builder.AddOp(OpTrue)
+	case "pk_k":
+		pubkey, err := resolver.GetPubkey(node.Keys[0])
+		if err != nil {
+			return err
+		}
+		builder.AddData(pubkey)
+	case "pk_h":
+		hash, err := resolver.GetPubkeyHash(node.Keys[0])
+		if err != nil {
+			return err
+		}
+		builder.AddOp(OpDup, OpHash160).AddData(hash).AddOp(OpEqualVerify)
+	case "older", "after":
+		builder.AddInt(int64(node.Value))
+		if node.Fragment == "older" {
+			builder.AddOp(OpCheckSequenceVerify)
+		} else {
+			builder.AddOp(OpCheckLockTimeVerify)
+		}
+	case "sha256", "hash256", "ripemd160", "hash160":
+		hashOp := map[string]byte{"sha256": OpSha256, "hash256": OpHash256,
+			"ripemd160": OpRipemd160, "hash160": OpHash160}[node.Fragment]
+		hash, _ := hex.DecodeString(node.Hash)
+		builder.AddOp(OpSize).AddInt(32).AddOp(OpEqualVerify, hashOp).AddData(hash)
+		appendVerify(OpEqual, OpEqualVerify)
+		return nil
+	case "multi":
+		pubkeys := [][]byte{}
+		for _, key := range node.Keys {
+			pubkey, err := resolver.GetPubkey(key)
+			if err != nil {
+				return err
+			}
+			pubkeys = append(pubkeys, pubkey)
+		}
+		if node.IsSorted {
+			sort.Slice(pubkeys, func(i, j int) bool {
+				return bytes.Compare(pubkeys[i], pubkeys[j]) < 0
+			})
+		}
+		builder.AddInt(int64(node.K))
+		for _, pubkey := range pubkeys {
+			builder.AddData(pubkey)
+		}
+		builder.AddInt(int64(len(node.Keys)))
+		appendVerify(OpCheckMultiSig, OpCheckMultiSigVerify)
+		return nil
+	case "andor":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpNotIf)
+		if err := subScript(2, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpElse)
+		if err := subScript(1, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpEndIf)
+	case "and_v":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		return subScript(1, verify)
+	case "and_b", "or_b":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		if err := subScript(1, false); err != nil {
+			return err
+		}
+		if node.Fragment == "and_b" {
+			builder.AddOp(OpBoolAnd)
+		} else {
+			builder.AddOp(OpBoolOr)
+		}
+	case "or_c", "or_d":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		if node.Fragment == "or_c" {
+			builder.AddOp(OpNotIf)
+		} else {
+			builder.AddOp(OpIfDup, OpNotIf)
+		}
+		if err := subScript(1, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpEndIf)
+	case "or_i":
+		builder.AddOp(OpIf)
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpElse)
+		if err := subScript(1, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpEndIf)
+	case "thresh":
+		for i := range node.Subs {
+			if err := subScript(i, false); err != nil {
+				return err
+			}
+			if i > 0 {
+				builder.AddOp(OpAdd)
+			}
+		}
+		builder.AddInt(int64(node.K))
+		appendVerify(OpEqual, OpEqualVerify)
+		return nil
+	case "a":
+		builder.AddOp(OpToAltStack)
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpFromAltStack)
+	case "s":
+		builder.AddOp(OpSwap)
+		return subScript(0, verify)
+	case "c":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		appendVerify(OpCheckSig, OpCheckSigVerify)
+		return nil
+	case "d":
+		builder.AddOp(OpDup, OpIf)
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpEndIf)
+	case "v":
+		return subScript(0, true)
+	case "j":
+		builder.AddOp(OpSize, Op0NotEqual, OpIf)
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(OpEndIf)
+	case "n":
+		if err := subScript(0, false); err != nil {
+			return err
+		}
+		builder.AddOp(Op0NotEqual)
+	default:
+		return fmt.Errorf("unknown fragment: %s", node.Fragment)
+	}
+	if verify {
+		builder.AddOp(OpVerify)
+	}
+	return nil
+}
+
+// GetOpsCount returns the non-push opcode count of the script.
+func GetOpsCount(script []byte) int {
+	elements, err := ParseScriptBytes(script)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for i, elem := range elements {
+		if elem.IsPush || elem.Opcode <= Op16 {
+			continue
+		}
+		count++
+		if (elem.Opcode == OpCheckMultiSig || elem.Opcode == OpCheckMultiSigVerify) && i > 0 {
+			if keyNum, err := GetElementNumber(elements[i-1], 4); err == nil {
+				count += int(keyNum)
+			}
+		}
+	}
+	return count
+}
+
+// newSpendingPath creates the path with the stack items.
+func newSpendingPath(stackSizes ...int) SpendingPath {
+	return SpendingPath{StackSizes: stackSizes}
+}
+
+// combinePath concatenates the paths.
+func combinePath(paths ...SpendingPath) SpendingPath {
+	result := SpendingPath{}
+	maxValue := func(a, b uint32) uint32 {
+		if a > b {
+			return a
+		}
+		return b
+	}
+	for _, path := range paths {
+		result.Keys = append(result.Keys, path.Keys...)
+		result.Hashes = append(result.Hashes, path.Hashes...)
+		result.OlderBlocks = maxValue(result.OlderBlocks, path.OlderBlocks)
+		result.OlderTime = maxValue(result.OlderTime, path.OlderTime)
+		result.AfterHeight = maxValue(result.AfterHeight, path.AfterHeight)
+		result.AfterTime = maxValue(result.AfterTime, path.AfterTime)
+		result.StackSizes = append(result.StackSizes, path.StackSizes...)
+		result.HasTimelock = result.HasTimelock || path.HasTimelock
+	}
+	result.IsMixingLock = (result.OlderBlocks > 0 && result.OlderTime > 0) ||
+		(result.AfterHeight > 0 && result.AfterTime > 0)
+	return result
+}
+
+// crossPaths returns all combinations of the path lists.
+func crossPaths(pathLists ...[]SpendingPath) []SpendingPath {
+	result := []SpendingPath{{}}
+	for _, paths := range pathLists {
+		next := []SpendingPath{}
+		for _, base := range result {
+			for _, path := range paths {
+				if len(next) >= miniscriptMaxPathCount {
+					break
+				}
+				next = append(next, combinePath(base, path))
+			}
+		}
+		result = next
+	}
+	return result
+}
+
+// Satisfactions returns the satisfactions and dissatisfactions.
+func (node *Miniscript) Satisfactions() (sats, dsats []SpendingPath) {
+	subSats := make([][]SpendingPath, len(node.Subs))
+	subDsats := make([][]SpendingPath, len(node.Subs))
+	for i, sub := range node.Subs {
+		subSats[i], subDsats[i] = sub.Satisfactions()
+	}
+	appendPaths := func(lists ...[]SpendingPath) []SpendingPath {
+		result := []SpendingPath{}
+		for _, list := range lists {
+			result = append(result, list...)
+		}
+		return result
+	}
+	constPath := func(stackSizes ...int) []SpendingPath {
+		return []SpendingPath{newSpendingPath(stackSizes...)}
+	}
+
+	switch node.Fragment {
+	case "0":
+		return nil, constPath()
+	case "1":
+		return constPath(), nil
+	case "pk_k":
+		sat := newSpendingPath(signatureSize)
+		sat.Keys = []string{node.Keys[0]}
+		return []SpendingPath{sat}, constPath(0)
+	case "pk_h":
+		sat := newSpendingPath(signatureSize, pubkeySize)
+		sat.Keys = []string{node.Keys[0]}
+		return []SpendingPath{sat}, constPath(0, pubkeySize)
+	case "older":
+		sat := newSpendingPath()
+		sat.HasTimelock = true
+		if node.Value&sequenceTypeFlag != 0 {
+			sat.OlderTime = node.Value
+		} else {
+			sat.OlderBlocks = node.Value
+		}
+		return []SpendingPath{sat}, nil
+	case "after":
+		sat := newSpendingPath()
+		sat.HasTimelock = true
+		if node.Value >= LocktimeThreshold {
+			sat.AfterTime = node.Value
+		} else {
+			sat.AfterHeight = node.Value
+		}
+		return []SpendingPath{sat}, nil
+	case "sha256", "hash256", "ripemd160", "hash160":
+		sat := newSpendingPath(32)
+		sat.Hashes = []string{node.Fragment + "(" + node.Hash + ")"}
+		return []SpendingPath{sat}, constPath(32)
+	case "multi":
+		for _, indexes := range combinations(len(node.Keys), node.K) {
+			sat := newSpendingPath(0)
+			for _, index := range indexes {
+				sat.Keys = append(sat.Keys, node.Keys[index])
+				sat.StackSizes = append(sat.StackSizes, signatureSize)
+			}
+			sats = append(sats, sat)
+		}
+		dsat := newSpendingPath(0)
+		for i := 0; i < node.K; i++ {
+			dsat.StackSizes = append(dsat.StackSizes, 0)
+		}
+		return sats, []SpendingPath{dsat}
+	case "andor":
+		return appendPaths(crossPaths(subSats[1], subSats[0]),
+				crossPaths(subSats[2], subDsats[0])),
+			crossPaths(subDsats[2], subDsats[0])
+	case "and_v":
+		return crossPaths(subSats[1], subSats[0]), nil
+	case "and_b":
+		return crossPaths(subSats[1], subSats[0]), crossPaths(subDsats[1], subDsats[0])
+	case "or_b":
+		return appendPaths(crossPaths(subDsats[1], subSats[0]),
+				crossPaths(subSats[1], subDsats[0])),
+			crossPaths(subDsats[1], subDsats[0])
+	case "or_c":
+		return appendPaths(subSats[0], crossPaths(subSats[1], subDsats[0])), nil
+	case "or_d":
+		return appendPaths(subSats[0], crossPaths(subSats[1], subDsats[0])),
+			crossPaths(subDsats[1], subDsats[0])
+	case "or_i":
+		return appendPaths(crossPaths(subSats[0], constPath(1)),
+				crossPaths(subSats[1], constPath(0))),
+			appendPaths(crossPaths(subDsats[0], constPath(1)),
+				crossPaths(subDsats[1], constPath(0)))
+	case "thresh":
+		for _, indexes := range combinations(len(node.Subs), node.K) {
+			lists := make([][]SpendingPath, len(node.Subs))
+			for i := range node.Subs {
+				lists[len(node.Subs)-1-i] = subDsats[i]
+			}
+			for _, index := range indexes {
+				lists[len(node.Subs)-1-index] = subSats[index]
+			}
+			sats = append(sats, crossPaths(lists...)...)
+		}
+		dsatLists := make([][]SpendingPath, len(node.Subs))
+		for i := range node.Subs {
+			dsatLists[len(node.Subs)-1-i] = subDsats[i]
+		}
+		return sats, crossPaths(dsatLists...)
+	case "a", "s", "c", "n":
+		return subSats[0], subDsats[0]
+	case "d":
+		return crossPaths(subSats[0], constPath(1)), constPath(0)
+	case "v":
+		return subSats[0], nil
+	case "j":
+		return subSats[0], constPath(0)
+	}
+	return nil, nil
+}
+
+// combinations returns the index combinations of k elements from n.
+func combinations(n, k int) [][]int {
+	result := [][]int{}
+	indexes := make([]int, k)
+	var fill func(start, depth int)
+	fill = func(start, depth int) {
+		if len(result) >= miniscriptMaxPathCount {
+			return
+		}
+		if depth == k {
+			item := make([]int, k)
+			copy(item, indexes)
+			result = append(result, item)
+			return
+		}
+		for i := start; i <= n-(k-depth); i++ {
+			indexes[depth] = i
+			fill(i+1, depth+1)
+		}
+	}
+	fill(0, 0)
+	return result
+}
+
+// GetWitnessSize returns the witness size of the path. (with witness script)
+func (path SpendingPath) GetWitnessSize(scriptSize int) int {
+	size := GetSerializeSize(scriptSize)
+	for _, stackSize := range path.StackSizes {
+		size += GetSerializeSize(stackSize)
+	}
+	return size + GetVarIntSize(len(path.StackSizes)+1)
+}
+
+// GetScriptSigSize returns the scriptsig size of the path. (with redeem script)
+func (path SpendingPath) GetScriptSigSize(scriptSize int) int {
+	size := GetPushDataSize(scriptSize)
+	for _, stackSize := range path.StackSizes {
+		if stackSize == 1 {
+			// OP_1
+			size++
+		} else {
+			size += GetPushDataSize(stackSize)
+		}
+	}
+	return size
+}
+
+// GetPushDataSize returns the size of the push data with push opcode.
+func GetPushDataSize(size int) int {
+	switch {
+	case size < int(OpPushData1):
+		return size + 1
+	case size <= 0xff:
+		return size + 2
+	case size <= 0xffff:
+		return size + 3
+	default:
+		return size + 5
+	}
+}
+
+// CreateScriptsigTemplate creates the dummy scriptsig for fee estimation.
+func CreateScriptsigTemplate(stackSizes []int, script []byte) string {
+	builder := NewScriptBuilder()
+	for _, stackSize := range stackSizes {
+		switch stackSize {
+		case 0:
+			builder.AddOp(OpFalse)
+		case 1:
+			builder.AddOp(OpTrue)
+		default:
+			data := make([]byte, stackSize)
+			for i := range data {
+				data[i] = 0xff
+			}
+			builder.AddData(data)
+		}
+	}
+	if len(script) > 0 {
+		builder.AddData(script)
+	}
+	return builder.Hex()
+}
+
+// PathSummary returns the summary string of the path.
+func (path SpendingPath) PathSummary() string {
+	items := []string{}
+	if len(path.Keys) > 0 {
+		keys := append([]string{}, path.Keys...)
+		sort.Strings(keys)
+		items = append(items, "keys: "+strings.Join(keys, ","))
+	}
+	if len(path.Hashes) > 0 {
+		items = append(items, "preimages: "+strings.Join(path.Hashes, ","))
+	}
+	if path.OlderBlocks > 0 {
+		items = append(items, fmt.Sprintf("older: %d blocks", path.OlderBlocks))
+	}
+	if path.OlderTime > 0 {
+		items = append(items, fmt.Sprintf("older: %d seconds",
+			(path.OlderTime&0xffff)*512))
+	}
+	if path.AfterHeight > 0 {
+		items = append(items, fmt.Sprintf("after: height %d", path.AfterHeight))
+	}
+	if path.AfterTime > 0 {
+		items = append(items, fmt.Sprintf("after: time %d", path.AfterTime))
+	}
+	if len(items) == 0 {
+		items = append(items, "(anyone can spend)")
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,253 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+)
+
+// CompilePolicy compiles the spending policy into the miniscript.
+// policy: pk(K), after(N), older(N), sha256(H), hash256(H), ripemd160(H),
+// hash160(H), and(X,Y), or([N@]X,[N@]Y), thresh(k,X1,...,Xn)
+func CompilePolicy(policy string) (*Miniscript, error) {
+	expr, err := parseExpression(policy)
+	if err != nil {
+		return nil, err
+	}
+	return compilePolicyNode(expr)
+}
+
+// compilePolicyNode compiles the policy node into B type miniscript.
+func compilePolicyNode(expr *exprNode) (*Miniscript, error) {
+	switch expr.name {
+	case "pk", "after", "older", "sha256", "hash256", "ripemd160", "hash160":
+		return newMiniscriptFromExpr(expr)
+	case "and":
+		if len(expr.args) != 2 {
+			return nil, errors.New("and requires 2 arguments")
+		}
+		return compilePolicyAnd(expr.args)
+	case "or":
+		if len(expr.args) != 2 {
+			return nil, errors.New("or requires 2 arguments")
+		}
+		return compilePolicyOr(expr.args)
+	case "thresh":
+		if len(expr.args) < 2 {
+			return nil, errors.New("thresh requires threshold and arguments")
+		}
+		k, err := strconv.Atoi(expr.args[0].name)
+		subs := expr.args[1:]
+		if err != nil || k < 1 || k > len(subs) {
+			return nil, fmt.Errorf("thresh threshold is invalid: %s", expr.args[0].name)
+		}
+		return compilePolicyThresh(k, subs)
+	}
+	return nil, fmt.Errorf("unknown policy: %s", expr.name)
+}
+
+// compilePolicyAnd compiles and(X,Y) into and_v(v:X,Y).
+func compilePolicyAnd(args []*exprNode) (*Miniscript, error) {
+	left, err := compilePolicyNode(args[0])
+	if err != nil {
+		return nil, err
+	}
+	right, err := compilePolicyNode(args[1])
+	if err != nil {
+		return nil, err
+	}
+	verify, err := newMiniscriptWrapper("v", left)
+	if err != nil {
+		return nil, err
+	}
+	return newMiniscriptNode(&Miniscript{Fragment: "and_v", Subs: []*Miniscript{verify, right}})
+}
+
+// compilePolicyOr compiles or(X,Y) into or_d(X,Y) or or_i(X,Y).
+// The branch with higher probability is placed first.
+func compilePolicyOr(args []*exprNode) (*Miniscript, error) {
+	type branch struct {
+		probability int
+		node        *Miniscript
+	}
+	branches := []branch{}
+	for _, arg := range args {
+		probability := 1
+		sub := *arg
+		if index := strings.Index(sub.name, "@"); index > 0 {
+			value, err := strconv.Atoi(sub.name[:index])
+			if err != nil || value < 1 {
+				return nil, fmt.Errorf("probability is invalid: %s", sub.name[:index])
+			}
+			probability = value
+			sub.name = sub.name[index+1:]
+		}
+		node, err := compilePolicyNode(&sub)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, branch{probability: probability, node: node})
+	}
+	sort.SliceStable(branches, func(i, j int) bool {
+		return branches[i].probability > branches[j].probability
+	})
+
+	return compileMiniscriptOr(branches[0].node, branches[1].node)
+}
+
+// compilePolicyThresh compiles thresh(k,...) into multi, and/or chain or thresh.
+func compilePolicyThresh(k int, args []*exprNode) (*Miniscript, error) {
+	isAllKeys := len(args) <= 20
+	for _, arg := range args {
+		if arg.name != "pk" || len(arg.args) != 1 {
+			isAllKeys = false
+		}
+	}
+	if isAllKeys {
+		node := &Miniscript{Fragment: "multi", K: k}
+		for _, arg := range args {
+			node.Keys = append(node.Keys, arg.args[0].name)
+		}
+		return newMiniscriptNode(node)
+	}
+
+	if k == len(args) || k == 1 {
+		isAnd := k == len(args)
+		result, err := compilePolicyNode(args[len(args)-1])
+		if err != nil {
+			return nil, err
+		}
+		for i := len(args) - 2; i >= 0; i-- {
+			sub, err := compilePolicyNode(args[i])
+			if err != nil {
+				return nil, err
+			}
+			if isAnd {
+				verify, err := newMiniscriptWrapper("v", sub)
+				if err != nil {
+					return nil, err
+				}
+				result, err = newMiniscriptNode(&Miniscript{
+					Fragment: "and_v", Subs: []*Miniscript{verify, result}})
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				result, err = compileMiniscriptOr(sub, result)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		return result, nil
+	}
+
+	node := &Miniscript{Fragment: "thresh", K: k}
+	for i, arg := range args {
+		sub, err := compilePolicyNode(arg)
+		if err != nil {
+			return nil, err
+		}
+		if sub, err = makeDissatisfiableUnit(sub); err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			wrapper := "a"
+			if sub.Type.O {
+				wrapper = "s"
+			}
+			if sub, err = newMiniscriptWrapper(wrapper, sub); err != nil {
+				return nil, err
+			}
+		}
+		node.Subs = append(node.Subs, sub)
+	}
+	return newMiniscriptNode(node)
+}
+
+// compileMiniscriptOr combines the B type nodes with or_d or or_i.
+// or_d is used if either node is dissatisfiable. (left is preferred)
+func compileMiniscriptOr(left, right *Miniscript) (*Miniscript, error) {
+	isDissatisfiable := func(node *Miniscript) bool {
+		return node.Type.D && node.Type.U && node.Type.E
+	}
+	if !isDissatisfiable(left) && isDissatisfiable(right) {
+		left, right = right, left
+	}
+	if isDissatisfiable(left) {
+		return newMiniscriptNode(&Miniscript{Fragment: "or_d", Subs: []*Miniscript{left, right}})
+	}
+	return newMiniscriptNode(&Miniscript{Fragment: "or_i", Subs: []*Miniscript{left, right}})
+}
+
+// makeDissatisfiableUnit wraps the B type node to be Bdu. (l:, n:)
+func makeDissatisfiableUnit(node *Miniscript) (*Miniscript, error) {
+	var err error
+	if !node.Type.D {
+		if node, err = newMiniscriptWrapper("l", node); err != nil {
+			return nil, err
+		}
+	}
+	if !node.Type.U {
+		if node, err = newMiniscriptWrapper("n", node); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// DescriptorKeyResolver resolves descriptor keys. (pubkey, xpub with path)
+type DescriptorKeyResolver struct {
+	networkType int
+	derivePath  string
+}
+
+// NewDescriptorKeyResolver returns a new DescriptorKeyResolver struct.
+func NewDescriptorKeyResolver(networkType int, derivePath string) *DescriptorKeyResolver {
+	return &DescriptorKeyResolver{networkType: networkType, derivePath: derivePath}
+}
+
+// GetPubkey returns the pubkey of the descriptor key.
+func (resolver *DescriptorKeyResolver) GetPubkey(key string) ([]byte, error) {
+	if hexRegexp.MatchString(key) {
+		pubkey, err := hex.DecodeString(key)
+		if err == nil && (len(pubkey) == 33 || len(pubkey) == 65) {
+			return pubkey, nil
+		}
+	}
+	descList, _, err := cfd.CfdGoParseDescriptor(
+		"pk("+key+")", resolver.networkType, resolver.derivePath)
+	if err != nil {
+		return nil, fmt.Errorf("key %s is invalid. %s", key, err.Error())
+	}
+	desc := descList[len(descList)-1]
+	pubkey := GetDescriptorKeyPubkey(desc.KeyType, desc.Pubkey,
+		desc.ExtPubkey, desc.ExtPrivkey)
+	if pubkey == "" {
+		return nil, fmt.Errorf("key %s is invalid", key)
+	}
+	return hex.DecodeString(pubkey)
+}
+
+// GetPubkeyHash returns the pubkey hash (hash160) of the descriptor key.
+func (resolver *DescriptorKeyResolver) GetPubkeyHash(key string) ([]byte, error) {
+	pubkey, err := resolver.GetPubkey(key)
+	if err != nil {
+		return nil, err
+	}
+	_, lockingScript, _, err := cfd.CfdGoCreateAddress(int(cfd.KCfdP2pkh),
+		hex.EncodeToString(pubkey), "", int(cfd.KCfdNetworkMainnet))
+	if err != nil {
+		return nil, err
+	}
+	// OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG
+	if len(lockingScript) != 50 {
+		return nil, errors.New("p2pkh locking script is invalid")
+	}
+	return hex.DecodeString(lockingScript[6:46])
+}
@@ -0,0 +1,605 @@
+package txbuilder
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// script opcodes.
+const (
+	OpFalse               byte = 0x00
+	OpPushData1           byte = 0x4c
+	OpPushData2           byte = 0x4d
+	OpPushData4           byte = 0x4e
+	Op1Negate             byte = 0x4f
+	OpReserved            byte = 0x50
+	OpTrue                byte = 0x51
+	Op16                  byte = 0x60
+	OpNop                 byte = 0x61
+	OpVer                 byte = 0x62
+	OpIf                  byte = 0x63
+	OpNotIf               byte = 0x64
+	OpVerIf               byte = 0x65
+	OpVerNotIf            byte = 0x66
+	OpElse                byte = 0x67
+	OpEndIf               byte = 0x68
+	OpVerify              byte = 0x69
+	OpReturn              byte = 0x6a
+	OpToAltStack          byte = 0x6b
+	OpFromAltStack        byte = 0x6c
+	Op2Drop               byte = 0x6d
+	Op2Dup                byte = 0x6e
+	Op3Dup                byte = 0x6f
+	Op2Over               byte = 0x70
+	Op2Rot                byte = 0x71
+	Op2Swap               byte = 0x72
+	OpIfDup               byte = 0x73
+	OpDepth               byte = 0x74
+	OpDrop                byte = 0x75
+	OpDup                 byte = 0x76
+	OpNip                 byte = 0x77
+	OpOver                byte = 0x78
+	OpPick                byte = 0x79
+	OpRoll                byte = 0x7a
+	OpRot                 byte = 0x7b
+	OpSwap                byte = 0x7c
+	OpTuck                byte = 0x7d
+	OpCat                 byte = 0x7e
+	OpSubStr              byte = 0x7f
+	OpLeft                byte = 0x80
+	OpRight               byte = 0x81
+	OpSize                byte = 0x82
+	OpInvert              byte = 0x83
+	OpAnd                 byte = 0x84
+	OpOr                  byte = 0x85
+	OpXor                 byte = 0x86
+	OpEqual               byte = 0x87
+	OpEqualVerify         byte = 0x88
+	OpReserved1           byte = 0x89
+	OpReserved2           byte = 0x8a
+	Op1Add                byte = 0x8b
+	Op1Sub                byte = 0x8c
+	Op2Mul                byte = 0x8d
+	Op2Div                byte = 0x8e
+	OpNegate              byte = 0x8f
+	OpAbs                 byte = 0x90
+	OpNot                 byte = 0x91
+	Op0NotEqual           byte = 0x92
+	OpAdd                 byte = 0x93
+	OpSub                 byte = 0x94
+	OpMul                 byte = 0x95
+	OpDiv                 byte = 0x96
+	OpMod                 byte = 0x97
+	OpLShift              byte = 0x98
+	OpRShift              byte = 0x99
+	OpBoolAnd             byte = 0x9a
+	OpBoolOr              byte = 0x9b
+	OpNumEqual            byte = 0x9c
+	OpNumEqualVerify      byte = 0x9d
+	OpNumNotEqual         byte = 0x9e
+	OpLessThan            byte = 0x9f
+	OpGreaterThan         byte = 0xa0
+	OpLessThanOrEqual     byte = 0xa1
+	OpGreaterThanOrEqual  byte = 0xa2
+	OpMin                 byte = 0xa3
+	OpMax                 byte = 0xa4
+	OpWithin              byte = 0xa5
+	OpRipemd160           byte = 0xa6
+	OpSha1                byte = 0xa7
+	OpSha256              byte = 0xa8
+	OpHash160             byte = 0xa9
+	OpHash256             byte = 0xaa
+	OpCodeSeparator       byte = 0xab
+	OpCheckSig            byte = 0xac
+	OpCheckSigVerify      byte = 0xad
+	OpCheckMultiSig       byte = 0xae
+	OpCheckMultiSigVerify byte = 0xaf
+	OpNop1                byte = 0xb0
+	OpCheckLockTimeVerify byte = 0xb1
+	OpCheckSequenceVerify byte = 0xb2
+	OpNop4                byte = 0xb3
+	OpNop10               byte = 0xb9
+)
+
+// opcodeNames maps an opcode to its name.
+var opcodeNames = map[byte]string{
+	OpFalse: "OP_0", OpPushData1: "OP_PUSHDATA1", OpPushData2: "OP_PUSHDATA2",
+	OpPushData4: "OP_PUSHDATA4", Op1Negate: "OP_1NEGATE", OpReserved: "OP_RESERVED",
+	OpNop: "OP_NOP", OpVer: "OP_VER", OpIf: "OP_IF", OpNotIf: "OP_NOTIF",
+	OpVerIf: "OP_VERIF", OpVerNotIf: "OP_VERNOTIF", OpElse: "OP_ELSE",
+	OpEndIf: "OP_ENDIF", OpVerify: "OP_VERIFY", OpReturn: "OP_RETURN",
+	OpToAltStack: "OP_TOALTSTACK", OpFromAltStack: "OP_FROMALTSTACK",
+	Op2Drop: "OP_2DROP", Op2Dup: "OP_2DUP", Op3Dup: "OP_3DUP", Op2Over: "OP_2OVER",
+	Op2Rot: "OP_2ROT", Op2Swap: "OP_2SWAP", OpIfDup: "OP_IFDUP", OpDepth: "OP_DEPTH",
+	OpDrop: "OP_DROP", OpDup: "OP_DUP", OpNip: "OP_NIP", OpOver: "OP_OVER",
+	OpPick: "OP_PICK", OpRoll: "OP_ROLL", OpRot: "OP_ROT", OpSwap: "OP_SWAP",
+	OpTuck: "OP_TUCK", OpCat: "OP_CAT", OpSubStr: "OP_SUBSTR", OpLeft: "OP_LEFT",
+	OpRight: "OP_RIGHT", OpSize: "OP_SIZE", OpInvert: "OP_INVERT", OpAnd: "OP_AND",
+	OpOr: "OP_OR", OpXor: "OP_XOR", OpEqual: "OP_EQUAL", OpEqualVerify: "OP_EQUALVERIFY",
+	OpReserved1: "OP_RESERVED1", OpReserved2: "OP_RESERVED2", Op1Add: "OP_1ADD",
+	Op1Sub: "OP_1SUB", Op2Mul: "OP_2MUL", Op2Div: "OP_2DIV", OpNegate: "OP_NEGATE",
+	OpAbs: "OP_ABS", OpNot: "OP_NOT", Op0NotEqual: "OP_0NOTEQUAL", OpAdd: "OP_ADD",
+	OpSub: "OP_SUB", OpMul: "OP_MUL", OpDiv: "OP_DIV", OpMod: "OP_MOD",
+	OpLShift: "OP_LSHIFT", OpRShift: "OP_RSHIFT", OpBoolAnd: "OP_BOOLAND",
+	OpBoolOr: "OP_BOOLOR", OpNumEqual: "OP_NUMEQUAL", OpNumEqualVerify: "OP_NUMEQUALVERIFY",
+	OpNumNotEqual: "OP_NUMNOTEQUAL", OpLessThan: "OP_LESSTHAN",
+	OpGreaterThan: "OP_GREATERTHAN", OpLessThanOrEqual: "OP_LESSTHANOREQUAL",
+	OpGreaterThanOrEqual: "OP_GREATERTHANOREQUAL", OpMin: "OP_MIN", OpMax: "OP_MAX",
+	OpWithin: "OP_WITHIN", OpRipemd160: "OP_RIPEMD160", OpSha1: "OP_SHA1",
+	OpSha256: "OP_SHA256", OpHash160: "OP_HASH160", OpHash256: "OP_HASH256",
+	OpCodeSeparator: "OP_CODESEPARATOR", OpCheckSig: "OP_CHECKSIG",
+	OpCheckSigVerify: "OP_CHECKSIGVERIFY", OpCheckMultiSig: "OP_CHECKMULTISIG",
+	OpCheckMultiSigVerify: "OP_CHECKMULTISIGVERIFY", OpNop1: "OP_NOP1",
+	OpCheckLockTimeVerify: "OP_CHECKLOCKTIMEVERIFY",
+	OpCheckSequenceVerify: "OP_CHECKSEQUENCEVERIFY",
+}
+
+// opcodeAliases maps alternative opcode names to the opcode.
+var opcodeAliases = map[string]byte{
+	"OP_FALSE": OpFalse, "OP_TRUE": OpTrue, "OP_NOP2": OpCheckLockTimeVerify,
+	"OP_CLTV": OpCheckLockTimeVerify, "OP_NOP3": OpCheckSequenceVerify,
+	"OP_CSV": OpCheckSequenceVerify,
+}
+
+// ScriptElement script element (opcode or push data).
+type ScriptElement struct {
+	Opcode byte
+	Data   []byte
+	IsPush bool
+}
+
+// GetOpcodeName returns the opcode name.
+func GetOpcodeName(opcode byte) string {
+	if opcode >= OpTrue && opcode <= Op16 {
+		return fmt.Sprintf("OP_%d", opcode-OpTrue+1)
+	}
+	if opcode >= OpNop4 && opcode <= OpNop10 {
+		return fmt.Sprintf("OP_NOP%d", opcode-OpNop1+1)
+	}
+	if name, ok := opcodeNames[opcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("OP_UNKNOWN(0x%02x)", opcode)
+}
+
+// GetOpcodeFromName returns the opcode by name.
+func GetOpcodeFromName(name string) (opcode byte, ok bool) {
+	name = strings.ToUpper(name)
+	if !strings.HasPrefix(name, "OP_") {
+		name = "OP_" + name
+	}
+	if opcode, ok = opcodeAliases[name]; ok {
+		return opcode, true
+	}
+	if num, err := strconv.Atoi(strings.TrimPrefix(name, "OP_")); err == nil &&
+		num >= 0 && num <= 16 {
+		if num == 0 {
+			return OpFalse, true
+		}
+		return OpTrue + byte(num-1), true
+	}
+	for i := 0; i <= 0xff; i++ {
+		// OP_UNKNOWN(0x..) has the lower case hex.
+		if strings.EqualFold(GetOpcodeName(byte(i)), name) {
+			return byte(i), true
+		}
+	}
+	return 0, false
+}
+
+// ToAsm returns the element in asm format.
+// The push opcode is written before the data if it is not the smallest for the data size.
+// (ex. "OP_PUSHDATA1 05")
+func (elem ScriptElement) ToAsm() string {
+	if !elem.IsPush {
+		return GetOpcodeName(elem.Opcode)
+	}
+	data := "0"
+	if len(elem.Data) > 0 {
+		data = hex.EncodeToString(elem.Data)
+	}
+	if elem.Opcode != getPushOpcode(len(elem.Data)) {
+		return GetOpcodeName(elem.Opcode) + " " + data
+	}
+	return data
+}
+
+// ParseScriptBytes splits the script into the elements.
+func ParseScriptBytes(script []byte) (elements []ScriptElement, err error) {
+	offset := 0
+	for offset < len(script) {
+		opcode := script[offset]
+		offset++
+		if opcode > OpPushData4 {
+			elements = append(elements, ScriptElement{Opcode: opcode})
+			continue
+		}
+
+		size := int(opcode)
+		switch opcode {
+		case OpPushData1:
+			if offset+1 > len(script) {
+				return nil, errors.New("script pushdata1 size is short")
+			}
+			size = int(script[offset])
+			offset++
+		case OpPushData2:
+			if offset+2 > len(script) {
+				return nil, errors.New("script pushdata2 size is short")
+			}
+			size = int(binary.LittleEndian.Uint16(script[offset:]))
+			offset += 2
+		case OpPushData4:
+			if offset+4 > len(script) {
+				return nil, errors.New("script pushdata4 size is short")
+			}
+			size64 := uint64(binary.LittleEndian.Uint32(script[offset:]))
+			offset += 4
+			if size64 > uint64(len(script)-offset) {
+				return nil, errors.New("script push data is short")
+			}
+			size = int(size64)
+		}
+		if size > len(script)-offset {
+			return nil, errors.New("script push data is short")
+		}
+		data := make([]byte, size)
+		copy(data, script[offset:offset+size])
+		offset += size
+		elements = append(elements, ScriptElement{
+			Opcode: opcode,
+			Data:   data,
+			IsPush: true,
+		})
+	}
+	return elements, nil
+}
+
+// ParseScriptHex splits the script hex into the elements.
+func ParseScriptHex(scriptHex string) (elements []ScriptElement, err error) {
+	script, err := hex.DecodeString(scriptHex)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScriptBytes(script)
+}
+
+// ScriptToAsm returns the script in asm format.
+func ScriptToAsm(elements []ScriptElement) string {
+	items := make([]string, len(elements))
+	for i, elem := range elements {
+		items[i] = elem.ToAsm()
+	}
+	return strings.Join(items, " ")
+}
+
+// ScriptBuilder builds a script.
+type ScriptBuilder struct {
+	script []byte
+}
+
+// NewScriptBuilder returns a new ScriptBuilder struct.
+func NewScriptBuilder() *ScriptBuilder {
+	return &ScriptBuilder{}
+}
+
+// AddOp appends the opcode.
+func (builder *ScriptBuilder) AddOp(opcodes ...byte) *ScriptBuilder {
+	builder.script = append(builder.script, opcodes...)
+	return builder
+}
+
+// AddData appends the data with minimal push.
+func (builder *ScriptBuilder) AddData(data []byte) *ScriptBuilder {
+	size := len(data)
+	switch {
+	case size == 0:
+		builder.script = append(builder.script, OpFalse)
+		return builder
+	case size == 1 && data[0] >= 1 && data[0] <= 16:
+		builder.script = append(builder.script, OpTrue+data[0]-1)
+		return builder
+	case size == 1 && data[0] == 0x81:
+		builder.script = append(builder.script, Op1Negate)
+		return builder
+	}
+	return builder.addPush(getPushOpcode(size), data)
+}
+
+// addPush appends the data with the push opcode. The opcode must be valid for the data size.
+func (builder *ScriptBuilder) addPush(opcode byte, data []byte) *ScriptBuilder {
+	size := len(data)
+	builder.script = append(builder.script, opcode)
+	switch opcode {
+	case OpPushData1:
+		builder.script = append(builder.script, byte(size))
+	case OpPushData2:
+		builder.script = append(builder.script, byte(size), byte(size>>8))
+	case OpPushData4:
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(size))
+		builder.script = append(builder.script, buf...)
+	}
+	builder.script = append(builder.script, data...)
+	return builder
+}
+
+// getPushOpcode returns the smallest push opcode for the data size. (the small number opcodes are not used)
+func getPushOpcode(size int) byte {
+	switch {
+	case size < int(OpPushData1):
+		return byte(size)
+	case size <= 0xff:
+		return OpPushData1
+	case size <= 0xffff:
+		return OpPushData2
+	}
+	return OpPushData4
+}
+
+// AddInt appends the number with minimal encoding.
+func (builder *ScriptBuilder) AddInt(num int64) *ScriptBuilder {
+	if num == 0 {
+		return builder.AddOp(OpFalse)
+	}
+	if num == -1 || (num >= 1 && num <= 16) {
+		return builder.AddOp(byte(int64(OpTrue) + num - 1))
+	}
+	return builder.AddData(EncodeScriptNum(num))
+}
+
+// AddElements appends the parsed elements. The push opcodes are kept.
+func (builder *ScriptBuilder) AddElements(elements []ScriptElement) *ScriptBuilder {
+	for _, elem := range elements {
+		if elem.IsPush {
+			builder.addPush(elem.Opcode, elem.Data)
+		} else {
+			builder.AddOp(elem.Opcode)
+		}
+	}
+	return builder
+}
+
+// Bytes returns the script.
+func (builder *ScriptBuilder) Bytes() []byte {
+	return builder.script
+}
+
+// Hex returns the script in hex format.
+func (builder *ScriptBuilder) Hex() string {
+	return hex.EncodeToString(builder.script)
+}
+
+// EncodeScriptNum encodes the number in script number format.
+func EncodeScriptNum(num int64) []byte {
+	if num == 0 {
+		return []byte{}
+	}
+	isNegative := num < 0
+	abs := uint64(num)
+	if isNegative {
+		abs = uint64(-num)
+	}
+	result := []byte{}
+	for abs > 0 {
+		result = append(result, byte(abs&0xff))
+		abs >>= 8
+	}
+	if result[len(result)-1]&0x80 != 0 {
+		if isNegative {
+			result = append(result, 0x80)
+		} else {
+			result = append(result, 0x00)
+		}
+	} else if isNegative {
+		result[len(result)-1] |= 0x80
+	}
+	return result
+}
+
+// DecodeScriptNum decodes the script number.
+func DecodeScriptNum(data []byte, maxSize int) (int64, error) {
+	if len(data) > maxSize {
+		return 0, fmt.Errorf("script number overflow. size=%d", len(data))
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if data[len(data)-1]&0x7f == 0 &&
+		(len(data) == 1 || data[len(data)-2]&0x80 == 0) {
+		return 0, errors.New("script number is not minimally encoded")
+	}
+	result := int64(0)
+	for i, b := range data {
+		result |= int64(b) << uint(8*i)
+	}
+	if data[len(data)-1]&0x80 != 0 {
+		result &= ^(int64(0x80) << uint(8*(len(data)-1)))
+		return -result, nil
+	}
+	return result, nil
+}
+
+// GetElementNumber returns the number of small int opcode or push data.
+func GetElementNumber(elem ScriptElement, maxSize int) (int64, error) {
+	if elem.IsPush {
+		return DecodeScriptNum(elem.Data, maxSize)
+	}
+	switch {
+	case elem.Opcode == OpFalse:
+		return 0, nil
+	case elem.Opcode == Op1Negate:
+		return -1, nil
+	case elem.Opcode >= OpTrue && elem.Opcode <= Op16:
+		return int64(elem.Opcode-OpTrue) + 1, nil
+	}
+	return 0, fmt.Errorf("%s is not a number", GetOpcodeName(elem.Opcode))
+}
+
+// GetVarIntSize returns the size of the varint.
+func GetVarIntSize(value int) int {
+	switch {
+	case value < 0xfd:
+		return 1
+	case value <= 0xffff:
+		return 3
+	case value <= 0xffffffff:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// GetSerializeSize returns the size of the serialized data with varint prefix.
+func GetSerializeSize(size int) int {
+	return GetVarIntSize(size) + size
+}
+
+// ParseScriptAsm parses the script asm into the script.
+// Tokens are opcode names (OP_DUP), "0", "-1" and hex push data.
+// A token without OP_ prefix is push data if it is valid hex. (ex. DUP is opcode, ADD is data)
+// The push data is not converted to the small number opcode. (ex. "05" is 0105, OP_5 is 55)
+// OP_PUSHDATA1/2/4 is followed by the push data. (ex. "OP_PUSHDATA1 05" is 4c0105, "0" is empty)
+func ParseScriptAsm(asm string) ([]byte, error) {
+	builder := NewScriptBuilder()
+	tokens := strings.Fields(asm)
+	for index := 0; index < len(tokens); index++ {
+		token := tokens[index]
+		if token == "0" {
+			builder.AddOp(OpFalse)
+			continue
+		} else if token == "-1" {
+			builder.AddOp(Op1Negate)
+			continue
+		}
+		if !strings.HasPrefix(strings.ToUpper(token), "OP_") {
+			if data, err := hex.DecodeString(token); err == nil {
+				builder.addPush(getPushOpcode(len(data)), data)
+				continue
+			}
+		}
+		opcode, ok := GetOpcodeFromName(token)
+		if !ok {
+			return nil, fmt.Errorf("%s is unknown opcode or invalid hex", token)
+		}
+		if opcode < OpPushData1 || opcode > OpPushData4 {
+			builder.AddOp(opcode)
+			continue
+		}
+		data, err := parsePushDataToken(opcode, tokens[index+1:])
+		if err != nil {
+			return nil, fmt.Errorf("%s %s", token, err.Error())
+		}
+		builder.addPush(opcode, data)
+		index++
+	}
+	return builder.Bytes(), nil
+}
+
+// parsePushDataToken parses the push data token after OP_PUSHDATA1/2/4.
+func parsePushDataToken(opcode byte, tokens []string) ([]byte, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("push data is required")
+	}
+	data := []byte{}
+	if tokens[0] != "0" {
+		var err error
+		if data, err = hex.DecodeString(tokens[0]); err != nil {
+			return nil, fmt.Errorf("push data %s is invalid hex", tokens[0])
+		}
+	}
+	if (opcode == OpPushData1 && len(data) > 0xff) || (opcode == OpPushData2 && len(data) > 0xffff) {
+		return nil, fmt.Errorf("push data size %d is too large", len(data))
+	}
+	return data, nil
+}
+
+// ScriptTemplate standard script template information.
+type ScriptTemplate struct {
+	Type           string
+	WitnessVersion int
+	Hash           []byte
+	Pubkeys        [][]byte
+	RequiredSigs   int
+}
+
+// ClassifyScript classifies the script into the standard template.
+func ClassifyScript(script []byte) ScriptTemplate {
+	result := ScriptTemplate{Type: "nonstandard", WitnessVersion: -1}
+	size := len(script)
+	switch {
+	case size == 0:
+		result.Type = "empty"
+		return result
+	case size == 25 && script[0] == OpDup && script[1] == OpHash160 && script[2] == 20 &&
+		script[23] == OpEqualVerify && script[24] == OpCheckSig:
+		result.Type = "p2pkh"
+		result.Hash = script[3:23]
+		return result
+	case size == 23 && script[0] == OpHash160 && script[1] == 20 && script[22] == OpEqual:
+		result.Type = "p2sh"
+		result.Hash = script[2:22]
+		return result
+	case size >= 4 && size <= 42 && (script[0] == OpFalse || (script[0] >= OpTrue && script[0] <= Op16)) &&
+		int(script[1]) == size-2:
+		result.WitnessVersion = 0
+		if script[0] != OpFalse {
+			result.WitnessVersion = int(script[0]-OpTrue) + 1
+		}
+		result.Hash = script[2:]
+		switch {
+		case result.WitnessVersion == 0 && size == 22:
+			result.Type = "p2wpkh"
+		case result.WitnessVersion == 0 && size == 34:
+			result.Type = "p2wsh"
+		case result.WitnessVersion == 1 && size == 34:
+			result.Type = "p2tr"
+		case result.WitnessVersion == 0:
+			result.Type = "nonstandard"
+			result.WitnessVersion = -1
+			result.Hash = nil
+		default:
+			result.Type = "witness_unknown"
+		}
+		return result
+	case script[0] == OpReturn:
+		result.Type = "nulldata"
+		return result
+	}
+
+	elements, err := ParseScriptBytes(script)
+	if err != nil {
+		return result
+	}
+	count := len(elements)
+	if count == 2 && elements[0].IsPush && elements[1].Opcode == OpCheckSig &&
+		(len(elements[0].Data) == 33 || len(elements[0].Data) == 65) {
+		result.Type = "pubkey"
+		result.Pubkeys = [][]byte{elements[0].Data}
+		result.RequiredSigs = 1
+		return result
+	}
+	if count >= 4 && elements[count-1].Opcode == OpCheckMultiSig && !elements[count-1].IsPush {
+		required, err1 := GetElementNumber(elements[0], 1)
+		keyNum, err2 := GetElementNumber(elements[count-2], 1)
+		if err1 != nil || err2 != nil || keyNum != int64(count-3) ||
+			required < 1 || required > keyNum || keyNum > 20 {
+			return result
+		}
+		pubkeys := [][]byte{}
+		for _, elem := range elements[1 : count-2] {
+			if !elem.IsPush || (len(elem.Data) != 33 && len(elem.Data) != 65) {
+				return result
+			}
+			pubkeys = append(pubkeys, elem.Data)
+		}
+		result.Type = "multisig"
+		result.Pubkeys = pubkeys
+		result.RequiredSigs = int(required)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+)
+
+// TxScriptChecker checks the signature and timelock of the transaction input.
+type TxScriptChecker struct {
+	tx          *Transaction
+	txHex       string
+	index       int
+	amount      int64
+	commitment  string
+	networkType int
+}
+
+// NewTxScriptChecker returns a new TxScriptChecker struct for the transaction input.
+func NewTxScriptChecker(tx *Transaction, index int, amount int64, commitment string, networkType int) *TxScriptChecker {
+	return &TxScriptChecker{
+		tx:          tx,
+		txHex:       tx.Hex(),
+		index:       index,
+		amount:      amount,
+		commitment:  commitment,
+		networkType: networkType,
+	}
+}
+
+// CheckSignature checks the signature with the sighash of the transaction input.
+func (checker *TxScriptChecker) CheckSignature(signature, pubkey, scriptCode []byte, isWitness bool) SignatureCheckResult {
+	result := SignatureCheckResult{}
+	rawSignature, _, _, err := cfd.CfdGoDecodeSignatureFromDer(hex.EncodeToString(signature))
+	if err != nil {
+		result.Reason = "signature is invalid der format. " + err.Error()
+		return result
+	}
+	sighashType := NewSighashTypeFromByte(signature[len(signature)-1])
+	result.SighashType = sighashType.String()
+
+	// the sighash is calculated with the script code as the redeem script.
+	hashType := int(cfd.KCfdP2sh)
+	if isWitness {
+		hashType = int(cfd.KCfdP2wsh)
+	}
+	txin := checker.tx.TxIn[checker.index]
+	if checker.tx.IsElements {
+		result.Sighash, err = cfd.CfdGoCreateConfidentialSighash(checker.txHex, txin.Txid, txin.Vout,
+			hashType, "", hex.EncodeToString(scriptCode), checker.amount, checker.commitment,
+			sighashType.GetCfdType(), sighashType.AnyoneCanPay)
+	} else {
+		result.Sighash, err = cfd.CfdGoCreateSighash(checker.networkType, checker.txHex, txin.Txid, txin.Vout,
+			hashType, "", hex.EncodeToString(scriptCode), checker.amount, sighashType.GetCfdType(), sighashType.AnyoneCanPay)
+	}
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+	result.IsValid, err = cfd.CfdGoVerifyEcSignature(result.Sighash, hex.EncodeToString(pubkey), rawSignature)
+	if err != nil {
+		result.IsValid = false
+		result.Reason = err.Error()
+	} else if !result.IsValid {
+		result.Reason = "signature is unmatch the sighash and pubkey"
+	}
+	return result
+}
+
+// CheckLocktime checks the locktime. (BIP65)
+func (checker *TxScriptChecker) CheckLocktime(locktime int64) error {
+	tx := checker.tx
+	switch {
+	case (int64(tx.Locktime) < LocktimeThreshold) != (locktime < LocktimeThreshold):
+		return errors.New("locktime type (block height or time) is unmatch the tx locktime")
+	case locktime > int64(tx.Locktime):
+		return fmt.Errorf("locktime %d is not reached. tx locktime is %d", locktime, tx.Locktime)
+	case tx.TxIn[checker.index].Sequence == SequenceFinal:
+		return errors.New("sequence is final")
+	}
+	return nil
+}
+
+// CheckSequence checks the sequence. (BIP112)
+func (checker *TxScriptChecker) CheckSequence(sequence int64) error {
+	txSequence := checker.tx.TxIn[checker.index].Sequence
+	mask := uint32(sequenceTypeFlag) | SequenceValueMask
+	switch {
+	case checker.tx.Version < 2:
+		return errors.New("tx version must be 2 or higher")
+	case (txSequence & sequenceDisableFlag) != 0:
+		return errors.New("tx sequence disables the relative timelock")
+	case (txSequence & sequenceTypeFlag) != (uint32(sequence) & sequenceTypeFlag):
+		return errors.New("sequence type (blocks or time) is unmatch the tx sequence")
+	case (uint32(sequence) & mask) > (txSequence & mask):
+		return fmt.Errorf("sequence %s is not reached. tx sequence is %s",
+			DescribeSequence(uint32(sequence)), DescribeSequence(txSequence))
+	}
+	return nil
+}
@@ -0,0 +1,833 @@
+package txbuilder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"cfd-cli/hashes"
+)
+
+const (
+	// maxScriptElementSize the maximum size of the push data.
+	maxScriptElementSize = 520
+	// maxScriptOpsCount the maximum number of non-push opcodes per script.
+	maxScriptOpsCount = 201
+	// maxScriptStackSize the maximum size of the stack and the altstack.
+	maxScriptStackSize = 1000
+	// maxMultisigPubkeys the maximum number of pubkeys of CHECKMULTISIG.
+	maxMultisigPubkeys = 20
+)
+
+// SignatureCheckResult signature check result.
+type SignatureCheckResult struct {
+	IsValid     bool
+	Sighash     string
+	SighashType string
+	Reason      string
+}
+
+// SignatureChecker checks the signature and timelock on the script execution.
+type SignatureChecker interface {
+	// CheckSignature checks the signature. scriptCode is the script for the sighash.
+	CheckSignature(signature, pubkey, scriptCode []byte, isWitness bool) SignatureCheckResult
+	// CheckLocktime checks the locktime for OP_CHECKLOCKTIMEVERIFY.
+	CheckLocktime(locktime int64) error
+	// CheckSequence checks the sequence for OP_CHECKSEQUENCEVERIFY.
+	CheckSequence(sequence int64) error
+}
+
+// ScriptStep script execution step.
+type ScriptStep struct {
+	Phase    string
+	Index    int
+	Element  ScriptElement
+	Executed bool
+	Stack    [][]byte
+	AltStack [][]byte
+	Notes    []string
+}
+
+// ScriptInterpreter executes the script. (segwit v0 and legacy)
+type ScriptInterpreter struct {
+	Stack    [][]byte
+	AltStack [][]byte
+	Checker  SignatureChecker
+	Phase    string
+	// Trace is called after each opcode.
+	Trace func(step ScriptStep)
+}
+
+// NewScriptInterpreter returns a new ScriptInterpreter struct.
+func NewScriptInterpreter(checker SignatureChecker) *ScriptInterpreter {
+	return &ScriptInterpreter{
+		Stack:    [][]byte{},
+		AltStack: [][]byte{},
+		Checker:  checker,
+	}
+}
+
+// VerifyInputScript executes the scriptsig, the locking script and the redeem script or witness script.
+func (interp *ScriptInterpreter) VerifyInputScript(scriptSig []byte, witness [][]byte, lockingScript []byte) error {
+	interp.Phase = "scriptSig"
+	if err := interp.Execute(scriptSig, false); err != nil {
+		return err
+	}
+	scriptSigStack := copyStack(interp.Stack)
+	interp.Phase = "lockingScript"
+	if err := interp.Execute(lockingScript, false); err != nil {
+		return err
+	}
+	if !interp.isTopTrue() {
+		return errors.New("lockingScript evaluated to false")
+	}
+
+	template := ClassifyScript(lockingScript)
+	if template.WitnessVersion >= 0 {
+		if len(scriptSig) != 0 {
+			return errors.New("scriptSig must be empty for the native segwit")
+		}
+		return interp.verifyWitness(template, witness)
+	}
+	if template.Type != "p2sh" {
+		if len(witness) != 0 {
+			return errors.New("witness is unexpected")
+		}
+		return nil
+	}
+
+	if !isPushOnly(scriptSig) {
+		return errors.New("scriptSig must be push only for p2sh")
+	}
+	if len(scriptSigStack) == 0 {
+		return errors.New("redeemScript is not found")
+	}
+	redeemScript := scriptSigStack[len(scriptSigStack)-1]
+	interp.Stack = scriptSigStack[:len(scriptSigStack)-1]
+	interp.AltStack = [][]byte{}
+	interp.Phase = "redeemScript"
+	if err := interp.Execute(redeemScript, false); err != nil {
+		return err
+	}
+	if !interp.isTopTrue() {
+		return errors.New("redeemScript evaluated to false")
+	}
+	redeemTemplate := ClassifyScript(redeemScript)
+	if redeemTemplate.WitnessVersion < 0 {
+		if len(witness) != 0 {
+			return errors.New("witness is unexpected")
+		}
+		return nil
+	}
+	if !bytes.Equal(scriptSig, NewScriptBuilder().AddData(redeemScript).Bytes()) {
+		return errors.New("scriptSig must be only the redeemScript push for the nested segwit")
+	}
+	return interp.verifyWitness(redeemTemplate, witness)
+}
+
+// verifyWitness executes the witness program.
+func (interp *ScriptInterpreter) verifyWitness(template ScriptTemplate, witness [][]byte) error {
+	var script []byte
+	switch template.Type {
+	case "p2wpkh":
+		if len(witness) != 2 {
+			return fmt.Errorf("p2wpkh witness must be 2 items, but %d items", len(witness))
+		}
+		script = NewScriptBuilder().AddOp(OpDup, OpHash160).AddData(template.Hash).
+			AddOp(OpEqualVerify, OpCheckSig).Bytes()
+		interp.Stack = copyStack(witness)
+	case "p2wsh":
+		if len(witness) == 0 {
+			return errors.New("p2wsh witness is empty")
+		}
+		script = witness[len(witness)-1]
+		if !bytes.Equal(hashes.Sha256(script), template.Hash) {
+			return errors.New("witnessScript hash is unmatch the locking script")
+		}
+		interp.Stack = copyStack(witness[:len(witness)-1])
+	default:
+		return fmt.Errorf("witness version %d is not supported", template.WitnessVersion)
+	}
+	for _, item := range interp.Stack {
+		if len(item) > maxScriptElementSize {
+			return errors.New("witness item size is too large")
+		}
+	}
+	interp.AltStack = [][]byte{}
+	interp.Phase = "witnessScript"
+	if err := interp.Execute(script, true); err != nil {
+		return err
+	}
+	if len(interp.Stack) != 1 {
+		return fmt.Errorf("stack must be only one item after the execution (cleanstack), but %d items", len(interp.Stack))
+	}
+	if !interp.isTopTrue() {
+		return errors.New("witnessScript evaluated to false")
+	}
+	return nil
+}
+
+// Execute executes the script on the current stack.
+func (interp *ScriptInterpreter) Execute(script []byte, isWitness bool) error {
+	elements, err := ParseScriptBytes(script)
+	if err != nil {
+		return fmt.Errorf("%s is invalid. %s", interp.Phase, err.Error())
+	}
+	state := &executionState{elements: elements, isWitness: isWitness}
+	for index, elem := range elements {
+		state.index = index
+		step := ScriptStep{Phase: interp.Phase, Index: index, Element: elem, Executed: state.isExecuting()}
+		notes, err := interp.executeElement(state, elem)
+		step.Notes = notes
+		step.Stack = copyStack(interp.Stack)
+		step.AltStack = copyStack(interp.AltStack)
+		if interp.Trace != nil {
+			interp.Trace(step)
+		}
+		if err != nil {
+			return fmt.Errorf("%s[%d] %s failed. %s", interp.Phase, index, elem.ToAsm(), err.Error())
+		}
+		if len(interp.Stack)+len(interp.AltStack) > maxScriptStackSize {
+			return fmt.Errorf("%s[%d] stack size is too large", interp.Phase, index)
+		}
+	}
+	if len(state.conditions) != 0 {
+		return fmt.Errorf("%s has unbalanced conditional", interp.Phase)
+	}
+	return nil
+}
+
+// executionState the state of a script execution.
+type executionState struct {
+	elements      []ScriptElement
+	index         int
+	isWitness     bool
+	conditions    []bool
+	codeSeparator int
+	opsCount      int
+}
+
+// isExecuting returns true if all conditions are true.
+func (state *executionState) isExecuting() bool {
+	for _, condition := range state.conditions {
+		if !condition {
+			return false
+		}
+	}
+	return true
+}
+
+// executeElement executes the script element.
+func (interp *ScriptInterpreter) executeElement(state *executionState, elem ScriptElement) (notes []string, err error) {
+	if elem.IsPush {
+		if len(elem.Data) > maxScriptElementSize {
+			return nil, errors.New("push data size is too large")
+		}
+		if state.isExecuting() {
+			interp.push(elem.Data)
+		}
+		return nil, nil
+	}
+
+	opcode := elem.Opcode
+	if opcode > Op16 {
+		state.opsCount++
+		if state.opsCount > maxScriptOpsCount {
+			return nil, errors.New("opcode count is over the limit")
+		}
+	}
+	switch opcode {
+	case OpCat, OpSubStr, OpLeft, OpRight, OpInvert, OpAnd, OpOr, OpXor,
+		Op2Mul, Op2Div, OpMul, OpDiv, OpMod, OpLShift, OpRShift:
+		return nil, errors.New("opcode is disabled")
+	case OpVerIf, OpVerNotIf:
+		return nil, errors.New("opcode is invalid")
+	}
+
+	switch opcode {
+	case OpIf, OpNotIf:
+		condition := false
+		if state.isExecuting() {
+			value, err := interp.pop()
+			if err != nil {
+				return nil, err
+			}
+			if state.isWitness && (len(value) > 1 || (len(value) == 1 && value[0] != 1)) {
+				return nil, errors.New("condition must be empty or 0x01 on the witness script (minimalif)")
+			}
+			condition = castToBool(value)
+			if opcode == OpNotIf {
+				condition = !condition
+			}
+		}
+		state.conditions = append(state.conditions, condition)
+		return nil, nil
+	case OpElse:
+		if len(state.conditions) == 0 {
+			return nil, errors.New("OP_ELSE without OP_IF")
+		}
+		state.conditions[len(state.conditions)-1] = !state.conditions[len(state.conditions)-1]
+		return nil, nil
+	case OpEndIf:
+		if len(state.conditions) == 0 {
+			return nil, errors.New("OP_ENDIF without OP_IF")
+		}
+		state.conditions = state.conditions[:len(state.conditions)-1]
+		return nil, nil
+	}
+	if !state.isExecuting() {
+		return nil, nil
+	}
+
+	switch {
+	case opcode == Op1Negate:
+		interp.pushNumber(-1)
+		return nil, nil
+	case opcode >= OpTrue && opcode <= Op16:
+		interp.pushNumber(int64(opcode-OpTrue) + 1)
+		return nil, nil
+	case opcode == OpNop || opcode == OpNop1 || (opcode >= OpNop4 && opcode <= OpNop10):
+		return nil, nil
+	case opcode == OpCheckSig || opcode == OpCheckSigVerify:
+		return interp.executeCheckSig(state, opcode == OpCheckSigVerify)
+	case opcode == OpCheckMultiSig || opcode == OpCheckMultiSigVerify:
+		return interp.executeCheckMultiSig(state, opcode == OpCheckMultiSigVerify)
+	case opcode == OpCodeSeparator:
+		state.codeSeparator = state.index + 1
+		return nil, nil
+	}
+	return nil, interp.executeOpcode(opcode)
+}
+
+// executeOpcode executes the stack, arithmetic, hash and timelock opcodes.
+func (interp *ScriptInterpreter) executeOpcode(opcode byte) error {
+	switch opcode {
+	case OpVerify:
+		value, err := interp.pop()
+		if err != nil {
+			return err
+		}
+		if !castToBool(value) {
+			return errors.New("verify failed")
+		}
+	case OpReturn:
+		return errors.New("OP_RETURN is executed")
+	case OpCheckLockTimeVerify, OpCheckSequenceVerify:
+		value, err := interp.peek(1)
+		if err != nil {
+			return err
+		}
+		num, err := DecodeScriptNum(value, 5)
+		if err != nil {
+			return err
+		}
+		if num < 0 {
+			return errors.New("negative timelock")
+		}
+		if opcode == OpCheckLockTimeVerify {
+			return interp.Checker.CheckLocktime(num)
+		}
+		if (uint32(num) & sequenceDisableFlag) != 0 {
+			return nil
+		}
+		return interp.Checker.CheckSequence(num)
+	case OpToAltStack:
+		value, err := interp.pop()
+		if err != nil {
+			return err
+		}
+		interp.AltStack = append(interp.AltStack, value)
+	case OpFromAltStack:
+		if len(interp.AltStack) == 0 {
+			return errors.New("altstack is empty")
+		}
+		interp.push(interp.AltStack[len(interp.AltStack)-1])
+		interp.AltStack = interp.AltStack[:len(interp.AltStack)-1]
+	case Op2Drop:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		interp.Stack = interp.Stack[:len(interp.Stack)-2]
+	case OpDrop:
+		if _, err := interp.pop(); err != nil {
+			return err
+		}
+	case OpNip:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		interp.remove(2)
+	case Op2Dup:
+		return interp.copyItems(2, 2)
+	case Op3Dup:
+		return interp.copyItems(3, 3)
+	case Op2Over:
+		return interp.copyItems(4, 2)
+	case OpDup:
+		return interp.copyItems(1, 1)
+	case OpOver:
+		return interp.copyItems(2, 1)
+	case Op2Rot:
+		if err := interp.checkDepth(6); err != nil {
+			return err
+		}
+		first, second := interp.remove(6), interp.remove(5)
+		interp.push(first)
+		interp.push(second)
+	case Op2Swap:
+		if err := interp.checkDepth(4); err != nil {
+			return err
+		}
+		first, second := interp.remove(4), interp.remove(3)
+		interp.push(first)
+		interp.push(second)
+	case OpIfDup:
+		value, err := interp.peek(1)
+		if err != nil {
+			return err
+		}
+		if castToBool(value) {
+			interp.push(value)
+		}
+	case OpDepth:
+		interp.pushNumber(int64(len(interp.Stack)))
+	case OpPick, OpRoll:
+		num, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		if num < 0 || num >= int64(len(interp.Stack)) {
+			return errors.New("stack index is out of range")
+		}
+		if opcode == OpPick {
+			value, _ := interp.peek(int(num) + 1)
+			interp.push(value)
+		} else {
+			interp.push(interp.remove(int(num) + 1))
+		}
+	case OpRot:
+		if err := interp.checkDepth(3); err != nil {
+			return err
+		}
+		interp.push(interp.remove(3))
+	case OpSwap:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		interp.push(interp.remove(2))
+	case OpTuck:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		value, _ := interp.peek(1)
+		interp.Stack = append(interp.Stack, nil)
+		copy(interp.Stack[len(interp.Stack)-2:], interp.Stack[len(interp.Stack)-3:len(interp.Stack)-1])
+		interp.Stack[len(interp.Stack)-3] = value
+	case OpSize:
+		value, err := interp.peek(1)
+		if err != nil {
+			return err
+		}
+		interp.pushNumber(int64(len(value)))
+	case OpEqual, OpEqualVerify:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		second, _ := interp.pop()
+		first, _ := interp.pop()
+		isEqual := bytes.Equal(first, second)
+		if opcode == OpEqualVerify {
+			if !isEqual {
+				return fmt.Errorf("%x and %x are not equal", first, second)
+			}
+			return nil
+		}
+		interp.pushBool(isEqual)
+	case OpRipemd160, OpSha1, OpSha256, OpHash160, OpHash256:
+		value, err := interp.pop()
+		if err != nil {
+			return err
+		}
+		hashFunc := map[byte]func([]byte) []byte{OpRipemd160: hashes.Ripemd160, OpSha1: hashes.Sha1,
+			OpSha256: hashes.Sha256, OpHash160: hashes.Hash160, OpHash256: hashes.Hash256}[opcode]
+		interp.push(hashFunc(value))
+	case OpReserved, OpVer, OpReserved1, OpReserved2:
+		return errors.New("reserved opcode is executed")
+	default:
+		return interp.executeArithmetic(opcode)
+	}
+	return nil
+}
+
+// executeArithmetic executes the arithmetic opcodes.
+func (interp *ScriptInterpreter) executeArithmetic(opcode byte) error {
+	switch opcode {
+	case Op1Add, Op1Sub, OpNegate, OpAbs, OpNot, Op0NotEqual:
+		num, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case Op1Add:
+			num++
+		case Op1Sub:
+			num--
+		case OpNegate:
+			num = -num
+		case OpAbs:
+			if num < 0 {
+				num = -num
+			}
+		case OpNot:
+			num = boolToNumber(num == 0)
+		case Op0NotEqual:
+			num = boolToNumber(num != 0)
+		}
+		interp.pushNumber(num)
+	case OpAdd, OpSub, OpBoolAnd, OpBoolOr, OpNumEqual, OpNumEqualVerify, OpNumNotEqual,
+		OpLessThan, OpGreaterThan, OpLessThanOrEqual, OpGreaterThanOrEqual, OpMin, OpMax:
+		if err := interp.checkDepth(2); err != nil {
+			return err
+		}
+		second, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		first, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		var result int64
+		switch opcode {
+		case OpAdd:
+			result = first + second
+		case OpSub:
+			result = first - second
+		case OpBoolAnd:
+			result = boolToNumber(first != 0 && second != 0)
+		case OpBoolOr:
+			result = boolToNumber(first != 0 || second != 0)
+		case OpNumEqual, OpNumEqualVerify:
+			result = boolToNumber(first == second)
+		case OpNumNotEqual:
+			result = boolToNumber(first != second)
+		case OpLessThan:
+			result = boolToNumber(first < second)
+		case OpGreaterThan:
+			result = boolToNumber(first > second)
+		case OpLessThanOrEqual:
+			result = boolToNumber(first <= second)
+		case OpGreaterThanOrEqual:
+			result = boolToNumber(first >= second)
+		case OpMin:
+			result = first
+			if second < first {
+				result = second
+			}
+		case OpMax:
+			result = first
+			if second > first {
+				result = second
+			}
+		}
+		if opcode == OpNumEqualVerify {
+			if result == 0 {
+				return fmt.Errorf("%d and %d are not equal", first, second)
+			}
+			return nil
+		}
+		interp.pushNumber(result)
+	case OpWithin:
+		if err := interp.checkDepth(3); err != nil {
+			return err
+		}
+		max, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		min, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		num, err := interp.popNumber()
+		if err != nil {
+			return err
+		}
+		interp.pushBool(min <= num && num < max)
+	default:
+		return errors.New("opcode is unknown")
+	}
+	return nil
+}
+
+// executeCheckSig executes OP_CHECKSIG and OP_CHECKSIGVERIFY.
+func (interp *ScriptInterpreter) executeCheckSig(state *executionState, isVerify bool) (notes []string, err error) {
+	if err = interp.checkDepth(2); err != nil {
+		return nil, err
+	}
+	pubkey, _ := interp.pop()
+	signature, _ := interp.pop()
+	scriptCode := state.getScriptCode(signature)
+	result := interp.checkSignature(signature, pubkey, scriptCode, state.isWitness)
+	notes = append(notes, formatSignatureCheck(signature, pubkey, result))
+	if isVerify {
+		if !result.IsValid {
+			return notes, errors.New("signature verification failed")
+		}
+		return notes, nil
+	}
+	if !result.IsValid && len(signature) != 0 {
+		notes = append(notes, "non-empty invalid signature fails the nullfail policy")
+	}
+	interp.pushBool(result.IsValid)
+	return notes, nil
+}
+
+// executeCheckMultiSig executes OP_CHECKMULTISIG and OP_CHECKMULTISIGVERIFY.
+func (interp *ScriptInterpreter) executeCheckMultiSig(state *executionState, isVerify bool) (notes []string, err error) {
+	keyNum, err := interp.popNumber()
+	if err != nil {
+		return nil, err
+	}
+	if keyNum < 0 || keyNum > maxMultisigPubkeys {
+		return nil, fmt.Errorf("pubkey count %d is out of range", keyNum)
+	}
+	state.opsCount += int(keyNum)
+	if state.opsCount > maxScriptOpsCount {
+		return nil, errors.New("opcode count is over the limit")
+	}
+	if err = interp.checkDepth(int(keyNum)); err != nil {
+		return nil, err
+	}
+	pubkeys := make([][]byte, keyNum)
+	for index := int(keyNum) - 1; index >= 0; index-- {
+		pubkeys[index], _ = interp.pop()
+	}
+	sigNum, err := interp.popNumber()
+	if err != nil {
+		return nil, err
+	}
+	if sigNum < 0 || sigNum > keyNum {
+		return nil, fmt.Errorf("signature count %d is out of range", sigNum)
+	}
+	if err = interp.checkDepth(int(sigNum) + 1); err != nil {
+		return nil, err
+	}
+	signatures := make([][]byte, sigNum)
+	for index := int(sigNum) - 1; index >= 0; index-- {
+		signatures[index], _ = interp.pop()
+	}
+	dummy, _ := interp.pop()
+	if len(dummy) != 0 {
+		return nil, errors.New("dummy element must be empty (nulldummy)")
+	}
+
+	scriptCode := state.getScriptCode(signatures...)
+	isSuccess := true
+	keyIndex := 0
+	for sigIndex, signature := range signatures {
+		matched := false
+		for keyIndex < len(pubkeys) && len(pubkeys)-keyIndex >= len(signatures)-sigIndex {
+			result := interp.checkSignature(signature, pubkeys[keyIndex], scriptCode, state.isWitness)
+			notes = append(notes, fmt.Sprintf("sig[%d] x pubkey[%d] %s",
+				sigIndex, keyIndex, formatSignatureCheck(signature, pubkeys[keyIndex], result)))
+			keyIndex++
+			if result.IsValid {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			notes = append(notes, fmt.Sprintf("sig[%d] does not match the remaining pubkeys", sigIndex))
+			isSuccess = false
+			break
+		}
+	}
+	if isVerify {
+		if !isSuccess {
+			return notes, errors.New("multisig verification failed")
+		}
+		return notes, nil
+	}
+	interp.pushBool(isSuccess)
+	return notes, nil
+}
+
+// checkSignature checks the signature with the checker.
+func (interp *ScriptInterpreter) checkSignature(signature, pubkey, scriptCode []byte, isWitness bool) SignatureCheckResult {
+	if len(signature) == 0 {
+		return SignatureCheckResult{Reason: "signature is empty"}
+	}
+	if interp.Checker == nil {
+		return SignatureCheckResult{Reason: "signature checker is not set"}
+	}
+	return interp.Checker.CheckSignature(signature, pubkey, scriptCode, isWitness)
+}
+
+// getScriptCode returns the script after the last OP_CODESEPARATOR.
+// The signatures are removed from the legacy script code.
+func (state *executionState) getScriptCode(signatures ...[]byte) []byte {
+	buf := &bytes.Buffer{}
+	for _, elem := range state.elements[state.codeSeparator:] {
+		if !state.isWitness && elem.IsPush && len(elem.Data) > 0 {
+			isSignature := false
+			for _, signature := range signatures {
+				isSignature = isSignature || bytes.Equal(elem.Data, signature)
+			}
+			if isSignature {
+				continue
+			}
+		}
+		writeScriptElement(buf, elem)
+	}
+	return buf.Bytes()
+}
+
+// writeScriptElement writes the element with the original push opcode.
+func writeScriptElement(buf *bytes.Buffer, elem ScriptElement) {
+	buf.WriteByte(elem.Opcode)
+	if !elem.IsPush {
+		return
+	}
+	switch elem.Opcode {
+	case OpPushData1:
+		buf.WriteByte(byte(len(elem.Data)))
+	case OpPushData2:
+		sizeBytes := make([]byte, 2)
+		binary.LittleEndian.PutUint16(sizeBytes, uint16(len(elem.Data)))
+		buf.Write(sizeBytes)
+	case OpPushData4:
+		sizeBytes := make([]byte, 4)
+		binary.LittleEndian.PutUint32(sizeBytes, uint32(len(elem.Data)))
+		buf.Write(sizeBytes)
+	}
+	buf.Write(elem.Data)
+}
+
+// formatSignatureCheck returns the description of the signature check.
+func formatSignatureCheck(signature, pubkey []byte, result SignatureCheckResult) string {
+	status := "valid"
+	if !result.IsValid {
+		status = "invalid"
+	}
+	text := fmt.Sprintf("checksig %s. pubkey=%x", status, pubkey)
+	if result.Sighash != "" {
+		text += fmt.Sprintf(" sighash=%s (%s)", result.Sighash, result.SighashType)
+	}
+	if result.Reason != "" {
+		text += " reason: " + result.Reason
+	}
+	return text
+}
+
+// isPushOnly returns true if the script has only push operations.
+func isPushOnly(script []byte) bool {
+	elements, err := ParseScriptBytes(script)
+	if err != nil {
+		return false
+	}
+	for _, elem := range elements {
+		if !elem.IsPush && elem.Opcode > Op16 {
+			return false
+		}
+	}
+	return true
+}
+
+// castToBool returns the boolean value of the stack item.
+func castToBool(value []byte) bool {
+	for index, b := range value {
+		if b != 0 {
+			// negative zero is false.
+			return !(index == len(value)-1 && b == 0x80)
+		}
+	}
+	return false
+}
+
+// boolToNumber returns 1 if true, otherwise 0.
+func boolToNumber(value bool) int64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// copyStack returns the copy of the stack.
+func copyStack(stack [][]byte) [][]byte {
+	result := make([][]byte, len(stack))
+	copy(result, stack)
+	return result
+}
+
+func (interp *ScriptInterpreter) isTopTrue() bool {
+	value, err := interp.peek(1)
+	return err == nil && castToBool(value)
+}
+
+func (interp *ScriptInterpreter) checkDepth(depth int) error {
+	if len(interp.Stack) < depth {
+		return fmt.Errorf("stack has %d items, but %d items are required", len(interp.Stack), depth)
+	}
+	return nil
+}
+
+// copyItems pushes the copy of the count items from the depth.
+func (interp *ScriptInterpreter) copyItems(depth, count int) error {
+	if err := interp.checkDepth(depth); err != nil {
+		return err
+	}
+	start := len(interp.Stack) - depth
+	interp.Stack = append(interp.Stack, copyStack(interp.Stack[start:start+count])...)
+	return nil
+}
+
+func (interp *ScriptInterpreter) push(value []byte) {
+	interp.Stack = append(interp.Stack, value)
+}
+
+func (interp *ScriptInterpreter) pushNumber(num int64) {
+	interp.push(EncodeScriptNum(num))
+}
+
+func (interp *ScriptInterpreter) pushBool(value bool) {
+	if value {
+		interp.push([]byte{1})
+	} else {
+		interp.push([]byte{})
+	}
+}
+
+// peek returns the item of the depth. (1 is the top)
+func (interp *ScriptInterpreter) peek(depth int) ([]byte, error) {
+	if err := interp.checkDepth(depth); err != nil {
+		return nil, err
+	}
+	return interp.Stack[len(interp.Stack)-depth], nil
+}
+
+// remove removes the item of the depth. (1 is the top)
+func (interp *ScriptInterpreter) remove(depth int) []byte {
+	index := len(interp.Stack) - depth
+	value := interp.Stack[index]
+	interp.Stack = append(interp.Stack[:index], interp.Stack[index+1:]...)
+	return value
+}
+
+func (interp *ScriptInterpreter) pop() ([]byte, error) {
+	value, err := interp.peek(1)
+	if err != nil {
+		return nil, err
+	}
+	interp.Stack = interp.Stack[:len(interp.Stack)-1]
+	return value, nil
+}
+
+func (interp *ScriptInterpreter) popNumber() (int64, error) {
+	value, err := interp.pop()
+	if err != nil {
+		return 0, err
+	}
+	return DecodeScriptNum(value, 4)
+}
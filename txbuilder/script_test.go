@@ -0,0 +1,63 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestScriptAsmRoundTrip(t *testing.T) {
+	data76 := strings.Repeat("ab", 76)
+	testCases := []struct {
+		script string
+		asm    string
+	}{
+		{"76a914fc7250a211deddc70ee5a2738de5f07817351cef88ac",
+			"OP_DUP OP_HASH160 fc7250a211deddc70ee5a2738de5f07817351cef OP_EQUALVERIFY OP_CHECKSIG"},
+		// the 1 byte push is not the small number opcode.
+		{"0105", "05"},
+		{"0181", "81"},
+		{"55", "OP_5"},
+		{"4f", "OP_1NEGATE"},
+		{"00", "0"},
+		// the non-minimal pushes keep the push opcode.
+		{"4c0105", "OP_PUSHDATA1 05"},
+		{"4c00", "OP_PUSHDATA1 0"},
+		{"4d0100aa", "OP_PUSHDATA2 aa"},
+		{"4e01000000aa", "OP_PUSHDATA4 aa"},
+		{"4c4c" + data76, data76},
+		{"ba", "OP_UNKNOWN(0xba)"},
+	}
+	for _, testCase := range testCases {
+		elements, err := ParseScriptHex(testCase.script)
+		if err != nil {
+			t.Errorf("%s: %v", testCase.script, err)
+			continue
+		}
+		if asm := ScriptToAsm(elements); asm != testCase.asm {
+			t.Errorf("%s: asm %s, want %s", testCase.script, asm, testCase.asm)
+		}
+		script, err := ParseScriptAsm(testCase.asm)
+		if err != nil {
+			t.Errorf("%s: %v", testCase.asm, err)
+			continue
+		}
+		if hex.EncodeToString(script) != testCase.script {
+			t.Errorf("%s: script %x, want %s", testCase.asm, script, testCase.script)
+		}
+		if built := NewScriptBuilder().AddElements(elements).Hex(); built != testCase.script {
+			t.Errorf("%s: elements %s, want %s", testCase.script, built, testCase.script)
+		}
+	}
+}
+
+func TestParseScriptAsmError(t *testing.T) {
+	for _, asm := range []string{"OP_PUSHDATA1", "OP_PUSHDATA1 zz", "OP_PUSHDATA1 " + strings.Repeat("00", 256), "OP_UNKNOWN"} {
+		if _, err := ParseScriptAsm(asm); err == nil {
+			t.Errorf("%s: error is nil", asm)
+		}
+	}
+	if opcode, ok := GetOpcodeFromName("op_unknown(0xBA)"); !ok || opcode != 0xba {
+		t.Errorf("unknown opcode name: %x, %v", opcode, ok)
+	}
+}
@@ -0,0 +1,163 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+)
+
+const (
+	// sighashDefault taproot default sighash type.
+	sighashDefault = 0x00
+	// sighashAnyoneCanPayFlag SIGHASH_ANYONECANPAY flag.
+	sighashAnyoneCanPayFlag = 0x80
+	// sighashRangeproofFlag elements SIGHASH_RANGEPROOF flag.
+	sighashRangeproofFlag = 0x40
+	// sighashBaseTypeMask the mask of the base sighash type.
+	sighashBaseTypeMask = 0x1f
+)
+
+// SighashTypeUsage the usage of the sighash type flag.
+const SighashTypeUsage = "sighashtype (all,none,single,default with |anyonecanpay and |rangeproof (elements), or hex byte. ex. all|anyonecanpay, 0x81)"
+
+// SighashType sighash type.
+type SighashType struct {
+	Type         int
+	AnyoneCanPay bool
+	Rangeproof   bool
+}
+
+// ParseSighashType parses the sighash type name or the hex byte value.
+// anyoneCanPay is merged for the -anyonecanpay flag.
+func ParseSighashType(name string, anyoneCanPay bool) (SighashType, error) {
+	var sighashType SighashType
+	text := strings.ToLower(strings.TrimSpace(name))
+	if text == "" {
+		text = "all"
+	}
+
+	if value, ok := parseSighashByte(text); ok {
+		sighashType = NewSighashTypeFromByte(value)
+	} else {
+		for index, item := range strings.Split(text, "|") {
+			switch item = strings.TrimPrefix(strings.TrimSpace(item), "sighash_"); {
+			case index == 0 && item == "all":
+				sighashType.Type = int(cfd.KCfdSigHashAll)
+			case index == 0 && item == "none":
+				sighashType.Type = int(cfd.KCfdSigHashNone)
+			case index == 0 && item == "single":
+				sighashType.Type = int(cfd.KCfdSigHashSingle)
+			case index == 0 && item == "default":
+				sighashType.Type = sighashDefault
+			case index > 0 && item == "anyonecanpay":
+				sighashType.AnyoneCanPay = true
+			case index > 0 && item == "rangeproof":
+				sighashType.Rangeproof = true
+			default:
+				return sighashType, fmt.Errorf("sighashtype %s is unknown type", name)
+			}
+		}
+	}
+	if anyoneCanPay {
+		sighashType.AnyoneCanPay = true
+	}
+	switch sighashType.Type {
+	case sighashDefault:
+		if sighashType.AnyoneCanPay || sighashType.Rangeproof {
+			return sighashType, errors.New("sighashtype default cannot have the flags")
+		}
+	case int(cfd.KCfdSigHashAll), int(cfd.KCfdSigHashNone), int(cfd.KCfdSigHashSingle):
+	default:
+		return sighashType, fmt.Errorf("sighashtype %s is unknown type", name)
+	}
+	return sighashType, nil
+}
+
+// parseSighashByte parses the hex byte value. (ex. 0x81, 81)
+func parseSighashByte(text string) (byte, bool) {
+	if len(text) > 2 && strings.HasPrefix(text, "0x") {
+		text = text[2:]
+	} else if len(text) != 2 {
+		return 0, false
+	}
+	value, err := strconv.ParseUint(text, 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(value), true
+}
+
+// NewSighashTypeFromByte returns a new SighashType from the byte value.
+func NewSighashTypeFromByte(value byte) SighashType {
+	return SighashType{
+		Type:         int(value & sighashBaseTypeMask),
+		AnyoneCanPay: (value & sighashAnyoneCanPayFlag) != 0,
+		Rangeproof:   (value & sighashRangeproofFlag) != 0,
+	}
+}
+
+// NewSighashTypeFromDerSignature returns a new SighashType from the last byte of the der signature.
+func NewSighashTypeFromDerSignature(derSignature string) (SighashType, error) {
+	signature, err := hex.DecodeString(derSignature)
+	if err != nil || len(signature) == 0 {
+		return SighashType{}, errors.New("der signature is invalid")
+	}
+	return NewSighashTypeFromByte(signature[len(signature)-1]), nil
+}
+
+// Byte returns the sighash type byte value.
+func (sighashType SighashType) Byte() byte {
+	value := byte(sighashType.Type)
+	if sighashType.AnyoneCanPay {
+		value |= sighashAnyoneCanPayFlag
+	}
+	if sighashType.Rangeproof {
+		value |= sighashRangeproofFlag
+	}
+	return value
+}
+
+// GetCfdType returns the sighash type for cfd. (the base type with the rangeproof flag)
+func (sighashType SighashType) GetCfdType() int {
+	if sighashType.Rangeproof {
+		return sighashType.Type | sighashRangeproofFlag
+	}
+	return sighashType.Type
+}
+
+// ValidateEcdsa validates the sighash type for the ecdsa signature.
+func (sighashType SighashType) ValidateEcdsa(isElements bool) error {
+	if sighashType.Type == sighashDefault {
+		return errors.New("sighashtype default is only for taproot")
+	}
+	if sighashType.Rangeproof && !isElements {
+		return errors.New("sighashtype rangeproof is only for elements")
+	}
+	return nil
+}
+
+// String returns the sighash type name.
+func (sighashType SighashType) String() string {
+	name := fmt.Sprintf("0x%02x", sighashType.Type)
+	switch sighashType.Type {
+	case sighashDefault:
+		name = "default"
+	case int(cfd.KCfdSigHashAll):
+		name = "all"
+	case int(cfd.KCfdSigHashNone):
+		name = "none"
+	case int(cfd.KCfdSigHashSingle):
+		name = "single"
+	}
+	if sighashType.AnyoneCanPay {
+		name += "|anyonecanpay"
+	}
+	if sighashType.Rangeproof {
+		name += "|rangeproof"
+	}
+	return name
+}
@@ -0,0 +1,243 @@
+package txbuilder
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/keys"
+)
+
+// TxInSignResult sign result of the transaction input.
+type TxInSignResult struct {
+	Txid   string
+	Vout   uint32
+	Status string
+	Detail string
+}
+
+// IsComplete returns true if the input is fully signed.
+func (result TxInSignResult) IsComplete() bool {
+	return result.Status == "signed" || result.Status == "complete" || result.Status == "already signed"
+}
+
+// SignOption sign transaction option.
+type SignOption struct {
+	NetworkType int
+	KeySet      *keys.SigningKeySet
+	SighashType SighashType
+	GrindR      bool
+}
+
+// SignTransaction signs all inputs of the transaction data that the keys match.
+// The transaction hex and the partial signatures of the data are updated.
+func SignTransaction(data *cache.TransactionCacheData, isElements bool, option SignOption) ([]TxInSignResult, error) {
+	if option.KeySet == nil {
+		return nil, errors.New("keys are required")
+	}
+	signer := &transactionSigner{
+		data:        data,
+		isElements:  isElements,
+		networkType: option.NetworkType,
+		keySet:      option.KeySet,
+		sighashType: option.SighashType,
+		grindR:      option.GrindR,
+	}
+	return signer.SignAll()
+}
+
+// transactionSigner signs the inputs of the transaction data.
+type transactionSigner struct {
+	data        *cache.TransactionCacheData
+	isElements  bool
+	networkType int
+	keySet      *keys.SigningKeySet
+	sighashType SighashType
+	grindR      bool
+}
+
+// SignAll signs all inputs that the keys match, and returns the results.
+func (signer *transactionSigner) SignAll() ([]TxInSignResult, error) {
+	tx, err := DecodeTransaction(signer.data.Hex, signer.isElements)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TxInSignResult, len(tx.TxIn))
+	for index, txin := range tx.TxIn {
+		results[index] = TxInSignResult{Txid: txin.Txid, Vout: txin.Vout}
+		var utxo *cache.UtxoData
+		for utxoIndex := range signer.data.Utxos {
+			if signer.data.Utxos[utxoIndex].Txid == txin.Txid && signer.data.Utxos[utxoIndex].Vout == txin.Vout {
+				utxo = &signer.data.Utxos[utxoIndex]
+				break
+			}
+		}
+		if utxo == nil || utxo.Descriptor == "" {
+			results[index].Status = "no utxo"
+			results[index].Detail = "utxo descriptor is not found"
+			continue
+		}
+		if txin.IsSigned() && len(utxo.PartialSigs) == 0 {
+			results[index].Status = "already signed"
+			continue
+		}
+		if err = signer.signInput(utxo, &results[index]); err != nil {
+			results[index].Status = "error"
+			results[index].Detail = err.Error()
+		}
+	}
+	return results, nil
+}
+
+// signInput signs the input with the utxo descriptor.
+func (signer *transactionSigner) signInput(utxo *cache.UtxoData, result *TxInSignResult) error {
+	descList, _, err := cfd.CfdGoParseDescriptor(utxo.Descriptor, signer.networkType, "")
+	if err != nil {
+		return err
+	}
+	hashType := descList[0].HashType
+	lastDesc := descList[len(descList)-1]
+	signingKeys := signer.keySet.GetKeys(utxo.Descriptor)
+
+	if lastDesc.IsMultisig {
+		return signer.signMultisig(utxo, hashType, lastDesc, signingKeys, result)
+	}
+	switch hashType {
+	case int(cfd.KCfdP2pkh), int(cfd.KCfdP2wpkh), int(cfd.KCfdP2shP2wpkh):
+	default:
+		result.Status = "unsupported"
+		result.Detail = "descriptor is not single key or multisig"
+		return nil
+	}
+	pubkey := GetDescriptorKeyPubkey(lastDesc.KeyType, lastDesc.Pubkey, lastDesc.ExtPubkey, lastDesc.ExtPrivkey)
+	key := keys.FindSigningKey(signingKeys, pubkey)
+	if key == nil {
+		result.Status = "no key"
+		result.Detail = "pubkey " + pubkey
+		return nil
+	}
+	var txHex string
+	if signer.isElements {
+		txHex, err = cfd.CfdGoAddConfidentialTxSignWithPrivkey(
+			signer.data.Hex, utxo.Txid, utxo.Vout, hashType, pubkey,
+			key.Privkey, utxo.Amount, utxo.AmountCommitment, signer.sighashType.GetCfdType(),
+			signer.sighashType.AnyoneCanPay, signer.grindR)
+	} else {
+		txHex, err = cfd.CfdGoAddTxSignWithPrivkey(signer.networkType,
+			signer.data.Hex, utxo.Txid, utxo.Vout, hashType, pubkey,
+			key.Privkey, utxo.Amount, signer.sighashType.GetCfdType(), signer.sighashType.AnyoneCanPay, signer.grindR)
+	}
+	if err != nil {
+		return err
+	}
+	signer.data.Hex = txHex
+	result.Status = "signed"
+	return nil
+}
+
+// signMultisig adds the partial signatures of the matched keys,
+// and sets the scriptsig/witness if the signatures reach the required number.
+func (signer *transactionSigner) signMultisig(utxo *cache.UtxoData, hashType int, desc cfd.CfdDescriptorData,
+	signingKeys []keys.SigningKey, result *TxInSignResult) error {
+	txHex, isComplete, err := SignMultisigInput(signer.data.Hex, signer.isElements, signer.networkType, utxo,
+		hashType, desc.RedeemScript, signingKeys, signer.sighashType, signer.grindR)
+	if err != nil {
+		return err
+	}
+	signer.data.Hex = txHex
+	required := int(desc.ReqSigNum)
+	switch {
+	case isComplete:
+		result.Status = "complete"
+		result.Detail = fmt.Sprintf("%d of %d signatures", required, required)
+	case len(utxo.PartialSigs) == 0:
+		result.Status = "no key"
+		utxo.PartialSigs = nil
+	default:
+		result.Status = "partial"
+		result.Detail = fmt.Sprintf("%d of %d signatures", len(utxo.PartialSigs), required)
+	}
+	return nil
+}
+
+// SignMultisigInput adds the partial signatures of the keys to the utxo data,
+// and sets the scriptsig/witness in the pubkey order if the signatures reach the required number.
+// The partial signatures are cleared after the scriptsig/witness is set.
+func SignMultisigInput(txHex string, isElements bool, networkType int, utxo *cache.UtxoData,
+	hashType int, redeemScript string, signingKeys []keys.SigningKey,
+	sighashType SighashType, grindR bool) (newTxHex string, isComplete bool, err error) {
+	script, err := hex.DecodeString(redeemScript)
+	if err != nil {
+		return "", false, err
+	}
+	template := ClassifyScript(script)
+	if template.Type != "multisig" {
+		return "", false, errors.New("redeem script is not multisig")
+	}
+	pubkeys := make([]string, len(template.Pubkeys))
+	for index, pubkey := range template.Pubkeys {
+		pubkeys[index] = hex.EncodeToString(pubkey)
+	}
+	if utxo.PartialSigs == nil {
+		utxo.PartialSigs = map[string]string{}
+	}
+
+	for _, pubkey := range pubkeys {
+		if _, ok := utxo.PartialSigs[pubkey]; ok {
+			continue
+		}
+		key := keys.FindSigningKey(signingKeys, pubkey)
+		if key == nil {
+			continue
+		}
+		var sighash string
+		if isElements {
+			sighash, err = cfd.CfdGoCreateConfidentialSighash(txHex, utxo.Txid, utxo.Vout,
+				hashType, "", redeemScript, utxo.Amount, utxo.AmountCommitment,
+				sighashType.GetCfdType(), sighashType.AnyoneCanPay)
+		} else {
+			sighash, err = cfd.CfdGoCreateSighash(networkType, txHex, utxo.Txid, utxo.Vout,
+				hashType, "", redeemScript, utxo.Amount, sighashType.GetCfdType(), sighashType.AnyoneCanPay)
+		}
+		if err != nil {
+			return "", false, err
+		}
+		signature, err := cfd.CfdGoCalculateEcSignature(sighash, key.Privkey, "", networkType, grindR)
+		if err != nil {
+			return "", false, err
+		}
+		derSignature, err := cfd.CfdGoEncodeSignatureByDer(signature, sighashType.GetCfdType(), sighashType.AnyoneCanPay)
+		if err != nil {
+			return "", false, err
+		}
+		utxo.PartialSigs[pubkey] = derSignature
+	}
+
+	signList := []cfd.CfdMultisigSignData{}
+	for _, pubkey := range pubkeys {
+		if signature, ok := utxo.PartialSigs[pubkey]; ok && len(signList) < template.RequiredSigs {
+			signList = append(signList, cfd.CfdMultisigSignData{
+				Signature:     signature,
+				RelatedPubkey: pubkey,
+			})
+		}
+	}
+	if len(signList) < template.RequiredSigs {
+		return txHex, false, nil
+	}
+	if isElements {
+		txHex, err = cfd.CfdGoAddConfidentialTxMultisigSign(txHex, utxo.Txid, utxo.Vout,
+			hashType, signList, redeemScript)
+	} else {
+		txHex, err = cfd.CfdGoAddTxMultisigSign(networkType, txHex, utxo.Txid, utxo.Vout,
+			hashType, signList, redeemScript)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	utxo.PartialSigs = nil
+	return txHex, true, nil
+}
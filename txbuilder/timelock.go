@@ -0,0 +1,185 @@
+package txbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cfd-cli/cache"
+)
+
+const (
+	// BIP68 sequence flags
+	sequenceDisableFlag = uint32(1) << 31
+	SequenceValueMask   = uint32(0x0000ffff)
+	sequenceGranularity = 512
+)
+
+// EncodeSequenceBlocks encodes the relative timelock in blocks. (BIP68)
+func EncodeSequenceBlocks(blocks uint32) (uint32, error) {
+	if blocks > SequenceValueMask {
+		return 0, fmt.Errorf("relative blocks %d is too large", blocks)
+	}
+	return blocks, nil
+}
+
+// EncodeSequenceTime encodes the relative timelock in seconds. (BIP68)
+// The seconds is rounded up to 512-second units.
+func EncodeSequenceTime(seconds uint32) (uint32, error) {
+	units := (uint64(seconds) + sequenceGranularity - 1) / sequenceGranularity
+	if units > uint64(SequenceValueMask) {
+		return 0, fmt.Errorf("relative time %d seconds is too large", seconds)
+	}
+	return uint32(units) | sequenceTypeFlag, nil
+}
+
+// ParseLocktimeDate parses the date (RFC3339, "2006-01-02 15:04:05" or "2006-01-02" in UTC)
+// into the absolute locktime. (BIP65)
+func ParseLocktimeDate(date string) (uint32, error) {
+	var target time.Time
+	var err error
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if target, err = time.Parse(layout, date); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return 0, fmt.Errorf("locktime date %s is invalid format", date)
+	}
+	unixTime := target.Unix()
+	if unixTime < LocktimeThreshold || unixTime > int64(^uint32(0)) {
+		return 0, fmt.Errorf("locktime date %s is out of range", date)
+	}
+	return uint32(unixTime), nil
+}
+
+// DescribeLocktime returns the description of the locktime.
+func DescribeLocktime(locktime uint32) string {
+	switch {
+	case locktime == 0:
+		return "0 (disabled)"
+	case locktime < LocktimeThreshold:
+		return fmt.Sprintf("%d (block height)", locktime)
+	}
+	return fmt.Sprintf("%d (%s)", locktime,
+		time.Unix(int64(locktime), 0).UTC().Format(time.RFC3339))
+}
+
+// DescribeSequence returns the description of the sequence.
+func DescribeSequence(sequence uint32) string {
+	items := []string{fmt.Sprintf("0x%08x", sequence)}
+	if sequence == SequenceFinal {
+		items = append(items, "final")
+	} else if sequence <= SequenceMaxRbf {
+		items = append(items, "rbf")
+	}
+	if (sequence & sequenceDisableFlag) == 0 {
+		value := sequence & SequenceValueMask
+		if (sequence & sequenceTypeFlag) != 0 {
+			items = append(items, fmt.Sprintf("csv %d seconds", value*sequenceGranularity))
+		} else {
+			items = append(items, fmt.Sprintf("csv %d blocks", value))
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// GetRelativeTimelock returns the relative timelock of the sequence in blocks or seconds. (BIP68)
+// isEnabled is false if the sequence disables the relative timelock.
+func GetRelativeTimelock(sequence uint32) (blocks, seconds uint32, isEnabled bool) {
+	if (sequence & sequenceDisableFlag) != 0 {
+		return 0, 0, false
+	}
+	value := sequence & SequenceValueMask
+	if (sequence & sequenceTypeFlag) != 0 {
+		return 0, value * sequenceGranularity, true
+	}
+	return value, 0, true
+}
+
+// ValidateTimelock validates the locktime and sequences, and returns the warnings.
+// The input is warned only if no spending path of the descriptor satisfies the timelock.
+func ValidateTimelock(tx *Transaction, utxos []cache.UtxoData, networkType int) []string {
+	warnings := []string{}
+	isAllFinal := true
+	for _, txin := range tx.TxIn {
+		if txin.Sequence != SequenceFinal {
+			isAllFinal = false
+		}
+	}
+	if tx.Locktime != 0 && isAllFinal && len(tx.TxIn) > 0 {
+		warnings = append(warnings, "locktime is set but all sequences are final. locktime is disabled")
+	}
+
+	for index, txin := range tx.TxIn {
+		var descriptor string
+		for _, utxo := range utxos {
+			if utxo.Txid == txin.Txid && utxo.Vout == txin.Vout {
+				descriptor = utxo.Descriptor
+			}
+		}
+		if descriptor == "" {
+			continue
+		}
+		_, analysis, err := CreateScriptsigTemplateFromDescriptor(descriptor, networkType)
+		if err != nil {
+			continue
+		}
+		// the input is spendable if any path is satisfiable. (the timelock of the other paths is not required)
+		reasons := []string{}
+		isSatisfiable := false
+		for _, path := range analysis.Paths {
+			if path.IsMixingLock {
+				continue
+			}
+			if err := checkPathTimelock(tx, txin, path); err != nil {
+				reasons = append(reasons, fmt.Sprintf("path (%s): %s", path.PathSummary(), err.Error()))
+				continue
+			}
+			isSatisfiable = true
+			break
+		}
+		if !isSatisfiable && len(reasons) > 0 {
+			warnings = append(warnings, fmt.Sprintf("input[%d] no spending path is satisfiable. %s",
+				index, strings.Join(reasons, ", ")))
+		}
+	}
+	return warnings
+}
+
+// checkPathTimelock checks the sequence and locktime satisfy the path timelock.
+func checkPathTimelock(tx *Transaction, txin *TxIn, path SpendingPath) error {
+	if path.OlderBlocks > 0 || path.OlderTime > 0 {
+		switch {
+		case tx.Version < 2:
+			return errors.New("tx version must be 2 or higher for CSV")
+		case (txin.Sequence & sequenceDisableFlag) != 0:
+			return errors.New("sequence disables the relative timelock")
+		case path.OlderBlocks > 0 && (txin.Sequence&sequenceTypeFlag) != 0:
+			return errors.New("sequence is time-based, but the script requires blocks")
+		case path.OlderTime > 0 && (txin.Sequence&sequenceTypeFlag) == 0:
+			return errors.New("sequence is block-based, but the script requires time")
+		case path.OlderBlocks > 0 && (txin.Sequence&SequenceValueMask) < path.OlderBlocks:
+			return fmt.Errorf("sequence requires %d blocks or more", path.OlderBlocks)
+		case path.OlderTime > 0 && (txin.Sequence&SequenceValueMask) < (path.OlderTime&SequenceValueMask):
+			return fmt.Errorf("sequence requires %d seconds or more",
+				(path.OlderTime&SequenceValueMask)*sequenceGranularity)
+		}
+	}
+	if path.AfterHeight > 0 || path.AfterTime > 0 {
+		switch {
+		case txin.Sequence == SequenceFinal:
+			return errors.New("sequence is final. CLTV requires non-final sequence")
+		case path.AfterHeight > 0 && (tx.Locktime >= LocktimeThreshold):
+			return errors.New("locktime is time, but the script requires block height")
+		case path.AfterTime > 0 && (tx.Locktime < LocktimeThreshold):
+			return errors.New("locktime is block height, but the script requires time")
+		case path.AfterHeight > 0 && tx.Locktime < path.AfterHeight:
+			return fmt.Errorf("locktime requires %d or more", path.AfterHeight)
+		case path.AfterTime > 0 && tx.Locktime < path.AfterTime:
+			return fmt.Errorf("locktime requires %d or more", path.AfterTime)
+		}
+	}
+	return nil
+}
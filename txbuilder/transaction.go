@@ -0,0 +1,696 @@
+package txbuilder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	// elements input flags (outpoint index)
+	txInIssuanceFlag = uint32(0x80000000)
+	txInPeginFlag    = uint32(0x40000000)
+	txInIndexMask    = uint32(0x3fffffff)
+	// SequenceFinal final sequence number.
+	SequenceFinal = uint32(0xffffffff)
+	// SequenceMaxRbf max sequence number with BIP125 replaceable signal.
+	SequenceMaxRbf = uint32(0xfffffffd)
+)
+
+// Transaction transaction data. (bitcoin and elements)
+type Transaction struct {
+	Version    uint32
+	Locktime   uint32
+	IsElements bool
+	TxIn       []*TxIn
+	TxOut      []*TxOut
+}
+
+// TxIn transaction input data.
+type TxIn struct {
+	Txid      string
+	Vout      uint32
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   [][]byte
+	// elements fields
+	IsPegin                  bool
+	Issuance                 *TxInIssuance
+	IssuanceAmountRangeproof []byte
+	InflationKeysRangeproof  []byte
+	PeginWitness             [][]byte
+}
+
+// TxInIssuance elements issuance data.
+type TxInIssuance struct {
+	Nonce         []byte
+	Entropy       []byte
+	Amount        []byte
+	InflationKeys []byte
+}
+
+// TxOut transaction output data.
+type TxOut struct {
+	Amount        int64
+	LockingScript []byte
+	// elements fields (confidential encoding)
+	Asset           []byte
+	Value           []byte
+	Nonce           []byte
+	SurjectionProof []byte
+	Rangeproof      []byte
+}
+
+// DecodeTransaction decodes the transaction hex.
+func DecodeTransaction(txHex string, isElements bool) (*Transaction, error) {
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	reader := &txReader{data: txBytes}
+	tx := &Transaction{IsElements: isElements}
+	if isElements {
+		err = tx.readElements(reader)
+	} else {
+		err = tx.readBitcoin(reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if reader.offset != len(txBytes) {
+		return nil, errors.New("transaction has unknown trailing data")
+	}
+	return tx, nil
+}
+
+func (tx *Transaction) readBitcoin(reader *txReader) (err error) {
+	if tx.Version, err = reader.readUint32(); err != nil {
+		return err
+	}
+	hasWitness := false
+	if reader.remain() >= 2 && reader.data[reader.offset] == 0 && reader.data[reader.offset+1] == 1 {
+		hasWitness = true
+		reader.offset += 2
+	}
+	if err = tx.readTxIns(reader); err != nil {
+		return err
+	}
+	if err = tx.readTxOuts(reader); err != nil {
+		return err
+	}
+	if hasWitness {
+		for _, txin := range tx.TxIn {
+			if txin.Witness, err = reader.readStack(); err != nil {
+				return err
+			}
+		}
+	}
+	tx.Locktime, err = reader.readUint32()
+	return err
+}
+
+func (tx *Transaction) readElements(reader *txReader) (err error) {
+	if tx.Version, err = reader.readUint32(); err != nil {
+		return err
+	}
+	flag, err := reader.readByte()
+	if err != nil {
+		return err
+	}
+	if flag > 1 {
+		return fmt.Errorf("transaction flag %d is invalid", flag)
+	}
+	if err = tx.readTxIns(reader); err != nil {
+		return err
+	}
+	if err = tx.readTxOuts(reader); err != nil {
+		return err
+	}
+	if tx.Locktime, err = reader.readUint32(); err != nil {
+		return err
+	}
+	if flag == 0 {
+		return nil
+	}
+	for _, txin := range tx.TxIn {
+		if txin.IssuanceAmountRangeproof, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+		if txin.InflationKeysRangeproof, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+		if txin.Witness, err = reader.readStack(); err != nil {
+			return err
+		}
+		if txin.PeginWitness, err = reader.readStack(); err != nil {
+			return err
+		}
+	}
+	for _, txout := range tx.TxOut {
+		if txout.SurjectionProof, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+		if txout.Rangeproof, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tx *Transaction) readTxIns(reader *txReader) error {
+	count, err := reader.readVarInt()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		txin := &TxIn{}
+		txidBytes, err := reader.readBytes(32)
+		if err != nil {
+			return err
+		}
+		txin.Txid = hex.EncodeToString(reverseBytes(txidBytes))
+		if txin.Vout, err = reader.readUint32(); err != nil {
+			return err
+		}
+		if txin.ScriptSig, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+		if txin.Sequence, err = reader.readUint32(); err != nil {
+			return err
+		}
+		if tx.IsElements && txin.Vout != 0xffffffff {
+			txin.IsPegin = (txin.Vout & txInPeginFlag) != 0
+			hasIssuance := (txin.Vout & txInIssuanceFlag) != 0
+			txin.Vout &= txInIndexMask
+			if hasIssuance {
+				issuance := &TxInIssuance{}
+				if issuance.Nonce, err = reader.readBytes(32); err != nil {
+					return err
+				}
+				if issuance.Entropy, err = reader.readBytes(32); err != nil {
+					return err
+				}
+				if issuance.Amount, err = reader.readConfidentialValue(); err != nil {
+					return err
+				}
+				if issuance.InflationKeys, err = reader.readConfidentialValue(); err != nil {
+					return err
+				}
+				txin.Issuance = issuance
+			}
+		}
+		tx.TxIn = append(tx.TxIn, txin)
+	}
+	return nil
+}
+
+func (tx *Transaction) readTxOuts(reader *txReader) error {
+	count, err := reader.readVarInt()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		txout := &TxOut{}
+		if tx.IsElements {
+			if txout.Asset, err = reader.readConfidentialData(0x01, 0x0a, 0x0b); err != nil {
+				return err
+			}
+			if txout.Value, err = reader.readConfidentialValue(); err != nil {
+				return err
+			}
+			if txout.Nonce, err = reader.readConfidentialData(0x01, 0x02, 0x03); err != nil {
+				return err
+			}
+			txout.Amount = GetConfidentialValueAmount(txout.Value)
+		} else {
+			value, err := reader.readUint64()
+			if err != nil {
+				return err
+			}
+			txout.Amount = int64(value)
+		}
+		if txout.LockingScript, err = reader.readVarBytes(); err != nil {
+			return err
+		}
+		tx.TxOut = append(tx.TxOut, txout)
+	}
+	return nil
+}
+
+// HasWitness returns true if the transaction has witness data.
+func (tx *Transaction) HasWitness() bool {
+	for _, txin := range tx.TxIn {
+		if len(txin.Witness) > 0 || len(txin.PeginWitness) > 0 ||
+			len(txin.IssuanceAmountRangeproof) > 0 || len(txin.InflationKeysRangeproof) > 0 {
+			return true
+		}
+	}
+	if tx.IsElements {
+		for _, txout := range tx.TxOut {
+			if len(txout.SurjectionProof) > 0 || len(txout.Rangeproof) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Serialize serializes the transaction.
+func (tx *Transaction) Serialize(withWitness bool) []byte {
+	withWitness = withWitness && tx.HasWitness()
+	var buf bytes.Buffer
+	writeUint32(&buf, tx.Version)
+	if tx.IsElements {
+		if withWitness {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	} else if withWitness {
+		buf.Write([]byte{0, 1})
+	}
+
+	writeVarInt(&buf, uint64(len(tx.TxIn)))
+	for _, txin := range tx.TxIn {
+		txin.serializeBase(&buf, tx.IsElements)
+	}
+	writeVarInt(&buf, uint64(len(tx.TxOut)))
+	for _, txout := range tx.TxOut {
+		txout.serializeBase(&buf, tx.IsElements)
+	}
+
+	if !tx.IsElements {
+		if withWitness {
+			for _, txin := range tx.TxIn {
+				writeStack(&buf, txin.Witness)
+			}
+		}
+		writeUint32(&buf, tx.Locktime)
+		return buf.Bytes()
+	}
+
+	writeUint32(&buf, tx.Locktime)
+	if withWitness {
+		for _, txin := range tx.TxIn {
+			txin.serializeWitness(&buf, true)
+		}
+		for _, txout := range tx.TxOut {
+			txout.serializeWitness(&buf)
+		}
+	}
+	return buf.Bytes()
+}
+
+// Hex returns the transaction hex.
+func (tx *Transaction) Hex() string {
+	return hex.EncodeToString(tx.Serialize(true))
+}
+
+// Txid returns the transaction id.
+func (tx *Transaction) Txid() string {
+	first := sha256.Sum256(tx.Serialize(false))
+	second := sha256.Sum256(first[:])
+	return hex.EncodeToString(reverseBytes(second[:]))
+}
+
+// GetBaseSize returns the transaction size without witness.
+func (tx *Transaction) GetBaseSize() int {
+	return len(tx.Serialize(false))
+}
+
+// GetTotalSize returns the transaction size with witness.
+func (tx *Transaction) GetTotalSize() int {
+	return len(tx.Serialize(true))
+}
+
+// GetWeight returns the transaction weight.
+func (tx *Transaction) GetWeight() int {
+	return tx.GetBaseSize()*3 + tx.GetTotalSize()
+}
+
+// GetVsize returns the transaction virtual size.
+func (tx *Transaction) GetVsize() int {
+	return GetVsizeFromWeight(tx.GetWeight())
+}
+
+// GetTxInIndex returns the index of the input.
+func (tx *Transaction) GetTxInIndex(txid string, vout uint32) (int, error) {
+	for index, txin := range tx.TxIn {
+		if txin.Txid == txid && txin.Vout == vout {
+			return index, nil
+		}
+	}
+	return -1, fmt.Errorf("txin %s:%d is not found", txid, vout)
+}
+
+// IsReplaceable returns true if any input signals BIP125 replaceability.
+func (tx *Transaction) IsReplaceable() bool {
+	for _, txin := range tx.TxIn {
+		if txin.Sequence <= SequenceMaxRbf {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVsizeFromWeight returns the virtual size from the weight.
+func GetVsizeFromWeight(weight int) int {
+	return (weight + 3) / 4
+}
+
+// GetBaseSize returns the input size without witness.
+func (txin *TxIn) GetBaseSize(isElements bool) int {
+	var buf bytes.Buffer
+	txin.serializeBase(&buf, isElements)
+	return buf.Len()
+}
+
+// GetWitnessSize returns the witness size of the input.
+func (txin *TxIn) GetWitnessSize(isElements bool) int {
+	var buf bytes.Buffer
+	txin.serializeWitness(&buf, isElements)
+	return buf.Len()
+}
+
+// GetWeight returns the input weight. (witness of the tx with witness flag)
+func (txin *TxIn) GetWeight(isElements bool) int {
+	return txin.GetBaseSize(isElements)*4 + txin.GetWitnessSize(isElements)
+}
+
+// IsSigned returns true if the input has scriptsig or witness.
+func (txin *TxIn) IsSigned() bool {
+	return len(txin.ScriptSig) > 0 || len(txin.Witness) > 0
+}
+
+func (txin *TxIn) serializeBase(buf *bytes.Buffer, isElements bool) {
+	txidBytes, _ := hex.DecodeString(txin.Txid)
+	buf.Write(reverseBytes(txidBytes))
+	vout := txin.Vout
+	if isElements && vout != 0xffffffff {
+		if txin.Issuance != nil {
+			vout |= txInIssuanceFlag
+		}
+		if txin.IsPegin {
+			vout |= txInPeginFlag
+		}
+	}
+	writeUint32(buf, vout)
+	writeVarBytes(buf, txin.ScriptSig)
+	writeUint32(buf, txin.Sequence)
+	if isElements && txin.Issuance != nil {
+		buf.Write(txin.Issuance.Nonce)
+		buf.Write(txin.Issuance.Entropy)
+		writeConfidentialData(buf, txin.Issuance.Amount)
+		writeConfidentialData(buf, txin.Issuance.InflationKeys)
+	}
+}
+
+func (txin *TxIn) serializeWitness(buf *bytes.Buffer, isElements bool) {
+	if !isElements {
+		writeStack(buf, txin.Witness)
+		return
+	}
+	writeVarBytes(buf, txin.IssuanceAmountRangeproof)
+	writeVarBytes(buf, txin.InflationKeysRangeproof)
+	writeStack(buf, txin.Witness)
+	writeStack(buf, txin.PeginWitness)
+}
+
+// GetBaseSize returns the output size without witness.
+func (txout *TxOut) GetBaseSize(isElements bool) int {
+	var buf bytes.Buffer
+	txout.serializeBase(&buf, isElements)
+	return buf.Len()
+}
+
+// GetWitnessSize returns the witness size of the output. (elements only)
+func (txout *TxOut) GetWitnessSize(isElements bool) int {
+	if !isElements {
+		return 0
+	}
+	var buf bytes.Buffer
+	txout.serializeWitness(&buf)
+	return buf.Len()
+}
+
+// GetWeight returns the output weight.
+func (txout *TxOut) GetWeight(isElements bool) int {
+	return txout.GetBaseSize(isElements)*4 + txout.GetWitnessSize(isElements)
+}
+
+// IsFee returns true if the output is elements fee output.
+func (txout *TxOut) IsFee() bool {
+	return len(txout.LockingScript) == 0 && len(txout.Asset) > 0
+}
+
+// GetAsset returns the explicit asset id. Returns empty if the asset is commitment.
+func (txout *TxOut) GetAsset() string {
+	if len(txout.Asset) != 33 || txout.Asset[0] != 1 {
+		return ""
+	}
+	return hex.EncodeToString(reverseBytes(txout.Asset[1:]))
+}
+
+// IsBlinded returns true if the output value is confidential.
+func (txout *TxOut) IsBlinded() bool {
+	return len(txout.Value) == 33
+}
+
+func (txout *TxOut) serializeBase(buf *bytes.Buffer, isElements bool) {
+	if isElements {
+		writeConfidentialData(buf, txout.Asset)
+		writeConfidentialData(buf, txout.Value)
+		writeConfidentialData(buf, txout.Nonce)
+	} else {
+		var value [8]byte
+		binary.LittleEndian.PutUint64(value[:], uint64(txout.Amount))
+		buf.Write(value[:])
+	}
+	writeVarBytes(buf, txout.LockingScript)
+}
+
+func (txout *TxOut) serializeWitness(buf *bytes.Buffer) {
+	writeVarBytes(buf, txout.SurjectionProof)
+	writeVarBytes(buf, txout.Rangeproof)
+}
+
+// GetConfidentialValueAmount returns the amount of the explicit value.
+// Returns 0 if the value is confidential or null.
+func GetConfidentialValueAmount(value []byte) int64 {
+	if len(value) != 9 || value[0] != 1 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(value[1:]))
+}
+
+// NewConfidentialValue returns the explicit confidential value.
+func NewConfidentialValue(amount int64) []byte {
+	value := make([]byte, 9)
+	value[0] = 1
+	binary.BigEndian.PutUint64(value[1:], uint64(amount))
+	return value
+}
+
+// txReader transaction byte reader.
+type txReader struct {
+	data   []byte
+	offset int
+}
+
+func (reader *txReader) remain() int {
+	return len(reader.data) - reader.offset
+}
+
+func (reader *txReader) readBytes(size int) ([]byte, error) {
+	if size < 0 || reader.remain() < size {
+		return nil, errors.New("transaction data is too short")
+	}
+	data := reader.data[reader.offset : reader.offset+size]
+	reader.offset += size
+	return append([]byte{}, data...), nil
+}
+
+func (reader *txReader) readByte() (byte, error) {
+	data, err := reader.readBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return data[0], nil
+}
+
+func (reader *txReader) readUint32() (uint32, error) {
+	data, err := reader.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(data), nil
+}
+
+func (reader *txReader) readUint64() (uint64, error) {
+	data, err := reader.readBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(data), nil
+}
+
+func (reader *txReader) readVarInt() (uint64, error) {
+	prefix, err := reader.readByte()
+	if err != nil {
+		return 0, err
+	}
+	var data []byte
+	switch prefix {
+	case 0xfd:
+		if data, err = reader.readBytes(2); err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint16(data)), nil
+	case 0xfe:
+		if data, err = reader.readBytes(4); err != nil {
+			return 0, err
+		}
+		return uint64(binary.LittleEndian.Uint32(data)), nil
+	case 0xff:
+		if data, err = reader.readBytes(8); err != nil {
+			return 0, err
+		}
+		return binary.LittleEndian.Uint64(data), nil
+	}
+	return uint64(prefix), nil
+}
+
+func (reader *txReader) readVarBytes() ([]byte, error) {
+	size, err := reader.readVarInt()
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(reader.remain()) {
+		return nil, errors.New("transaction data is too short")
+	}
+	return reader.readBytes(int(size))
+}
+
+func (reader *txReader) readStack() ([][]byte, error) {
+	count, err := reader.readVarInt()
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(reader.remain()) {
+		return nil, errors.New("transaction data is too short")
+	}
+	stack := [][]byte{}
+	for i := uint64(0); i < count; i++ {
+		item, err := reader.readVarBytes()
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, item)
+	}
+	return stack, nil
+}
+
+// readConfidentialData reads the confidential data. (null or 33 bytes)
+func (reader *txReader) readConfidentialData(prefixes ...byte) ([]byte, error) {
+	prefix, err := reader.readByte()
+	if err != nil {
+		return nil, err
+	}
+	if prefix == 0 {
+		return []byte{}, nil
+	}
+	for _, target := range prefixes {
+		if prefix == target {
+			data, err := reader.readBytes(32)
+			if err != nil {
+				return nil, err
+			}
+			return append([]byte{prefix}, data...), nil
+		}
+	}
+	return nil, fmt.Errorf("confidential data prefix %d is invalid", prefix)
+}
+
+// readConfidentialValue reads the confidential value. (null, explicit or commitment)
+func (reader *txReader) readConfidentialValue() ([]byte, error) {
+	prefix, err := reader.readByte()
+	if err != nil {
+		return nil, err
+	}
+	size := 0
+	switch prefix {
+	case 0:
+		return []byte{}, nil
+	case 1:
+		size = 8
+	case 8, 9:
+		size = 32
+	default:
+		return nil, fmt.Errorf("confidential value prefix %d is invalid", prefix)
+	}
+	data, err := reader.readBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{prefix}, data...), nil
+}
+
+func writeUint32(buf *bytes.Buffer, value uint32) {
+	var data [4]byte
+	binary.LittleEndian.PutUint32(data[:], value)
+	buf.Write(data[:])
+}
+
+func writeVarInt(buf *bytes.Buffer, value uint64) {
+	switch {
+	case value < 0xfd:
+		buf.WriteByte(byte(value))
+	case value <= 0xffff:
+		buf.WriteByte(0xfd)
+		var data [2]byte
+		binary.LittleEndian.PutUint16(data[:], uint16(value))
+		buf.Write(data[:])
+	case value <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		var data [4]byte
+		binary.LittleEndian.PutUint32(data[:], uint32(value))
+		buf.Write(data[:])
+	default:
+		buf.WriteByte(0xff)
+		var data [8]byte
+		binary.LittleEndian.PutUint64(data[:], value)
+		buf.Write(data[:])
+	}
+}
+
+func writeVarBytes(buf *bytes.Buffer, data []byte) {
+	writeVarInt(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+func writeStack(buf *bytes.Buffer, stack [][]byte) {
+	writeVarInt(buf, uint64(len(stack)))
+	for _, item := range stack {
+		writeVarBytes(buf, item)
+	}
+}
+
+// writeConfidentialData writes the confidential data. (empty is null)
+func writeConfidentialData(buf *bytes.Buffer, data []byte) {
+	if len(data) == 0 {
+		buf.WriteByte(0)
+		return
+	}
+	buf.Write(data)
+}
+
+func reverseBytes(data []byte) []byte {
+	result := make([]byte, len(data))
+	for i := range data {
+		result[len(data)-1-i] = data[i]
+	}
+	return result
+}
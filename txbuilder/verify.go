@@ -0,0 +1,79 @@
+package txbuilder
+
+import (
+	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+)
+
+// TxInVerifyResult verify result of the transaction input.
+type TxInVerifyResult struct {
+	Txid string `json:"txid"`
+	Vout uint32 `json:"vout"`
+	// Status verified, failed or unsigned.
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// VerifyTxInputs verifies all inputs of the transaction with the utxo data.
+func VerifyTxInputs(tx string, isElements bool, utxos []cache.UtxoData, netType int) ([]TxInVerifyResult, error) {
+	txData, err := DecodeTransaction(tx, isElements)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]TxInVerifyResult, len(txData.TxIn))
+	for index, txin := range txData.TxIn {
+		results[index] = verifyTxInWithUtxo(tx, isElements, txin, utxos, netType)
+	}
+	return results, nil
+}
+
+// verifyTxInWithUtxo verifies the input with the descriptor, amount and commitment of the utxo data.
+func verifyTxInWithUtxo(tx string, isElements bool, txin *TxIn, utxos []cache.UtxoData, netType int) TxInVerifyResult {
+	result := TxInVerifyResult{Txid: txin.Txid, Vout: txin.Vout}
+	var utxo *cache.UtxoData
+	for index := range utxos {
+		if utxos[index].Txid == txin.Txid && utxos[index].Vout == txin.Vout {
+			utxo = &utxos[index]
+			break
+		}
+	}
+	switch {
+	case !txin.IsSigned():
+		result.Status = "unsigned"
+		return result
+	case utxo == nil || utxo.Descriptor == "":
+		result.Status = "failed"
+		result.Reason = "utxo descriptor is not found"
+		return result
+	}
+
+	_, _, addrType, address, err := ParseDescriptor(utxo.Descriptor, netType)
+	if err != nil {
+		result.Status = "failed"
+		result.Reason = err.Error()
+		return result
+	}
+	var isVerify bool
+	var reason string
+	if isElements {
+		isVerify, reason, err = cfd.CfdGoVerifyConfidentialTxSignReason(
+			tx, txin.Txid, txin.Vout, address, addrType, "",
+			utxo.Amount, utxo.AmountCommitment)
+	} else {
+		isVerify, reason, err = cfd.CfdGoVerifyTxSignReason(netType, tx,
+			txin.Txid, txin.Vout, address, addrType, "",
+			utxo.Amount, utxo.AmountCommitment)
+	}
+	switch {
+	case err != nil:
+		result.Status = "failed"
+		result.Reason = err.Error()
+	case !isVerify:
+		result.Status = "failed"
+		result.Reason = reason
+	default:
+		result.Status = "verified"
+	}
+	return result
+}
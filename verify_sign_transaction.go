@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -9,8 +10,16 @@ import (
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
+// VerifySignTransactionResult the result of verifysigntransaction.
+type VerifySignTransactionResult struct {
+	Inputs []txbuilder.TxInVerifyResult `json:"inputs"`
+}
+
 // VerifySignTransactionCmd verify sign from transaction.
 type VerifySignTransactionCmd struct {
 	cmd        string
@@ -18,6 +27,7 @@ type VerifySignTransactionCmd struct {
 	tx         *string
 	txFilePath *string
 	isElements *bool
+	nettype    *string
 	txid       *string
 	vout       *uint
 	address    *string
@@ -25,6 +35,7 @@ type VerifySignTransactionCmd struct {
 	descriptor *string
 	amount     *uint64
 	commitment *string
+	isAll      *bool
 }
 
 // NewVerifySignTransactionCmd returns a new VerifySignTransactionCmd struct.
@@ -49,6 +60,7 @@ func (cmd *VerifySignTransactionCmd) Init() {
 	cmd.tx = cmd.flagSet.String("tx", "", "transaction in hex format")
 	cmd.txFilePath = cmd.flagSet.String("file", "", "transaction data file path")
 	cmd.isElements = cmd.flagSet.Bool("elements", false, "elements mode")
+	cmd.nettype = cmd.flagSet.String("network", "", "network type (mainnet/testnet/regtest/liquidv1/liquidregtest)")
 	cmd.txid = cmd.flagSet.String("txid", "", "txin's txid")
 	cmd.vout = cmd.flagSet.Uint("vout", 0, "txin's vout")
 	cmd.descriptor = cmd.flagSet.String("descriptor", "", "txin's utxo output descriptor")
@@ -56,6 +68,7 @@ func (cmd *VerifySignTransactionCmd) Init() {
 	cmd.addrType = cmd.flagSet.String("addresstype", "", "txin's utxo addressType (p2wpkh, p2wsh, p2sh-p2wpkh, p2sh-p2wsh, p2pkh, p2sh)")
 	cmd.amount = cmd.flagSet.Uint64("amount", 0, "txin's utxo amount")
 	cmd.commitment = cmd.flagSet.String("commitment", "", "txin's utxo amount commitment (elements mode only)")
+	cmd.isAll = cmd.flagSet.Bool("all", false, "verify all inputs with the utxo data of the transaction data file")
 }
 
 // GetFlagSet returns the flag set for this command.
@@ -64,44 +77,74 @@ func (cmd *VerifySignTransactionCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *VerifySignTransactionCmd) Do(ctx context.Context) {
+func (cmd *VerifySignTransactionCmd) Do(ctx context.Context) error {
+	result, err := cmd.Result(ctx)
+	if result != nil {
+		inputs := result.(*VerifySignTransactionResult).Inputs
+		if *cmd.isAll {
+			printVerifyResults(inputs)
+		} else {
+			fmt.Printf("outpoint: %s,%d\n", inputs[0].Txid, inputs[0].Vout)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("verify: success.")
+	return nil
+}
+
+// Result performs the command action, and returns the verify results of the inputs.
+// The results are returned with the error if the verification fails.
+func (cmd *VerifySignTransactionCmd) Result(ctx context.Context) (interface{}, error) {
+	data := cache.NewTransactionCacheData()
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		_, err := os.Stat(*cmd.txFilePath)
-		if err != nil {
-			fmt.Println("tx data file not found.")
-			return
+		if _, err := os.Stat(*cmd.txFilePath); err != nil && !cache.IsMemoryPath(*cmd.txFilePath) {
+			return nil, errors.New("tx data file not found.")
 		}
-		txcache, err := ReadTransactionCache(*cmd.txFilePath)
+		txcache, err := cache.ReadTransactionCache(*cmd.txFilePath)
 		if err == nil {
+			data = txcache
 			tx = txcache.Hex
 		} else {
 			bytes, err := ioutil.ReadFile(*cmd.txFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return
+				return nil, err
 			}
 			tx = strings.TrimSpace(string(bytes))
 		}
 	}
 
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return nil, errors.New("tx is required")
 	}
 
-	netType := int(cfd.KCfdNetworkMainnet)
-	if *cmd.isElements {
-		netType = int(cfd.KCfdNetworkLiquidv1)
+	netType, err := txbuilder.GetNetworkType(*cmd.nettype, *cmd.isElements)
+	if err != nil {
+		return nil, err
+	}
+
+	if *cmd.isAll {
+		results, err := txbuilder.VerifyTxInputs(tx, *cmd.isElements, data.Utxos, netType)
+		if err != nil {
+			return nil, err
+		}
+		result := &VerifySignTransactionResult{Inputs: results}
+		for _, input := range results {
+			if input.Status == "failed" {
+				return result, errors.New("verify: fail.")
+			}
+		}
+		return result, nil
 	}
 
 	addrType := -1
 	address := *cmd.address
 	if len(*cmd.descriptor) > 0 {
-		_, _, tempHashType, tempAddr, err := ParseDescriptor(*cmd.descriptor, netType)
+		_, _, tempHashType, tempAddr, err := txbuilder.ParseDescriptor(*cmd.descriptor, netType)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return nil, err
 		}
 		if len(*cmd.addrType) == 0 {
 			addrType = tempHashType
@@ -126,13 +169,11 @@ func (cmd *VerifySignTransactionCmd) Do(ctx context.Context) {
 		case "p2wsh":
 			addrType = int(cfd.KCfdP2wshAddress)
 		default:
-			fmt.Printf("addresstype %s is unknown type.", *cmd.addrType)
-			return
+			return nil, fmt.Errorf("addresstype %s is unknown type.", *cmd.addrType)
 		}
 	}
 
 	var isVerify bool
-	var err error
 	var reason string
 	if *cmd.isElements {
 		isVerify, reason, err = cfd.CfdGoVerifyConfidentialTxSignReason(
@@ -144,14 +185,24 @@ func (cmd *VerifySignTransactionCmd) Do(ctx context.Context) {
 			"", int64(*cmd.amount), *cmd.commitment)
 	}
 	if err != nil {
-		fmt.Println(err)
-		return
+		return nil, err
 	}
 
-	fmt.Printf("outpoint: %s,%d\n", *cmd.txid, *cmd.vout)
-	if isVerify {
-		fmt.Println("verify: success.")
-	} else {
-		fmt.Printf("verify: fail. reason: %s\n", reason)
+	input := txbuilder.TxInVerifyResult{Txid: *cmd.txid, Vout: uint32(*cmd.vout), Status: "verified"}
+	if !isVerify {
+		input.Status = "failed"
+		input.Reason = reason
+		return &VerifySignTransactionResult{Inputs: []txbuilder.TxInVerifyResult{input}},
+			fmt.Errorf("verify: fail. reason: %s", reason)
+	}
+	return &VerifySignTransactionResult{Inputs: []txbuilder.TxInVerifyResult{input}}, nil
+}
+
+// printVerifyResults prints the verify result table of the inputs.
+func printVerifyResults(results []txbuilder.TxInVerifyResult) {
+	fmt.Printf("%-5s %-70s %-9s %s\n", "index", "outpoint", "status", "reason")
+	for index, result := range results {
+		fmt.Printf("%-5d %-70s %-9s %s\n", index,
+			fmt.Sprintf("%s,%d", result.Txid, result.Vout), result.Status, result.Reason)
 	}
 }
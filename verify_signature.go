@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -9,6 +10,9 @@ import (
 	"strings"
 
 	cfd "github.com/cryptogarageinc/cfd-go"
+
+	"cfd-cli/cache"
+	"cfd-cli/txbuilder"
 )
 
 // VerifySignatureCmd verify signature.
@@ -61,8 +65,7 @@ func (cmd *VerifySignatureCmd) Init() {
 	cmd.redeemScript = cmd.flagSet.String("script", "", "txin's utxo redeemScript (not exist descriptor)")
 	cmd.addrType = cmd.flagSet.String("addresstype", "",
 		"txin's utxo addressType (p2wpkh, p2wsh, p2sh-p2wpkh, p2sh-p2wsh, p2pkh, p2sh)")
-	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all",
-		"sighashtype (all,single,none)")
+	cmd.sigHashType = cmd.flagSet.String("sighashtype", "all", txbuilder.SighashTypeUsage)
 	cmd.anyoneCanPay = cmd.flagSet.Bool("anyonecanpay", false, "sighash anyonecanpay flag")
 	cmd.amount = cmd.flagSet.Uint64("amount", 0, "txin's utxo amount")
 	cmd.commitment = cmd.flagSet.String("commitment", "", "txin's utxo amount commitment (elements mode only)")
@@ -74,31 +77,27 @@ func (cmd *VerifySignatureCmd) GetFlagSet() *flag.FlagSet {
 }
 
 // Do performs the command action.
-func (cmd *VerifySignatureCmd) Do(ctx context.Context) {
+func (cmd *VerifySignatureCmd) Do(ctx context.Context) error {
 	var err error
 	tx := *cmd.tx
 	if *cmd.tx == "" && *cmd.txFilePath != "" {
-		_, err = os.Stat(*cmd.txFilePath)
-		if err != nil {
-			fmt.Println("tx data file not found.")
-			return
+		if _, err := os.Stat(*cmd.txFilePath); err != nil && !cache.IsMemoryPath(*cmd.txFilePath) {
+			return errors.New("tx data file not found.")
 		}
-		txcache, err := ReadTransactionCache(*cmd.txFilePath)
+		txcache, err := cache.ReadTransactionCache(*cmd.txFilePath)
 		if err == nil {
 			tx = txcache.Hex
 		} else {
 			bytes, err := ioutil.ReadFile(*cmd.txFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 			tx = strings.TrimSpace(string(bytes))
 		}
 	}
 
 	if tx == "" {
-		fmt.Println("tx is required")
-		return
+		return errors.New("tx is required")
 	}
 
 	netType := int(cfd.KCfdNetworkMainnet)
@@ -110,10 +109,9 @@ func (cmd *VerifySignatureCmd) Do(ctx context.Context) {
 	pubkey := *cmd.pubkey
 	redeemScript := *cmd.redeemScript
 	if len(*cmd.descriptor) > 0 {
-		tempPubkey, tempScript, tempHashType, _, err := ParseDescriptor(*cmd.descriptor, netType)
+		tempPubkey, tempScript, tempHashType, _, err := txbuilder.ParseDescriptor(*cmd.descriptor, netType)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
 		if len(*cmd.addrType) == 0 {
 			addrType = tempHashType
@@ -141,50 +139,38 @@ func (cmd *VerifySignatureCmd) Do(ctx context.Context) {
 		case "p2wsh":
 			addrType = int(cfd.KCfdP2wshAddress)
 		default:
-			fmt.Printf("addresstype %s is unknown type.", *cmd.addrType)
-			return
+			return fmt.Errorf("addresstype %s is unknown type.", *cmd.addrType)
 		}
 	}
 
-	sigHashType := -1
-	anyoneCanPay := *cmd.anyoneCanPay
 	signature := *cmd.signature
+	sighashType, err := txbuilder.ParseSighashType(*cmd.sigHashType, *cmd.anyoneCanPay)
 	if len(signature) > 130 {
-		// der decode
-		signature, sigHashType, anyoneCanPay, err = cfd.CfdGoDecodeSignatureFromDer(signature)
-		if err != nil {
-			fmt.Println(err)
-			return
+		// der decode. the sighash type is the last byte of the der signature.
+		sighashType, err = txbuilder.NewSighashTypeFromDerSignature(signature)
+		if err == nil {
+			signature, _, _, err = cfd.CfdGoDecodeSignatureFromDer(signature)
 		}
 	}
-
-	if sigHashType == -1 {
-		switch *cmd.sigHashType {
-		case "all":
-			sigHashType = int(cfd.KCfdSigHashAll)
-		case "none":
-			sigHashType = int(cfd.KCfdSigHashNone)
-		case "single":
-			sigHashType = int(cfd.KCfdSigHashSingle)
-		default:
-			fmt.Printf("sighashtype %s is unknown type.", *cmd.sigHashType)
-			return
-		}
+	if err == nil {
+		err = sighashType.ValidateEcdsa(*cmd.isElements)
+	}
+	if err != nil {
+		return err
 	}
 
 	isVerify, err := cfd.CfdGoVerifySignature(netType, tx,
 		signature, addrType, pubkey, redeemScript, *cmd.txid,
-		uint32(*cmd.vout), sigHashType, anyoneCanPay,
+		uint32(*cmd.vout), sighashType.GetCfdType(), sighashType.AnyoneCanPay,
 		int64(*cmd.amount), *cmd.commitment)
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	fmt.Printf("outpoint: %s,%d\n", *cmd.txid, *cmd.vout)
-	if isVerify {
-		fmt.Println("verify: success.")
-	} else {
-		fmt.Println("verify: fail.")
+	if !isVerify {
+		return errors.New("verify: fail.")
 	}
+	fmt.Println("verify: success.")
+	return nil
 }